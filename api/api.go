@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hashicorp/consul-terraform-sync/config"
 	"github.com/hashicorp/consul-terraform-sync/event"
 )
 
@@ -74,7 +75,7 @@ type API struct {
 }
 
 // NewAPI create a new API object
-func NewAPI(store *event.Store, port int) *API {
+func NewAPI(store *event.Store, conf *config.Config, port int) *API {
 	mux := http.NewServeMux()
 
 	// retrieve overall status
@@ -86,6 +87,9 @@ func NewAPI(store *event.Store, port int) *API {
 	// retrieve all task statuses
 	mux.Handle(fmt.Sprintf("/%s/%s", defaultAPIVersion, taskStatusPath),
 		newTaskStatusHandler(store, defaultAPIVersion))
+	// retrieve the effective configuration
+	mux.Handle(fmt.Sprintf("/%s/%s", defaultAPIVersion, configPath),
+		newConfigHandler(conf, defaultAPIVersion))
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hashicorp/consul-terraform-sync/config"
 	"github.com/hashicorp/consul-terraform-sync/event"
 )
 
@@ -37,6 +38,11 @@ func TestServe(t *testing.T) {
 			"status/tasks/task_b",
 			http.StatusOK,
 		},
+		{
+			"config",
+			"config",
+			http.StatusOK,
+		},
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -44,7 +50,7 @@ func TestServe(t *testing.T) {
 
 	port, err := FreePort()
 	require.NoError(t, err)
-	api := NewAPI(event.NewStore(), port)
+	api := NewAPI(event.NewStore(), config.DefaultConfig(), port)
 	go api.Serve(ctx)
 
 	for _, tc := range cases {
@@ -80,7 +86,7 @@ func TestServe_context_cancel(t *testing.T) {
 
 	port, err := FreePort()
 	require.NoError(t, err)
-	api := NewAPI(event.NewStore(), port)
+	api := NewAPI(event.NewStore(), config.DefaultConfig(), port)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	errCh := make(chan error)
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/hashicorp/consul-terraform-sync/config"
+)
+
+const configPath = "config"
+
+// configHandler handles the config endpoint
+type configHandler struct {
+	effective *config.EffectiveConfig
+	version   string
+}
+
+// newConfigHandler returns a new config handler. The effective configuration
+// is determined once since the configuration does not change while running.
+func newConfigHandler(conf *config.Config, version string) *configHandler {
+	return &configHandler{
+		effective: conf.Effective(),
+		version:   version,
+	}
+}
+
+// ServeHTTP serves the config endpoint which returns the effective
+// configuration with sensitive values redacted and the source of each block
+func (h *configHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[TRACE] (api.config) requesting config '%s'", r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		jsonResponse(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "method not allowed, the config endpoint is read-only",
+		})
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, h.effective)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul-terraform-sync/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	conf := config.DefaultConfig()
+	conf.Consul.Token = config.String("secret")
+	conf.Tasks = &config.TaskConfigs{{
+		Name:     config.String("task"),
+		Source:   config.String("source"),
+		Services: []string{"api"},
+	}}
+	conf.Finalize()
+
+	handler := newConfigHandler(conf, "v1")
+
+	cases := []struct {
+		name       string
+		method     string
+		statusCode int
+	}{
+		{
+			"get",
+			http.MethodGet,
+			http.StatusOK,
+		},
+		{
+			"put",
+			http.MethodPut,
+			http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "/v1/config", nil)
+			require.NoError(t, err)
+			resp := httptest.NewRecorder()
+
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, tc.statusCode, resp.Code)
+			if tc.statusCode != http.StatusOK {
+				return
+			}
+
+			var actual config.EffectiveConfig
+			decoder := json.NewDecoder(resp.Body)
+			require.NoError(t, decoder.Decode(&actual))
+
+			consul := actual.Config["consul"].(map[string]interface{})
+			assert.Equal(t, "(redacted)", consul["token"])
+
+			tasks := actual.Config["task"].([]interface{})
+			require.Len(t, tasks, 1)
+			task := tasks[0].(map[string]interface{})
+			assert.Equal(t, "task", task["name"])
+		})
+	}
+}
@@ -19,6 +19,10 @@ type TaskStatus struct {
 	Services  []string      `json:"services"`
 	EventsURL string        `json:"events_url"`
 	Events    []event.Event `json:"events,omitempty"`
+
+	// LastOutcome is the outcome of the most recent event. It distinguishes a
+	// failed apply from a failed handler after a successful apply.
+	LastOutcome string `json:"last_outcome,omitempty"`
 }
 
 // taskStatusHandler handles the task status endpoint
@@ -121,12 +125,18 @@ func makeTaskStatus(taskName string, events []event.Event, version string) TaskS
 		}
 	}
 
+	var lastOutcome string
+	if len(events) > 0 {
+		lastOutcome = events[0].Outcome
+	}
+
 	return TaskStatus{
-		TaskName:  taskName,
-		Status:    successToStatus(successes),
-		Providers: mapKeyToArray(uniqProviders),
-		Services:  mapKeyToArray(uniqServices),
-		EventsURL: makeEventsURL(events, version, taskName),
+		TaskName:    taskName,
+		Status:      successToStatus(successes),
+		Providers:   mapKeyToArray(uniqProviders),
+		Services:    mapKeyToArray(uniqServices),
+		EventsURL:   makeEventsURL(events, version, taskName),
+		LastOutcome: lastOutcome,
 	}
 }
 
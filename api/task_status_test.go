@@ -292,6 +292,7 @@ func TestTaskStatus_MakeStatus(t *testing.T) {
 			[]event.Event{
 				event.Event{
 					Success: true,
+					Outcome: event.OutcomeSuccess,
 					Config: &event.Config{
 						Providers: []string{"local", "null"},
 						Services:  []string{"api", "web"},
@@ -299,6 +300,7 @@ func TestTaskStatus_MakeStatus(t *testing.T) {
 				},
 				event.Event{
 					Success: false,
+					Outcome: event.OutcomeHandlerFailed,
 					Config: &event.Config{
 						Providers: []string{"local"},
 					},
@@ -312,11 +314,12 @@ func TestTaskStatus_MakeStatus(t *testing.T) {
 				},
 			},
 			TaskStatus{
-				TaskName:  "test_task",
-				Status:    StatusDegraded,
-				Providers: []string{"local", "null", "f5"},
-				Services:  []string{"api", "web", "db"},
-				EventsURL: "/v1/status/tasks/test_task?include=events",
+				TaskName:    "test_task",
+				Status:      StatusDegraded,
+				Providers:   []string{"local", "null", "f5"},
+				Services:    []string{"api", "web", "db"},
+				EventsURL:   "/v1/status/tasks/test_task?include=events",
+				LastOutcome: event.OutcomeSuccess,
 			},
 		},
 		{
@@ -335,6 +338,7 @@ func TestTaskStatus_MakeStatus(t *testing.T) {
 			[]event.Event{
 				event.Event{
 					Success: false,
+					Outcome: event.OutcomeHandlerFailed,
 					Config:  nil,
 				},
 				event.Event{
@@ -343,11 +347,12 @@ func TestTaskStatus_MakeStatus(t *testing.T) {
 				},
 			},
 			TaskStatus{
-				TaskName:  "test_task",
-				Status:    StatusCritical,
-				Providers: []string{},
-				Services:  []string{},
-				EventsURL: "/v1/status/tasks/test_task?include=events",
+				TaskName:    "test_task",
+				Status:      StatusCritical,
+				Providers:   []string{},
+				Services:    []string{},
+				EventsURL:   "/v1/status/tasks/test_task?include=events",
+				LastOutcome: event.OutcomeHandlerFailed,
 			},
 		},
 	}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -67,7 +68,8 @@ func NewCLI(out, err io.Writer) *CLI {
 func (cli *CLI) Run(args []string) int {
 	// Handle parsing the CLI flags.
 	var configFiles, inspectTasks config.FlagAppendSliceValue
-	var isVersion, isInspect, isOnce bool
+	var printFormat config.FlagFormatValue
+	var isVersion, isInspect, isOnce, isValidate, isSchema bool
 	var clientType string
 	var help, h bool
 
@@ -89,6 +91,15 @@ func (cli *CLI) Run(args []string) int {
 		"changes are applied in this mode.")
 	f.BoolVar(&isOnce, "once", false, "Render templates and run tasks once. "+
 		"Does not run the process as a daemon and disables buffer periods.")
+	f.BoolVar(&isValidate, "validate", false, "Validate the configuration "+
+		"and the files referenced by tasks, print any problems found, and then "+
+		"exits. Does not connect to Consul or install Terraform.")
+	f.Var(&printFormat, "print-config", "Print the effective configuration "+
+		"after merging all configuration files and applying defaults, and then "+
+		"exits. Sensitive values are redacted and blocks are annotated with the "+
+		"files they are defined in. Use -print-config=json for JSON output.")
+	f.BoolVar(&isSchema, "config-schema", false, "Print the JSON Schema of "+
+		"the configuration in the JSON format, and then exits.")
 	f.BoolVar(&isVersion, "version", false, "Print the version of this daemon.")
 
 	// Setup help flags for custom output
@@ -117,6 +128,10 @@ func (cli *CLI) Run(args []string) int {
 		return ExitCodeOK
 	}
 
+	if isSchema {
+		return cli.printSchema()
+	}
+
 	// Validate required flags
 	if len(configFiles) == 0 {
 		log.Printf("[ERR] config file(s) required, use --config-dir or --config-file flag options")
@@ -124,6 +139,14 @@ func (cli *CLI) Run(args []string) int {
 		return ExitCodeRequiredFlagsError
 	}
 
+	if isValidate {
+		return cli.validate(configFiles)
+	}
+
+	if printFormat != "" {
+		return cli.printConfig(configFiles, string(printFormat))
+	}
+
 	// Build the config.
 	conf, err := config.BuildConfig([]string(configFiles))
 	if err != nil {
@@ -195,7 +218,7 @@ func (cli *CLI) Run(args []string) int {
 		if isOnce || isInspect {
 			return
 		}
-		api := api.NewAPI(store, config.IntVal(conf.Port))
+		api := api.NewAPI(store, conf, config.IntVal(conf.Port))
 		if err = api.Serve(ctx); err != nil {
 			if err == context.Canceled {
 				exitCh <- struct{}{}
@@ -293,6 +316,59 @@ func (cli *CLI) Run(args []string) int {
 	}
 }
 
+// validate checks the configuration files and reports all of the problems
+// found with their positions.
+func (cli *CLI) validate(paths []string) int {
+	problems := config.Check(paths)
+	if len(problems) == 0 {
+		fmt.Fprintln(cli.outStream, "The configuration is valid.")
+		return ExitCodeOK
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(cli.errStream, p)
+	}
+	fmt.Fprintf(cli.errStream, "Found %d problem(s) with the configuration.\n",
+		len(problems))
+	return ExitCodeConfigError
+}
+
+// printConfig prints the effective configuration built from the
+// configuration files in the format.
+func (cli *CLI) printConfig(paths []string, format string) int {
+	conf, err := config.BuildConfig(paths)
+	if err != nil {
+		fmt.Fprintf(cli.errStream, "Error building configuration: %s\n", err)
+		return ExitCodeConfigError
+	}
+	conf.Finalize()
+
+	effective := conf.Effective()
+	switch format {
+	case "json":
+		b, err := effective.JSON()
+		if err != nil {
+			fmt.Fprintf(cli.errStream, "Error encoding configuration: %s\n", err)
+			return ExitCodeError
+		}
+		fmt.Fprintln(cli.outStream, string(b))
+	default:
+		fmt.Fprint(cli.outStream, string(effective.HCL()))
+	}
+	return ExitCodeOK
+}
+
+// printSchema prints the JSON Schema of the configuration.
+func (cli *CLI) printSchema() int {
+	b, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(cli.errStream, "Error encoding schema: %s\n", err)
+		return ExitCodeError
+	}
+	fmt.Fprintln(cli.outStream, string(b))
+	return ExitCodeOK
+}
+
 // printFlags prints out select flags
 func printFlags(f *flag.FlagSet) {
 	f.VisitAll(func(f *flag.Flag) {
@@ -25,6 +25,9 @@ type TerraformCLI struct {
 	workingDir string
 	workspace  string
 	varFiles   []string
+
+	// tfvarsFilename is the input variables file generated by Sync
+	tfvarsFilename string
 }
 
 // TerraformCLIConfig configures the Terraform client
@@ -35,6 +38,10 @@ type TerraformCLIConfig struct {
 	WorkingDir string
 	Workspace  string
 	VarFiles   []string
+
+	// TFVarsFilename is the input variables file generated by Sync for the
+	// task. Defaults to terraform.tfvars when empty.
+	TFVarsFilename string
 }
 
 // NewTerraformCLI creates a terraform-exec client and configures and
@@ -76,11 +83,17 @@ func NewTerraformCLI(config *TerraformCLIConfig) (*TerraformCLI, error) {
 		log.Printf("[INFO] (client.terraformcli) persiting Terraform logs on disk: %s", logPath)
 	}
 
+	tfvarsFilename := config.TFVarsFilename
+	if tfvarsFilename == "" {
+		tfvarsFilename = tftmpl.TFVarsFilename
+	}
+
 	client := &TerraformCLI{
-		tf:         tf,
-		workingDir: config.WorkingDir,
-		workspace:  config.Workspace,
-		varFiles:   config.VarFiles,
+		tf:             tf,
+		workingDir:     config.WorkingDir,
+		workspace:      config.Workspace,
+		varFiles:       config.VarFiles,
+		tfvarsFilename: tfvarsFilename,
 	}
 	log.Printf("[TRACE] (client.terraformcli) created Terraform CLI client %s", client.GoString())
 
@@ -145,7 +158,7 @@ func (t *TerraformCLI) Apply(ctx context.Context) error {
 	for i, vf := range t.varFiles {
 		opts[i] = tfexec.VarFile(vf)
 	}
-	opts[numFiles] = tfexec.VarFile(tftmpl.TFVarsFilename)
+	opts[numFiles] = tfexec.VarFile(t.tfvarsFilename)
 
 	return t.tf.Apply(ctx, opts...)
 }
@@ -158,7 +171,7 @@ func (t *TerraformCLI) Plan(ctx context.Context) error {
 	for i, vf := range t.varFiles {
 		opts[i] = tfexec.VarFile(vf)
 	}
-	opts[numFiles] = tfexec.VarFile(tftmpl.TFVarsFilename)
+	opts[numFiles] = tfexec.VarFile(t.tfvarsFilename)
 
 	_, err := t.tf.Plan(ctx, opts...)
 	return err
@@ -173,10 +186,12 @@ func (t *TerraformCLI) GoString() string {
 	return fmt.Sprintf("&TerraformCLI{"+
 		"WorkingDir:%s, "+
 		"WorkSpace:%s, "+
-		"VarFiles:%s"+
+		"VarFiles:%s, "+
+		"TFVarsFilename:%s"+
 		"}",
 		t.workingDir,
 		t.workspace,
 		t.varFiles,
+		t.tfvarsFilename,
 	)
 }
@@ -87,6 +87,7 @@ func TestNewTerraformCLI(t *testing.T) {
 
 			assert.NoError(t, err)
 			assert.NotNil(t, actual)
+			assert.Equal(t, "terraform.tfvars", actual.tfvarsFilename)
 		})
 	}
 }
@@ -260,8 +261,9 @@ func TestTerraformCLIGoString(t *testing.T) {
 		{
 			"happy path",
 			&TerraformCLI{
-				workingDir: "path/to/wd",
-				workspace:  "ws",
+				workingDir:     "path/to/wd",
+				workspace:      "ws",
+				tfvarsFilename: "terraform.tfvars.json",
 			},
 		},
 	}
@@ -276,6 +278,7 @@ func TestTerraformCLIGoString(t *testing.T) {
 			assert.Contains(t, tc.tf.GoString(), "&TerraformCLI")
 			assert.Contains(t, tc.tf.GoString(), tc.tf.workingDir)
 			assert.Contains(t, tc.tf.GoString(), tc.tf.workspace)
+			assert.Contains(t, tc.tf.GoString(), tc.tf.tfvarsFilename)
 		})
 	}
 }
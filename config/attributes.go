@@ -0,0 +1,349 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+// Problems is a list of configuration problems with positions that is used as
+// an error.
+type Problems []Problem
+
+// Error returns the problems with one problem per line.
+func (p Problems) Error() string {
+	lines := make([]string, len(p))
+	for i, problem := range p {
+		lines[i] = problem.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// checkAttributes parses a configuration file and checks the attributes and
+// the types of the values against the configuration structs before the file
+// is decoded. Unknown attributes are suggested the closest known attribute.
+// The problems found include the position of the attribute within the file.
+func checkAttributes(filename string, content []byte) error {
+	f, err := hcl.ParseBytes(content)
+	if err != nil {
+		var posErr *parser.PosError
+		if errors.As(err, &posErr) {
+			return Problems{{
+				Pos:     newPosition(filename, posErr.Pos),
+				Message: posErr.Err.Error(),
+			}}
+		}
+		return Problems{{Pos: Position{Filename: filename}, Message: err.Error()}}
+	}
+
+	root, ok := f.Node.(*ast.ObjectList)
+	if !ok {
+		return nil
+	}
+
+	c := &attrChecker{filename: filename}
+	c.checkObject(root, reflect.TypeOf(Config{}), "")
+	if len(c.problems) > 0 {
+		return c.problems
+	}
+	return nil
+}
+
+// attrChecker collects the problems with the attributes of a file.
+type attrChecker struct {
+	filename string
+	problems Problems
+}
+
+func (c *attrChecker) report(pos token.Pos, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{
+		Pos:     newPosition(c.filename, pos),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkObject checks the attributes of an object against a struct type. The
+// block describes the object for messages, and is empty for the root object.
+func (c *attrChecker) checkObject(list *ast.ObjectList, t reflect.Type, block string) {
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+		c.checkItem(item.Keys, item, t, block)
+	}
+}
+
+// checkItem checks an attribute of an object against a struct type. HCL
+// blocks with labels, like driver "terraform" {}, have a key for each label
+// which are checked as nested attributes.
+func (c *attrChecker) checkItem(keys []*ast.ObjectKey, item *ast.ObjectItem,
+	t reflect.Type, block string) {
+	key := keys[0]
+	name := keyValue(key)
+	pos := keyPos(key, item)
+
+	field, ok := structField(t, name)
+	if !ok {
+		msg := fmt.Sprintf("unknown attribute %q", name)
+		if block != "" {
+			msg += fmt.Sprintf(" in %s", block)
+		}
+		if suggestion := closestMatch(name, structFieldNames(t)); suggestion != "" {
+			msg += fmt.Sprintf("; did you mean %q?", suggestion)
+		}
+		c.report(pos, "%s", msg)
+		return
+	}
+
+	path := name
+	if block != "" {
+		path = block + "." + name
+	}
+	c.checkValue(keys[1:], item, field.Type, name, path, pos)
+}
+
+// checkValue checks the value of an attribute against the type of its field.
+func (c *attrChecker) checkValue(keys []*ast.ObjectKey, item *ast.ObjectItem,
+	t reflect.Type, name, path string, pos token.Pos) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		if len(keys) > 0 {
+			c.report(pos, "invalid value for %q: expected a duration", path)
+			return
+		}
+		c.checkLiteral(item.Val, path, pos, "a duration", func(v interface{}) bool {
+			switch v := v.(type) {
+			case int64:
+				return true
+			case string:
+				_, err := time.ParseDuration(v)
+				return err == nil
+			}
+			return false
+		})
+
+	case t.Kind() == reflect.Struct:
+		if len(keys) > 0 {
+			c.checkItem(keys, item, t, path)
+			return
+		}
+		objs := valueObjects(item.Val)
+		if len(objs) == 0 {
+			c.report(pos, "invalid value for %q: expected a block", path)
+		}
+		for _, obj := range objs {
+			c.checkObject(obj.List, t, path)
+		}
+
+	case t.Kind() == reflect.Slice:
+		elem := t.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+
+		switch elem.Kind() {
+		case reflect.Struct:
+			// Repeated blocks, like task and service, are described by their
+			// name in messages.
+			if len(keys) > 0 {
+				c.checkItem(keys, item, elem, name)
+				return
+			}
+
+			objs := valueObjects(item.Val)
+			if len(objs) == 0 {
+				c.report(pos, "invalid value for %q: expected a block", path)
+			}
+			for _, obj := range objs {
+				block := name
+				if n := attrValue(obj, "name"); n != "" {
+					block = fmt.Sprintf("%s %q", name, n)
+				}
+				c.checkObject(obj.List, elem, block)
+			}
+
+		case reflect.String:
+			// A single value is decoded as a list with one element.
+			if list, ok := item.Val.(*ast.ListType); ok {
+				for _, v := range list.List {
+					c.checkLiteral(v, path, pos, "a list of strings", nil)
+				}
+				return
+			}
+			c.checkLiteral(item.Val, path, pos, "a list of strings", nil)
+		}
+
+	case t.Kind() == reflect.String:
+		c.checkLiteral(item.Val, path, pos, "a string", nil)
+
+	case t.Kind() == reflect.Bool:
+		c.checkLiteral(item.Val, path, pos, "a boolean", func(v interface{}) bool {
+			switch v := v.(type) {
+			case bool:
+				return true
+			case string:
+				_, err := strconv.ParseBool(v)
+				return err == nil
+			}
+			return false
+		})
+
+	case t.Kind() == reflect.Int:
+		c.checkLiteral(item.Val, path, pos, "a number", func(v interface{}) bool {
+			switch v := v.(type) {
+			case int64:
+				return true
+			case string:
+				_, err := strconv.Atoi(v)
+				return err == nil
+			}
+			return false
+		})
+	}
+
+	// Maps and interfaces have arbitrary attributes and are not checked.
+}
+
+// checkLiteral checks that a value is a literal, and that the value of the
+// literal is valid if a validation function is given.
+func (c *attrChecker) checkLiteral(val ast.Node, path string, pos token.Pos,
+	expected string, valid func(interface{}) bool) {
+	lit, ok := val.(*ast.LiteralType)
+	if !ok {
+		c.report(pos, "invalid value for %q: expected %s", path, expected)
+		return
+	}
+	// Values interpolated from the environment or files are checked once
+	// decoded.
+	if s, ok := lit.Token.Value().(string); ok && hcltmpl.ContainsInterpolation(s) {
+		return
+	}
+	if valid != nil && !valid(lit.Token.Value()) {
+		c.report(pos, "invalid value for %q: expected %s, got %s", path,
+			expected, lit.Token.Text)
+	}
+}
+
+// valueObjects returns the objects of a value, which is either an object or a
+// list of objects.
+func valueObjects(val ast.Node) []*ast.ObjectType {
+	switch v := val.(type) {
+	case *ast.ObjectType:
+		return []*ast.ObjectType{v}
+	case *ast.ListType:
+		var objs []*ast.ObjectType
+		for _, elem := range v.List {
+			if obj, ok := elem.(*ast.ObjectType); ok {
+				objs = append(objs, obj)
+			}
+		}
+		return objs
+	}
+	return nil
+}
+
+// keyPos returns the position of an attribute key. The JSON parser does not
+// record the positions of keys, so the position of the assignment is used.
+func keyPos(key *ast.ObjectKey, item *ast.ObjectItem) token.Pos {
+	if pos := key.Pos(); pos.IsValid() {
+		return pos
+	}
+	return item.Assign
+}
+
+// structField returns the field of a struct decoded from the attribute name.
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath == "" && fieldName(f) == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// structFieldNames returns the sorted attribute names of a struct.
+func structFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath == "" {
+			names = append(names, fieldName(f))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fieldName returns the attribute name of a struct field from its
+// mapstructure tag.
+func fieldName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// closestMatch returns the option closest to the value by edit distance, if
+// it is close enough to be a likely typo. An empty string is returned if
+// there is no close option.
+func closestMatch(value string, options []string) string {
+	best := ""
+	bestDistance := len(value)/3 + 2
+	for _, o := range options {
+		if d := editDistance(value, o); d < bestDistance {
+			best = o
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
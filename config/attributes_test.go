@@ -0,0 +1,210 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAttributes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filename string
+		content  string
+		problems []string
+	}{
+		{
+			"long hcl",
+			"long.hcl",
+			"",
+			nil,
+		}, {
+			"unknown attribute",
+			"a.hcl",
+			`
+task {
+  name = "web"
+  servces = ["api"]
+}`,
+			[]string{`a.hcl:4:3: unknown attribute "servces" in task "web"; ` +
+				`did you mean "services"?`},
+		}, {
+			"unknown top-level attribute",
+			"a.hcl",
+			`log_levle = "INFO"`,
+			[]string{`a.hcl:1:1: unknown attribute "log_levle"; did you mean "log_level"?`},
+		}, {
+			"no suggestion",
+			"a.hcl",
+			`consul {
+  tls {
+    unrelated = true
+  }
+}`,
+			[]string{`a.hcl:3:5: unknown attribute "unrelated" in consul.tls`},
+		}, {
+			"labeled block",
+			"a.hcl",
+			`driver "terraform" {
+  working_dri = "dir"
+}`,
+			[]string{`a.hcl:2:3: unknown attribute "working_dri" in driver.terraform; ` +
+				`did you mean "working_dir"?`},
+		}, {
+			"unknown block label",
+			"a.hcl",
+			`driver "terafrom" {}`,
+			[]string{`a.hcl:1:8: unknown attribute "terafrom" in driver; ` +
+				`did you mean "terraform"?`},
+		}, {
+			"invalid types",
+			"a.hcl",
+			`port = "abc"
+syslog {
+  enabled = "maybe"
+}
+buffer_period {
+  min = "5 seconds"
+}
+consul {
+  address = ["a", "b"]
+}
+service = "api"
+task {
+  name = "web"
+  services = [{ name = "api" }]
+}`,
+			[]string{
+				`a.hcl:1:1: invalid value for "port": expected a number, got "abc"`,
+				`a.hcl:3:3: invalid value for "syslog.enabled": expected a boolean`,
+				`a.hcl:6:3: invalid value for "buffer_period.min": expected a duration`,
+				`a.hcl:9:3: invalid value for "consul.address": expected a string`,
+				`a.hcl:11:1: invalid value for "service": expected a block`,
+				`a.hcl:14:3: invalid value for "task \"web\".services": expected a list of strings`,
+			},
+		}, {
+			"weakly typed values",
+			"a.hcl",
+			`port = "8502"
+syslog {
+  enabled = "true"
+}
+buffer_period {
+  min = "5s"
+}
+task {
+  name = "web"
+  services = "api"
+}`,
+			nil,
+		}, {
+			"interpolated values",
+			"a.hcl",
+			`port = "${env:PORT}"
+syslog {
+  enabled = "${file:/path/to/enabled}"
+}`,
+			nil,
+		}, {
+			"arbitrary attributes",
+			"a.hcl",
+			`terraform_provider "aws" {
+  anything = "value"
+}
+driver "terraform" {
+  backend "consul" {
+    anything = "value"
+  }
+}
+task {
+  name = "web"
+  handler "aws" {
+    anything = "value"
+  }
+}`,
+			nil,
+		}, {
+			"json",
+			"a.json",
+			`{
+  "task": [{
+    "name": "web",
+    "servces": ["api"]
+  }]
+}`,
+			[]string{`a.json:4:14: unknown attribute "servces" in task "web"; ` +
+				`did you mean "services"?`},
+		}, {
+			"syntax error",
+			"a.hcl",
+			`task {`,
+			[]string{`a.hcl:1:`},
+		},
+	}
+
+	long, err := ioutil.ReadFile("testdata/long.hcl")
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content := tc.content
+			if tc.name == "long hcl" {
+				content = string(long)
+			}
+
+			err := checkAttributes(tc.filename, []byte(content))
+			if len(tc.problems) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			problems, ok := err.(Problems)
+			require.True(t, ok)
+			require.Len(t, problems, len(tc.problems), err.Error())
+			for i, p := range problems {
+				assert.Contains(t, p.String(), tc.problems[i])
+			}
+		})
+	}
+}
+
+func TestFromFile_attributes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-attributes-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.hcl")
+	content := []byte("task {\n  name = \"web\"\n  servces = [\"api\"]\n}\n")
+	require.NoError(t, ioutil.WriteFile(path, content, 0644))
+
+	_, err = fromFile(path)
+	require.Error(t, err)
+	assert.Equal(t, path+`:3:3: unknown attribute "servces" in task "web"; `+
+		`did you mean "services"?`, err.Error())
+}
+
+func TestClosestMatch(t *testing.T) {
+	options := []string{"services", "source", "providers", "name"}
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"servces", "services"},
+		{"sources", "source"},
+		{"provider", "providers"},
+		{"nmae", "name"},
+		{"unrelated", ""},
+		{"x", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			assert.Equal(t, tc.expected, closestMatch(tc.value, options))
+		})
+	}
+}
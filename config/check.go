@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Problem is an issue with the configuration found by Check. Problems with a
+// configuration block include the position of the block when it is known.
+type Problem struct {
+	Pos     Position
+	Message string
+}
+
+// String returns the problem prefixed by its position.
+func (p Problem) String() string {
+	if !p.Pos.IsValid() {
+		return p.Message
+	}
+	return fmt.Sprintf("%s: %s", p.Pos, p.Message)
+}
+
+// Check builds the configuration from the files at the paths and validates
+// it without connecting to Consul or installing Terraform. Unlike Validate,
+// checking continues past the first problem and also verifies that the files
+// referenced by tasks exist. Problems are sorted by position.
+func Check(paths []string) []Problem {
+	conf, err := BuildConfig(paths)
+	if err != nil {
+		if problems, ok := err.(Problems); ok {
+			return problems
+		}
+		return []Problem{{Message: err.Error()}}
+	}
+	conf.Finalize()
+
+	c := &checker{conf: conf, positions: conf.positions}
+	c.checkDriver()
+	c.checkConsul()
+	c.checkVault()
+	c.checkServices()
+	c.checkProviders()
+	c.checkTasks()
+
+	if err := conf.BufferPeriod.Validate(); err != nil {
+		c.report(Position{}, "invalid buffer_period: %s", err)
+	}
+	if err := conf.validateDynamicConfigs(); err != nil {
+		c.report(Position{}, "%s", err)
+	}
+
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		a, b := c.problems[i].Pos, c.problems[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.problems
+}
+
+// checker collects the problems of a configuration.
+type checker struct {
+	conf      *Config
+	positions blockPositions
+	problems  []Problem
+}
+
+func (c *checker) report(pos Position, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{
+		Pos:     pos,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) checkDriver() {
+	if err := c.conf.Driver.Validate(); err != nil {
+		c.report(Position{}, "invalid driver: %s", err)
+	}
+}
+
+func (c *checker) checkConsul() {
+	if err := c.conf.Consul.Validate(); err != nil {
+		c.report(c.positions.get("consul", "", 0), "%s", err)
+	}
+}
+
+func (c *checker) checkVault() {
+	if err := c.conf.Vault.Validate(); err != nil {
+		c.report(c.positions.get("vault", "", 0), "%s", err)
+	}
+}
+
+func (c *checker) checkServices() {
+	seen := make(map[string]int)
+	for _, s := range *c.conf.Services {
+		id := s.id()
+		n := seen[id]
+		seen[id]++
+		pos := c.positions.get("service", id, n)
+
+		if err := s.Validate(); err != nil {
+			c.report(pos, "invalid service %q: %s", id, err)
+			continue
+		}
+		if n > 0 {
+			c.report(pos, "duplicate service ID %q%s", id,
+				c.firstDefinition("service", id))
+		}
+	}
+}
+
+func (c *checker) checkProviders() {
+	seen := make(map[string]int)
+	for _, p := range *c.conf.TerraformProviders {
+		id := p.id()
+		n := seen[id]
+		seen[id]++
+		pos := c.positions.get("terraform_provider", id, n)
+
+		if err := p.Validate(); err != nil {
+			c.report(pos, "invalid terraform_provider: %s", err)
+			continue
+		}
+		if n > 0 {
+			c.report(pos, "duplicate terraform_provider %q%s", id,
+				c.firstDefinition("terraform_provider", id))
+		}
+	}
+}
+
+func (c *checker) checkTasks() {
+	if c.conf.Tasks.Len() == 0 {
+		c.report(Position{}, "missing tasks configuration")
+		return
+	}
+
+	seen := make(map[string]int)
+	for _, t := range *c.conf.Tasks {
+		name := StringVal(t.Name)
+		n := seen[name]
+		seen[name]++
+		pos := c.positions.get("task", name, n)
+
+		if err := t.Validate(); err != nil {
+			c.report(pos, "invalid task %q: %s", name, err)
+		} else if n > 0 {
+			c.report(pos, "duplicate task name %q%s", name,
+				c.firstDefinition("task", name))
+		}
+
+		for _, p := range t.Providers {
+			if err := c.conf.providerConfigured(p); err != nil {
+				c.report(pos, "task %q %s", name, err)
+			}
+		}
+
+		for _, vf := range t.VarFiles {
+			if _, err := os.Stat(vf); err != nil {
+				c.report(pos, "variable file for task %q: %s", name, err)
+			}
+		}
+
+		if tmpl := StringVal(t.TFVarsTemplate); tmpl != "" {
+			if _, err := os.Stat(tmpl); err != nil {
+				c.report(pos, "tfvars template for task %q: %s", name, err)
+			}
+		}
+
+		// Modules from local paths are resolved by Terraform relative to the
+		// working directory of the task. Other module sources are only
+		// available once installed by Terraform.
+		source := StringVal(t.Source)
+		if isLocalSource(source) && c.conf.Driver.Terraform != nil {
+			wd := filepath.Join(StringVal(c.conf.Driver.Terraform.WorkingDir), name)
+			if _, err := os.Stat(filepath.Join(wd, source)); err != nil {
+				c.report(pos, "module source for task %q: %s", name, err)
+			}
+		}
+	}
+}
+
+// firstDefinition describes the position of the first definition of a block,
+// if known.
+func (c *checker) firstDefinition(blockType, id string) string {
+	pos := c.positions.get(blockType, id, 0)
+	if !pos.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf(", first defined at %s", pos)
+}
+
+// isLocalSource reports whether the module source is a local path.
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
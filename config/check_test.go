@@ -0,0 +1,157 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-check-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	varFile := filepath.Join(dir, "vars.tfvars")
+	require.NoError(t, ioutil.WriteFile(varFile, []byte(`count = 1`), 0644))
+	workingDir := filepath.Join(dir, "sync-tasks")
+	require.NoError(t, os.MkdirAll(filepath.Join(workingDir, "modules", "local"), 0755))
+
+	driver := `
+driver "terraform" {
+  working_dir = "` + workingDir + `"
+}
+`
+
+	testCases := []struct {
+		name     string
+		config   string
+		problems []string
+	}{
+		{
+			"valid",
+			driver + `
+terraform_provider "aws" {
+  alias = "west"
+}
+
+task {
+  name = "task"
+  services = ["api"]
+  providers = ["aws.west"]
+  source = "../modules/local"
+  variable_files = ["` + varFile + `"]
+}
+`,
+			nil,
+		}, {
+			"all problems",
+			driver + `
+buffer_period {
+  min = "10s"
+  max = "5s"
+}
+
+service {
+  name = "api"
+}
+
+service {
+  name = "api"
+}
+
+task {
+  name = "task"
+  services = ["api"]
+  providers = ["aws.east"]
+  source = "./dne"
+  variable_files = ["` + filepath.Join(dir, "dne.tfvars") + `"]
+  tfvars_template = "` + filepath.Join(dir, "dne.tmpl") + `"
+}
+
+task {
+  name = "task"
+  services = ["api"]
+  source = "source"
+}
+
+task {
+  name = "1invalid"
+  services = ["api"]
+  source = "source"
+}
+`,
+			[]string{
+				"invalid buffer_period",
+				`config.hcl:15:1: duplicate service ID "api", first defined at ` +
+					filepath.Join(dir, "config.hcl") + `:11:1`,
+				`config.hcl:19:1: task "task" uses provider "aws.east", but there is no terraform_provider block for "aws" with alias "east"`,
+				`config.hcl:19:1: variable file for task "task"`,
+				`config.hcl:19:1: tfvars template for task "task"`,
+				`config.hcl:19:1: module source for task "task"`,
+				`config.hcl:28:1: duplicate task name "task"`,
+				`config.hcl:34:1: invalid task "1invalid"`,
+			},
+		}, {
+			"consul token file",
+			driver + `
+consul {
+  token_file = "` + filepath.Join(dir, "dne.token") + `"
+}
+
+task {
+  name = "task"
+  services = ["api"]
+  source = "source"
+}
+`,
+			[]string{
+				`config.hcl:6:1: consul.token_file: unable to read token`,
+			},
+		}, {
+			"vault auth",
+			driver + `
+vault {
+  address = "https://vault.example.com"
+  auth {
+    cert {}
+  }
+}
+
+task {
+  name = "task"
+  services = ["api"]
+  source = "source"
+}
+`,
+			[]string{
+				`config.hcl:6:1: vault.auth: cert: the client certificate and key of vault.tls are required`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config.hcl")
+			require.NoError(t, ioutil.WriteFile(path, []byte(tc.config), 0644))
+
+			problems := Check([]string{path})
+			require.Len(t, problems, len(tc.problems), "%v", problems)
+			for i, p := range problems {
+				assert.Contains(t, p.String(), tc.problems[i])
+			}
+		})
+	}
+
+	t.Run("decode error", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.hcl")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`task {`), 0644))
+
+		problems := Check([]string{path})
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0].String(), path)
+	})
+}
@@ -35,10 +35,15 @@ type Config struct {
 	Vault               *VaultConfig              `mapstructure:"vault"`
 	Driver              *DriverConfig             `mapstructure:"driver"`
 	Tasks               *TaskConfigs              `mapstructure:"task"`
+	TaskDefaults        *TaskConfig               `mapstructure:"task_defaults"`
 	Services            *ServiceConfigs           `mapstructure:"service"`
 	DeprecatedProviders *TerraformProviderConfigs `mapstructure:"provider"`
 	TerraformProviders  *TerraformProviderConfigs `mapstructure:"terraform_provider"`
 	BufferPeriod        *BufferPeriodConfig       `mapstructure:"buffer_period"`
+
+	// positions are the positions of the blocks within the configuration
+	// files, used to describe the blocks in validation errors.
+	positions blockPositions
 }
 
 // BuildConfig builds a new Config object from the default configuration and
@@ -62,6 +67,16 @@ func BuildConfig(paths []string) (*Config, error) {
 		return nil, fmt.Errorf("no configuration files found")
 	}
 
+	positions, err := loadBlockPositions(paths)
+	if err != nil {
+		return nil, err
+	}
+	config.positions = positions
+
+	if err := config.expandTasks(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
@@ -97,10 +112,12 @@ func (c *Config) Copy() *Config {
 		Vault:               c.Vault.Copy(),
 		Driver:              c.Driver.Copy(),
 		Tasks:               c.Tasks.Copy(),
+		TaskDefaults:        c.TaskDefaults.Copy(),
 		Services:            c.Services.Copy(),
 		DeprecatedProviders: c.DeprecatedProviders.Copy(),
 		TerraformProviders:  c.TerraformProviders.Copy(),
 		BufferPeriod:        c.BufferPeriod.Copy(),
+		positions:           c.positions.merge(nil),
 	}
 }
 
@@ -150,6 +167,10 @@ func (c *Config) Merge(o *Config) *Config {
 		r.Tasks = r.Tasks.Merge(o.Tasks)
 	}
 
+	if o.TaskDefaults != nil {
+		r.TaskDefaults = r.TaskDefaults.Merge(o.TaskDefaults)
+	}
+
 	if o.Services != nil {
 		r.Services = r.Services.Merge(o.Services)
 	}
@@ -166,6 +187,8 @@ func (c *Config) Merge(o *Config) *Config {
 		r.BufferPeriod = r.BufferPeriod.Merge(o.BufferPeriod)
 	}
 
+	r.positions = r.positions.merge(o.positions)
+
 	return r
 }
 
@@ -250,6 +273,18 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.Consul.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Vault.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.validateBlocks(); err != nil {
+		return err
+	}
+
 	if err := c.Tasks.Validate(); err != nil {
 		return err
 	}
@@ -262,8 +297,6 @@ func (c *Config) Validate() error {
 		return err
 	}
 
-	// TODO: validate providers listed in tasks exist
-
 	if err := c.BufferPeriod.Validate(); err != nil {
 		return err
 	}
@@ -289,6 +322,7 @@ func (c *Config) GoString() string {
 		"Vault:%s, "+
 		"Driver:%s, "+
 		"Tasks:%s, "+
+		"TaskDefaults:%s, "+
 		"Services:%s, "+
 		"TerraformProviders:%s, "+
 		"BufferPeriod:%s"+
@@ -300,12 +334,158 @@ func (c *Config) GoString() string {
 		c.Vault.GoString(),
 		c.Driver.GoString(),
 		c.Tasks.GoString(),
+		c.TaskDefaults.GoString(),
 		c.Services.GoString(),
 		c.TerraformProviders.GoString(),
 		c.BufferPeriod.GoString(),
 	)
 }
 
+// expandTasks merges the task defaults into each task and expands the tasks
+// with for_each into a task for each set of parameters. The task defaults are
+// removed once merged so that they are not merged again.
+func (c *Config) expandTasks() error {
+	defaults := c.TaskDefaults
+	c.TaskDefaults = nil
+	if c.Tasks == nil {
+		return nil
+	}
+
+	tasks := make(TaskConfigs, 0, c.Tasks.Len())
+	seen := make(map[string]int)
+	for _, t := range *c.Tasks {
+		name := StringVal(t.Name)
+		n := seen[name]
+		seen[name]++
+
+		t = mergeTaskDefaults(defaults, t)
+		expanded, err := t.expand()
+		if err != nil {
+			return fmt.Errorf("task %q: %s", name, err)
+		}
+
+		// Expanded tasks are defined by the position of the templated task
+		if len(t.ForEach) > 0 && c.positions != nil {
+			if pos := c.positions.get("task", name, n); pos.IsValid() {
+				for _, e := range expanded {
+					if StringVal(e.Name) != name {
+						c.positions.add("task", StringVal(e.Name), pos)
+					}
+				}
+			}
+		}
+		tasks = append(tasks, expanded...)
+	}
+	c.Tasks = &tasks
+	return nil
+}
+
+// validateBlocks validates the configuration across blocks. Task names,
+// service IDs, and provider configurations must be unique, and the providers
+// used by tasks must be configured. Errors describe the conflicting blocks by
+// their position in the configuration files when known.
+func (c *Config) validateBlocks() error {
+	var taskNames, serviceIDs, providerIDs []string
+	if c.Tasks != nil {
+		for _, t := range *c.Tasks {
+			taskNames = append(taskNames, StringVal(t.Name))
+		}
+	}
+	if c.Services != nil {
+		for _, s := range *c.Services {
+			serviceIDs = append(serviceIDs, s.id())
+		}
+	}
+	if c.TerraformProviders != nil {
+		for _, p := range *c.TerraformProviders {
+			providerIDs = append(providerIDs, p.id())
+		}
+	}
+
+	if err := c.validateUnique("task", "task name", taskNames); err != nil {
+		return err
+	}
+	if err := c.validateUnique("service", "service ID", serviceIDs); err != nil {
+		return err
+	}
+	err := c.validateUnique("terraform_provider", "provider configuration", providerIDs)
+	if err != nil {
+		return err
+	}
+
+	if c.Tasks == nil {
+		return nil
+	}
+	seen := make(map[string]int)
+	for i, t := range *c.Tasks {
+		name := StringVal(t.Name)
+		n := seen[name]
+		seen[name]++
+		for _, p := range t.Providers {
+			if err := c.providerConfigured(p); err != nil {
+				return fmt.Errorf("task %q (%s) %s", name,
+					c.positions.describe("task", name, n, i), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateUnique validates that the identifiers of the blocks of a type are
+// unique. The error for a duplicate identifier names both blocks.
+func (c *Config) validateUnique(blockType, kind string, ids []string) error {
+	first := make(map[string]int)
+	for i, id := range ids {
+		if id == "" {
+			continue
+		}
+
+		j, ok := first[id]
+		if !ok {
+			first[id] = i
+			continue
+		}
+
+		return fmt.Errorf("duplicate %s %q: defined by %s and %s", kind, id,
+			c.positions.describe(blockType, id, 0, j),
+			c.positions.describe(blockType, id, 1, i))
+	}
+	return nil
+}
+
+// providerConfigured checks that the provider used by a task is configured.
+// A provider referenced by name and alias requires a terraform_provider block
+// with the alias. A provider referenced by name only requires either a
+// terraform_provider block or a required_providers entry for providers that
+// do not need configuration.
+func (c *Config) providerConfigured(id string) error {
+	parts := strings.SplitN(id, ".", 2)
+	name := parts[0]
+
+	if c.TerraformProviders != nil {
+		for _, p := range *c.TerraformProviders {
+			pID := p.id()
+			if pID == id || (len(parts) == 1 && strings.Split(pID, ".")[0] == name) {
+				return nil
+			}
+		}
+	}
+
+	if len(parts) > 1 {
+		return fmt.Errorf("uses provider %q, but there is no terraform_provider "+
+			"block for %q with alias %q", id, name, parts[1])
+	}
+
+	if c.Driver != nil && c.Driver.Terraform != nil {
+		if _, ok := c.Driver.Terraform.RequiredProviders[name]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("uses provider %q, but there is no terraform_provider "+
+		"block or required_providers entry for it", id)
+}
+
 func (c *Config) validateDynamicConfigs() error {
 	// If dynamic provider configs contain Vault dependency, verify that Vault is
 	// configured.
@@ -356,6 +536,13 @@ func decodeConfig(content []byte, format string) (*Config, error) {
 		return nil, err
 	}
 
+	for k, v := range raw {
+		if raw[k], err = interpolateValue(v, k); err != nil {
+			log.Printf("[ERR] (config) failed to interpolate %s", format)
+			return nil, err
+		}
+	}
+
 	var config Config
 	var md mapstructure.Metadata
 	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
@@ -378,6 +565,44 @@ func decodeConfig(content []byte, format string) (*Config, error) {
 	return &config, nil
 }
 
+// interpolateValue replaces the ${env:NAME} and ${file:PATH} sequences within
+// the strings of a decoded configuration value. The path is the attribute
+// path of the value, used to describe errors.
+func interpolateValue(v interface{}, path string) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		s, err := hcltmpl.Interpolate(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %s", path, err)
+		}
+		return s, nil
+
+	case map[string]interface{}:
+		for k, elem := range v {
+			var err error
+			if v[k], err = interpolateValue(elem, path+"."+k); err != nil {
+				return nil, err
+			}
+		}
+
+	case []map[string]interface{}:
+		for _, elem := range v {
+			if _, err := interpolateValue(elem, path); err != nil {
+				return nil, err
+			}
+		}
+
+	case []interface{}:
+		for i, elem := range v {
+			var err error
+			if v[i], err = interpolateValue(elem, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
 // fromFile reads the configuration file at the given path and returns a new
 // Config struct with the data populated.
 func fromFile(path string) (*Config, error) {
@@ -392,10 +617,15 @@ func fromFile(path string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := checkAttributes(path, content); err != nil {
+		log.Printf("[ERR] (config) invalid attributes in file: %s\n", path)
+		return nil, err
+	}
+
 	config, err := decodeConfig(content, format)
 	if err != nil {
 		log.Printf("[ERR] (config) failed decoding content from file: %s\n", path)
-		return nil, err
+		return nil, fmt.Errorf("%s: %s", path, err)
 	}
 
 	return config, nil
@@ -404,53 +634,17 @@ func fromFile(path string) (*Config, error) {
 // fromPath iterates and merges all configuration files in a given directory,
 // returning the resulting config.
 func fromPath(path string) (*Config, error) {
-	// Ensure the given filepath exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		log.Printf("[ERR] (config) missing file/folder: %s\n", path)
-		return nil, err
-	}
-
-	// Check if a file was given or a path to a directory
-	stat, err := os.Stat(path)
+	files, err := configFilePaths(path)
 	if err != nil {
 		return nil, err
 	}
 
-	if stat.Mode().IsRegular() {
-		// Skip files when we can
-		if stat.Size() == 0 || !supportedFormat(fileFormat(path)) {
-			return nil, nil
-		}
-		return fromFile(path)
-	}
-
-	if !stat.Mode().IsDir() {
-		return nil, fmt.Errorf("unknown filetype %q: %s", stat.Mode().String(), path)
-	}
-
-	// Ensure the given filepath has at least one config file
-	files, err := ioutil.ReadDir(path)
-	if err != nil {
-		log.Printf("[ERR] (config) failed listing directory: %s\n", path)
-		return nil, err
-	}
-
 	// Create a blank config to merge off of
 	var c *Config
 
-	for _, fileInfo := range files {
-		// Skip subdirectories
-		if fileInfo.IsDir() {
-			continue
-		}
-
-		// Skip file based on extension before processing
-		if !supportedFormat(fileFormat(fileInfo.Name())) {
-			continue
-		}
-
+	for _, file := range files {
 		// Parse and merge the config
-		newConfig, err := fromFile(filepath.Join(path, fileInfo.Name()))
+		newConfig, err := fromFile(file)
 		if err != nil {
 			return nil, err
 		}
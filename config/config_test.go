@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"testing"
 	"time"
 
@@ -74,6 +75,7 @@ var (
 						"source":  "namespace/pName2",
 					},
 				},
+				TFVarsFormat: String("json"),
 			},
 		},
 		Services: &ServiceConfigs{
@@ -81,9 +83,13 @@ var (
 				Name:        String("serviceA"),
 				Description: String("descriptionA"),
 			}, {
-				Name:        String("serviceB"),
-				Namespace:   String("teamB"),
-				Description: String("descriptionB"),
+				Name:         String("serviceB"),
+				Namespace:    String("teamB"),
+				Datacenter:   String("dc2"),
+				Description:  String("descriptionB"),
+				Tags:         []string{"canary", "v2"},
+				NodeMeta:     map[string]string{"rack": "rack-1"},
+				HealthStatus: []string{"any"},
 			},
 		},
 		Tasks: &TaskConfigs{
@@ -93,6 +99,12 @@ var (
 				Services:    []string{"serviceA", "serviceB", "serviceC"},
 				Providers:   []string{"X"},
 				Source:      String("Y"),
+				Connect:     Bool(true),
+				Handlers: &HandlerConfigs{{
+					"X": map[string]interface{}{
+						"enabled": false,
+					},
+				}},
 			},
 		},
 		TerraformProviders: &TerraformProviderConfigs{{
@@ -164,6 +176,73 @@ func TestDecodeConfig(t *testing.T) {
 	}
 }
 
+func TestDecodeConfig_interpolation(t *testing.T) {
+	os.Setenv("CTS_CONFIG_TEST_TOKEN", "token")
+	defer os.Unsetenv("CTS_CONFIG_TEST_TOKEN")
+	os.Setenv("CTS_CONFIG_TEST_PORT", "8502")
+	defer os.Unsetenv("CTS_CONFIG_TEST_PORT")
+
+	testCases := []struct {
+		name    string
+		format  string
+		content string
+	}{
+		{
+			"hcl",
+			"hcl",
+			`port = "${env:CTS_CONFIG_TEST_PORT}"
+consul {
+  token = "${env:CTS_CONFIG_TEST_TOKEN}"
+}
+driver "terraform" {
+  backend "consul" {
+    path = "${env:CTS_CONFIG_TEST_TOKEN}/path"
+  }
+}
+task {
+  name = "task"
+  variable_files = ["${env:CTS_CONFIG_TEST_TOKEN}.tfvars"]
+}`,
+		}, {
+			"json",
+			"json",
+			`{
+  "port": "${env:CTS_CONFIG_TEST_PORT}",
+  "consul": {"token": "${env:CTS_CONFIG_TEST_TOKEN}"},
+  "driver": {"terraform": {"backend": {"consul": {
+    "path": "${env:CTS_CONFIG_TEST_TOKEN}/path"
+  }}}},
+  "task": [{
+    "name": "task",
+    "variable_files": ["${env:CTS_CONFIG_TEST_TOKEN}.tfvars"]
+  }]
+}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := decodeConfig([]byte(tc.content), tc.format)
+			require.NoError(t, err)
+
+			assert.Equal(t, 8502, *c.Port)
+			assert.Equal(t, "token", *c.Consul.Token)
+			assert.Equal(t, map[string]interface{}{
+				"consul": map[string]interface{}{"path": "token/path"},
+			}, c.Driver.Terraform.Backend)
+			assert.Equal(t, []string{"token.tfvars"}, (*c.Tasks)[0].VarFiles)
+		})
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		_, err := decodeConfig([]byte(`consul {
+  token = "${env:CTS_CONFIG_TEST_DNE}"
+}`), "hcl")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid value for "consul.token"`)
+	})
+}
+
 func TestFromPath(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -265,6 +344,7 @@ func TestConfig_Finalize(t *testing.T) {
 	expected.Syslog.Facility = String("LOCAL0")
 	expected.BufferPeriod.Enabled = Bool(true)
 	expected.Consul.KVNamespace = String("")
+	expected.Consul.TokenFile = String("")
 	expected.Consul.TLS.Cert = String("")
 	expected.Consul.Transport.MaxIdleConns = Int(100)
 	expected.Vault = DefaultVaultConfig()
@@ -277,15 +357,23 @@ func TestConfig_Finalize(t *testing.T) {
 	backend["ca_file"] = "ca_cert"
 	backend["key_file"] = "key"
 	(*expected.Tasks)[0].VarFiles = []string{}
+	(*expected.Tasks)[0].SensitiveVariables = []string{}
+	(*expected.Tasks)[0].TFVarsTemplate = String("")
 	(*expected.Tasks)[0].Version = String("")
 	(*expected.Tasks)[0].BufferPeriod = DefaultTaskBufferPeriodConfig()
 	(*expected.Services)[0].ID = String("serviceA")
 	(*expected.Services)[0].Namespace = String("")
+	(*expected.Services)[0].Partition = String("")
 	(*expected.Services)[0].Datacenter = String("")
 	(*expected.Services)[0].Tag = String("")
+	(*expected.Services)[0].Tags = []string{}
+	(*expected.Services)[0].NodeMeta = map[string]string{}
+	(*expected.Services)[0].HealthStatus = []string{}
+	(*expected.Services)[0].Filter = String("")
 	(*expected.Services)[1].ID = String("serviceB")
-	(*expected.Services)[1].Datacenter = String("")
+	(*expected.Services)[1].Partition = String("")
 	(*expected.Services)[1].Tag = String("")
+	(*expected.Services)[1].Filter = String("")
 
 	c := longConfig.Copy()
 	c.Finalize()
@@ -325,6 +413,108 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_validateBlocks(t *testing.T) {
+	task := func(name string, providers ...string) *TaskConfig {
+		return &TaskConfig{
+			Name:      String(name),
+			Services:  []string{"api"},
+			Source:    String("source"),
+			Providers: providers,
+		}
+	}
+	provider := func(name string, alias string) *TerraformProviderConfig {
+		conf := map[string]interface{}{}
+		if alias != "" {
+			conf["alias"] = alias
+		}
+		return &TerraformProviderConfig{name: conf}
+	}
+
+	testCases := []struct {
+		name   string
+		config *Config
+		err    string
+	}{
+		{
+			"valid",
+			&Config{
+				Tasks: &TaskConfigs{task("a", "aws.west"), task("b", "aws", "null")},
+				Services: &ServiceConfigs{
+					{Name: String("api")},
+					{Name: String("api"), ID: String("api-dc2")},
+				},
+				TerraformProviders: &TerraformProviderConfigs{
+					provider("aws", "east"),
+					provider("aws", "west"),
+				},
+				Driver: &DriverConfig{Terraform: &TerraformConfig{
+					RequiredProviders: map[string]interface{}{"null": map[string]interface{}{}},
+				}},
+			},
+			"",
+		}, {
+			"duplicate task",
+			&Config{Tasks: &TaskConfigs{task("a"), task("b"), task("a")}},
+			`duplicate task name "a": defined by task block #1 and task block #3`,
+		}, {
+			"duplicate service ID",
+			&Config{Services: &ServiceConfigs{
+				{Name: String("api"), ID: String("web")},
+				{Name: String("web")},
+			}},
+			`duplicate service ID "web": defined by service block #1 and service block #2`,
+		}, {
+			"duplicate provider",
+			&Config{TerraformProviders: &TerraformProviderConfigs{
+				provider("aws", "west"),
+				provider("aws", "west"),
+			}},
+			`duplicate provider configuration "aws.west"`,
+		}, {
+			"undefined provider alias",
+			&Config{
+				Tasks: &TaskConfigs{task("a", "aws.west")},
+				TerraformProviders: &TerraformProviderConfigs{
+					provider("aws", "east"),
+				},
+			},
+			`task "a" (task block #1) uses provider "aws.west", but there is ` +
+				`no terraform_provider block for "aws" with alias "west"`,
+		}, {
+			"undefined provider",
+			&Config{Tasks: &TaskConfigs{task("a", "aws")}},
+			`task "a" (task block #1) uses provider "aws", but there is no ` +
+				`terraform_provider block or required_providers entry for it`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.validateBlocks()
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+
+	t.Run("positions", func(t *testing.T) {
+		c := &Config{
+			Tasks: &TaskConfigs{task("a"), task("a")},
+			positions: blockPositions{"task.a": {
+				{Filename: "a.hcl", Line: 1, Column: 1},
+				{Filename: "b.hcl", Line: 10, Column: 1},
+			}},
+		}
+		err := c.validateBlocks()
+		require.Error(t, err)
+		assert.Equal(t, `duplicate task name "a": defined by a.hcl:1:1 and `+
+			`b.hcl:10:1`, err.Error())
+	})
+}
+
 func TestConfig_validateDynamicConfig(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -1,6 +1,10 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
 
 const (
 	// DefaultConsulAddress is the default address to connect with Consul
@@ -34,6 +38,13 @@ type ConsulConfig struct {
 	// Token is the token to communicate with Consul securely.
 	Token *string `mapstructure:"token"`
 
+	// TokenFile is the path of a file that contains the token. It takes
+	// precedence over the Token. The file is watched for changes, and the
+	// token is swapped for requests to Consul and for Terraform, which is
+	// given the token with the CONSUL_HTTP_TOKEN environment variable for
+	// the Consul backend.
+	TokenFile *string `mapstructure:"token_file"`
+
 	// Transport configures the low-level network connection details.
 	Transport *TransportConfig `mapstructure:"transport"`
 }
@@ -73,6 +84,8 @@ func (c *ConsulConfig) Copy() *ConsulConfig {
 
 	o.Token = StringCopy(c.Token)
 
+	o.TokenFile = StringCopy(c.TokenFile)
+
 	if c.Transport != nil {
 		o.Transport = c.Transport.Copy()
 	}
@@ -122,6 +135,10 @@ func (c *ConsulConfig) Merge(o *ConsulConfig) *ConsulConfig {
 		r.Token = StringCopy(o.Token)
 	}
 
+	if o.TokenFile != nil {
+		r.TokenFile = StringCopy(o.TokenFile)
+	}
+
 	if o.Transport != nil {
 		r.Transport = r.Transport.Merge(o.Transport)
 	}
@@ -159,7 +176,20 @@ func (c *ConsulConfig) Finalize() {
 	}
 	c.TLS.Finalize()
 
-	if c.Token == nil {
+	if c.TokenFile == nil {
+		c.TokenFile = String("")
+	}
+
+	// Order of precedence
+	// 1. `token_file` configuration value
+	// 2. `token` configuration value
+	// 3. `CONSUL_TOKEN` and `CONSUL_HTTP_TOKEN` environment variables
+	//
+	// An unreadable token file keeps the configured token and is reported by
+	// Validate.
+	if *c.TokenFile != "" {
+		c.Token = stringFromFile([]string{*c.TokenFile}, StringVal(c.Token))
+	} else if c.Token == nil {
 		c.Token = stringFromEnv([]string{
 			"CONSUL_TOKEN",
 			"CONSUL_HTTP_TOKEN",
@@ -172,6 +202,27 @@ func (c *ConsulConfig) Finalize() {
 	c.Transport.Finalize()
 }
 
+// Validate validates the values and nested values of the configuration struct.
+// The token file must contain a token, since the token of the file replaces
+// any other token.
+func (c *ConsulConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if tokenFile := StringVal(c.TokenFile); tokenFile != "" {
+		content, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return fmt.Errorf("consul.token_file: unable to read token: %s", err)
+		}
+		if strings.TrimSpace(string(content)) == "" {
+			return fmt.Errorf("consul.token_file: no token in %s", tokenFile)
+		}
+	}
+
+	return nil
+}
+
 // GoString defines the printable version of this struct.
 // Sensitive information is redacted.
 func (c *ConsulConfig) GoString() string {
@@ -186,6 +237,7 @@ func (c *ConsulConfig) GoString() string {
 		"KVPath:%s, "+
 		"TLS:%s, "+
 		"Token:%s, "+
+		"TokenFile:%s, "+
 		"Transport:%s"+
 		"}",
 		StringVal(c.Address),
@@ -194,6 +246,7 @@ func (c *ConsulConfig) GoString() string {
 		StringVal(c.KVPath),
 		c.TLS.GoString(),
 		sensitiveGoString(c.Token),
+		StringVal(c.TokenFile),
 		c.Transport.GoString(),
 	)
 }
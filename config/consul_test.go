@@ -2,10 +2,14 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConsulConfig_Copy(t *testing.T) {
@@ -32,6 +36,7 @@ func TestConsulConfig_Copy(t *testing.T) {
 				KVNamespace: String("org"),
 				TLS:         &TLSConfig{Enabled: Bool(true)},
 				Token:       String("abcd1234"),
+				TokenFile:   String("/path/to/token"),
 			},
 		},
 	}
@@ -173,6 +178,18 @@ func TestConsulConfig_Merge(t *testing.T) {
 			&ConsulConfig{Token: String("same")},
 			&ConsulConfig{Token: String("same")},
 		},
+		{
+			"token_file_overrides",
+			&ConsulConfig{TokenFile: String("/same")},
+			&ConsulConfig{TokenFile: String("/different")},
+			&ConsulConfig{TokenFile: String("/different")},
+		},
+		{
+			"token_file_empty_one",
+			&ConsulConfig{TokenFile: String("/same")},
+			&ConsulConfig{},
+			&ConsulConfig{TokenFile: String("/same")},
+		},
 		{
 			"transport_overrides",
 			&ConsulConfig{Transport: &TransportConfig{DialKeepAlive: TimeDuration(10 * time.Second)}},
@@ -236,7 +253,8 @@ func TestConsulConfig_Finalize(t *testing.T) {
 					ServerName: String(""),
 					Verify:     Bool(true),
 				},
-				Token: String(""),
+				Token:     String(""),
+				TokenFile: String(""),
 				Transport: &TransportConfig{
 					DialKeepAlive:       TimeDuration(DefaultDialKeepAlive),
 					DialTimeout:         TimeDuration(DefaultDialTimeout),
@@ -257,3 +275,104 @@ func TestConsulConfig_Finalize(t *testing.T) {
 		})
 	}
 }
+
+func TestConsulConfig_Finalize_tokenFile(t *testing.T) {
+	t.Parallel()
+
+	f, err := ioutil.TempFile("", "consul-token")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("file-token\n")
+	require.NoError(t, err)
+	f.Close()
+
+	cases := []struct {
+		name      string
+		tokenFile string
+		token     *string
+		expected  string
+	}{
+		{
+			"token_file",
+			f.Name(),
+			nil,
+			"file-token",
+		},
+		{
+			"token_file_precedence",
+			f.Name(),
+			String("token"),
+			"file-token",
+		},
+		{
+			"missing_token_file",
+			"/path/to/missing",
+			String("token"),
+			"token",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ConsulConfig{TokenFile: String(tc.tokenFile), Token: tc.token}
+			c.Finalize()
+			assert.Equal(t, tc.expected, *c.Token)
+		})
+	}
+}
+
+func TestConsulConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "consul-token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("token\n"), 0600))
+	emptyFile := filepath.Join(dir, "empty")
+	require.NoError(t, ioutil.WriteFile(emptyFile, []byte(" \n"), 0600))
+
+	cases := []struct {
+		name    string
+		i       *ConsulConfig
+		isValid bool
+	}{
+		{
+			"nil",
+			nil,
+			true,
+		},
+		{
+			"no token_file",
+			&ConsulConfig{TokenFile: String("")},
+			true,
+		},
+		{
+			"token_file",
+			&ConsulConfig{TokenFile: String(tokenFile)},
+			true,
+		},
+		{
+			"missing token_file",
+			&ConsulConfig{TokenFile: String(filepath.Join(dir, "missing"))},
+			false,
+		},
+		{
+			"empty token_file",
+			&ConsulConfig{TokenFile: String(emptyFile)},
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.i.Validate()
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -158,6 +158,7 @@ func TestDriverConfig_Finalize(t *testing.T) {
 					WorkingDir:        String(path.Join(wd, DefaultTFWorkingDir)),
 					Backend:           map[string]interface{}{},
 					RequiredProviders: map[string]interface{}{},
+					TFVarsFormat:      String(DefaultTFVarsFormat),
 				},
 			},
 		},
@@ -177,6 +178,7 @@ func TestDriverConfig_Finalize(t *testing.T) {
 					WorkingDir:        String(path.Join(wd, DefaultTFWorkingDir)),
 					Backend:           map[string]interface{}{},
 					RequiredProviders: map[string]interface{}{},
+					TFVarsFormat:      String(DefaultTFVarsFormat),
 				},
 			},
 		},
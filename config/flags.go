@@ -2,6 +2,7 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"strings"
 )
 
@@ -23,3 +24,31 @@ func (s *FlagAppendSliceValue) Set(value string) error {
 	*s = append(*s, value)
 	return nil
 }
+
+var _ flag.Value = (*FlagFormatValue)(nil)
+
+// FlagFormatValue implements the flag.Value interface for the format of the
+// output of a flag that can also be used as a boolean flag. The flag without
+// a value selects the HCL format.
+type FlagFormatValue string
+
+func (f *FlagFormatValue) String() string {
+	return string(*f)
+}
+
+func (f *FlagFormatValue) Set(value string) error {
+	switch value {
+	case "true":
+		*f = "hcl"
+	case "hcl", "json":
+		*f = FlagFormatValue(value)
+	default:
+		return fmt.Errorf("unsupported format %q, must be hcl or json", value)
+	}
+	return nil
+}
+
+// IsBoolFlag allows the flag to be set without a value.
+func (f *FlagFormatValue) IsBoolFlag() bool {
+	return true
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// HandlerStagePreApply is the stage for handlers that execute before the
+	// driver applies changes for a task. An error returned by a pre-apply
+	// handler vetoes the apply.
+	HandlerStagePreApply = "pre-apply"
+
+	// HandlerStagePostApply is the stage for handlers that execute after the
+	// driver successfully applies changes for a task. This is the default stage.
+	HandlerStagePostApply = "post-apply"
+
+	// handlerEnabledKey is the reserved attribute of a handler block to toggle
+	// the handler on or off.
+	handlerEnabledKey = "enabled"
+
+	// handlerStageKey is the reserved attribute of a handler block to set the
+	// stage the handler executes in.
+	handlerStageKey = "stage"
+)
+
+// preApplyHandlerTypes are the types of handlers that support the pre-apply
+// stage. The panos handler commits the changes of an apply, so it can only
+// execute post-apply.
+var preApplyHandlerTypes = map[string]bool{
+	"fake-sync": true,
+}
+
+// HandlerConfigs is an array of configuration for each handler of a task.
+type HandlerConfigs []*HandlerConfig
+
+// HandlerConfig is a map representing the configuration for a single handler
+// where the key is the type of handler and value is the configuration.
+//
+//	handler "fake-sync" {
+//	  stage = "pre-apply"
+//	  name = "change-freeze"
+//	}
+type HandlerConfig map[string]interface{}
+
+// DefaultHandlerConfigs returns a configuration that is populated with the
+// default values.
+func DefaultHandlerConfigs() *HandlerConfigs {
+	return &HandlerConfigs{}
+}
+
+// Len is a helper method to get the length of the underlying config list
+func (c *HandlerConfigs) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	return len(*c)
+}
+
+// Copy returns a deep copy of this configuration.
+func (c *HandlerConfigs) Copy() *HandlerConfigs {
+	if c == nil {
+		return nil
+	}
+
+	o := make(HandlerConfigs, c.Len())
+	for i, h := range *c {
+		copy := make(HandlerConfig)
+		for k, v := range *h {
+			copy[k] = v
+		}
+		o[i] = &copy
+	}
+	return &o
+}
+
+// Merge combines all values in this configuration with the values in the other
+// configuration, with values in the other configuration taking precedence.
+// Maps and slices are merged, most other values are overwritten. Complex
+// structs define their own merge functionality.
+func (c *HandlerConfigs) Merge(o *HandlerConfigs) *HandlerConfigs {
+	if c == nil {
+		if o == nil {
+			return nil
+		}
+		return o.Copy()
+	}
+
+	if o == nil {
+		return c.Copy()
+	}
+
+	r := c.Copy()
+
+	*r = append(*r, *o.Copy()...)
+
+	return r
+}
+
+// Finalize ensures the configuration has no nil pointers and sets default
+// values.
+func (c *HandlerConfigs) Finalize() {
+	if c == nil {
+		return
+	}
+
+	for _, h := range *c {
+		h.Finalize()
+	}
+}
+
+// Validate validates the values and nested values of the configuration struct
+func (c *HandlerConfigs) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	for _, h := range *c {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GoString defines the printable version of this struct. Handler configuration
+// is redacted since handlers may contain credentials of the infrastructure
+// they act on.
+func (c *HandlerConfigs) GoString() string {
+	if c == nil {
+		return "(*HandlerConfigs)(nil)"
+	}
+
+	s := make([]string, len(*c))
+	for i, h := range *c {
+		s[i] = fmt.Sprintf("&map[%s:%s]", h.Type(), redactMessage)
+	}
+
+	return "{" + strings.Join(s, ", ") + "}"
+}
+
+// Finalize ensures the configuration has no nil pointers and sets default
+// values.
+func (c *HandlerConfig) Finalize() {
+	if c == nil {
+		return
+	}
+
+	for k, v := range *c {
+		if v == nil {
+			(*c)[k] = make(map[string]interface{})
+		}
+	}
+}
+
+// Validate validates the values and nested values of the configuration struct.
+func (c *HandlerConfig) Validate() error {
+	if c == nil {
+		return fmt.Errorf("invalid handler configuration")
+	}
+
+	numLabels := len(*c)
+	if numLabels == 0 {
+		return fmt.Errorf("missing handler type for the handler block")
+	} else if numLabels > 1 {
+		labels := make([]string, 0, numLabels)
+		for l := range *c {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		return fmt.Errorf("unexpected handler block labels: %s", strings.Join(labels, ","))
+	}
+
+	for name, v := range *c {
+		if v == nil {
+			continue
+		}
+		conf, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid configuration for handler %q", name)
+		}
+		if enabled, ok := conf[handlerEnabledKey]; ok {
+			if _, ok := enabled.(bool); !ok {
+				return fmt.Errorf("expected bool for 'enabled' of handler %q: %v",
+					name, enabled)
+			}
+		}
+		if stage, ok := conf[handlerStageKey]; ok {
+			switch stage {
+			case HandlerStagePreApply, HandlerStagePostApply:
+			default:
+				return fmt.Errorf("unsupported stage for handler %q: %v. "+
+					"Expected %q or %q", name, stage, HandlerStagePreApply,
+					HandlerStagePostApply)
+			}
+			if stage == HandlerStagePreApply && !preApplyHandlerTypes[name] {
+				return fmt.Errorf("handler %q does not support the %q stage",
+					name, HandlerStagePreApply)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Type returns the type of the handler, which is the label of the handler
+// block.
+func (c *HandlerConfig) Type() string {
+	if c == nil {
+		return ""
+	}
+
+	for k := range *c {
+		return k
+	}
+	return ""
+}
+
+// Enabled returns whether the handler is enabled. Handlers are enabled unless
+// explicitly disabled with `enabled = false`.
+func (c *HandlerConfig) Enabled() bool {
+	if c == nil {
+		return false
+	}
+
+	enabled, ok := c.rawConfig()[handlerEnabledKey].(bool)
+	return !ok || enabled
+}
+
+// Stage returns the stage the handler executes in. Handlers execute
+// post-apply unless configured otherwise.
+func (c *HandlerConfig) Stage() string {
+	if stage, ok := c.rawConfig()[handlerStageKey].(string); ok {
+		return stage
+	}
+	return HandlerStagePostApply
+}
+
+// Config returns the configuration of the handler to pass to the handler
+// constructor. The reserved attributes of the handler block are excluded.
+func (c *HandlerConfig) Config() map[string]interface{} {
+	raw := c.rawConfig()
+	conf := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		if k == handlerEnabledKey || k == handlerStageKey {
+			continue
+		}
+		conf[k] = v
+	}
+	return conf
+}
+
+// rawConfig returns the configuration map of the handler block
+func (c *HandlerConfig) rawConfig() map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+
+	for _, v := range *c {
+		conf, _ := v.(map[string]interface{})
+		return conf
+	}
+	return nil
+}
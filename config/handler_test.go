@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerConfigs_Copy(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		a    *HandlerConfigs
+	}{
+		{
+			"nil",
+			nil,
+		},
+		{
+			"empty",
+			&HandlerConfigs{},
+		},
+		{
+			"same_enabled",
+			&HandlerConfigs{
+				{
+					"panos": map[string]interface{}{
+						"enabled":  true,
+						"username": "admin",
+					},
+				},
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			r := tc.a.Copy()
+			assert.Equal(t, tc.a, r)
+		})
+	}
+}
+
+func TestHandlerConfigs_Merge(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		a    *HandlerConfigs
+		b    *HandlerConfigs
+		r    *HandlerConfigs
+	}{
+		{
+			"nil_a",
+			nil,
+			&HandlerConfigs{},
+			&HandlerConfigs{},
+		},
+		{
+			"nil_b",
+			&HandlerConfigs{},
+			nil,
+			&HandlerConfigs{},
+		},
+		{
+			"nil_both",
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"appends",
+			&HandlerConfigs{{
+				"panos": map[string]interface{}{"username": "admin"},
+			}},
+			&HandlerConfigs{{
+				"fake-sync": map[string]interface{}{"name": "fake"},
+			}},
+			&HandlerConfigs{{
+				"panos": map[string]interface{}{"username": "admin"},
+			}, {
+				"fake-sync": map[string]interface{}{"name": "fake"},
+			}},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			r := tc.a.Merge(tc.b)
+			assert.Equal(t, tc.r, r)
+		})
+	}
+}
+
+func TestHandlerConfigs_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		i       *HandlerConfigs
+		isValid bool
+	}{
+		{
+			"nil",
+			nil,
+			true,
+		},
+		{
+			"empty",
+			&HandlerConfigs{},
+			true,
+		},
+		{
+			"valid",
+			&HandlerConfigs{{
+				"panos": map[string]interface{}{"enabled": false},
+			}},
+			true,
+		},
+		{
+			"missing type",
+			&HandlerConfigs{{}},
+			false,
+		},
+		{
+			"multiple types",
+			&HandlerConfigs{{
+				"panos":     map[string]interface{}{},
+				"fake-sync": map[string]interface{}{},
+			}},
+			false,
+		},
+		{
+			"invalid config",
+			&HandlerConfigs{{
+				"panos": "config",
+			}},
+			false,
+		},
+		{
+			"valid stage",
+			&HandlerConfigs{{
+				"fake-sync": map[string]interface{}{"stage": "pre-apply"},
+			}},
+			true,
+		},
+		{
+			"post-apply only handler",
+			&HandlerConfigs{{
+				"panos": map[string]interface{}{"stage": "pre-apply"},
+			}},
+			false,
+		},
+		{
+			"invalid stage",
+			&HandlerConfigs{{
+				"panos": map[string]interface{}{"stage": "during-apply"},
+			}},
+			false,
+		},
+		{
+			"invalid enabled",
+			&HandlerConfigs{{
+				"panos": map[string]interface{}{"enabled": "false"},
+			}},
+			false,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			err := tc.i.Validate()
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestHandlerConfig_Config(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		i       *HandlerConfig
+		enabled bool
+		stage   string
+		config  map[string]interface{}
+	}{
+		{
+			"default",
+			&HandlerConfig{
+				"panos": map[string]interface{}{"username": "admin"},
+			},
+			true,
+			HandlerStagePostApply,
+			map[string]interface{}{"username": "admin"},
+		},
+		{
+			"disabled",
+			&HandlerConfig{
+				"panos": map[string]interface{}{
+					"enabled":  false,
+					"username": "admin",
+				},
+			},
+			false,
+			HandlerStagePostApply,
+			map[string]interface{}{"username": "admin"},
+		},
+		{
+			"pre-apply",
+			&HandlerConfig{
+				"panos": map[string]interface{}{
+					"stage":    "pre-apply",
+					"username": "admin",
+				},
+			},
+			true,
+			HandlerStagePreApply,
+			map[string]interface{}{"username": "admin"},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			assert.Equal(t, "panos", tc.i.Type())
+			assert.Equal(t, tc.enabled, tc.i.Enabled())
+			assert.Equal(t, tc.stage, tc.i.Stage())
+			assert.Equal(t, tc.config, tc.i.Config())
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sensitiveAttributes are the paths of the attributes with sensitive values
+// that are redacted from the effective configuration.
+var sensitiveAttributes = map[string]bool{
+	"consul.token":         true,
+	"consul.auth.password": true,
+	"vault.token":          true,
+
+	"vault.auth.approle.secret_id": true,
+}
+
+// sensitiveBackendKeys are substrings of the names of Terraform backend
+// arguments that have sensitive values, like access_key and sas_token.
+var sensitiveBackendKeys = []string{
+	"token", "password", "secret", "key", "credentials", "conn_str",
+}
+
+// labeledBlocks are the blocks that are labeled by the keys of their values
+// in HCL, like driver "terraform" {}.
+var labeledBlocks = map[string]bool{
+	"driver":             true,
+	"backend":            true,
+	"handler":            true,
+	"provider":           true,
+	"terraform_provider": true,
+}
+
+// EffectiveConfig is the configuration after merging and finalizing, in a
+// form for printing. Sensitive values are redacted.
+type EffectiveConfig struct {
+	// Config is the configuration keyed by attribute name.
+	Config map[string]interface{} `json:"config"`
+
+	// Sources are the positions of the definitions of each block within the
+	// configuration files, keyed by the block type and identifier of the
+	// block, like task.web. Blocks merged from multiple definitions have a
+	// position for each definition.
+	Sources map[string][]string `json:"sources"`
+}
+
+// Effective returns the effective configuration to print. The configuration
+// is expected to be finalized. Provider and handler arguments are redacted
+// since they will have varying arguments containing sensitive information,
+// along with tokens, passwords, and sensitive backend arguments.
+func (c *Config) Effective() *EffectiveConfig {
+	e := &EffectiveConfig{
+		Config:  make(map[string]interface{}),
+		Sources: make(map[string][]string),
+	}
+	if c == nil {
+		return e
+	}
+
+	if v, ok := effectiveValue(reflect.ValueOf(*c), ""); ok {
+		e.Config = v.(map[string]interface{})
+	}
+	for key, positions := range c.positions {
+		sources := make([]string, len(positions))
+		for i, pos := range positions {
+			sources[i] = pos.String()
+		}
+		e.Sources[key] = sources
+	}
+	return e
+}
+
+// JSON returns the effective configuration encoded as JSON.
+func (e *EffectiveConfig) JSON() ([]byte, error) {
+	return json.MarshalIndent(e, "", "  ")
+}
+
+// HCL returns the effective configuration formatted as HCL. Each top-level
+// block is annotated with a comment of its sources.
+func (e *EffectiveConfig) HCL() []byte {
+	w := &hclWriter{sources: e.Sources}
+	w.writeBody(e.Config, 0)
+	return w.buf.Bytes()
+}
+
+// effectiveValue converts a configuration value to a generic value of maps,
+// lists, and literals for printing. Nil values are omitted and the sensitive
+// values at the path are redacted.
+func effectiveValue(v reflect.Value, path string) (interface{}, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == durationType {
+		return v.Interface().(time.Duration).String(), true
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := fieldName(f)
+			if val, ok := effectiveValue(v.Field(i), joinPath(path, name)); ok {
+				m[name] = val
+			}
+		}
+		return m, true
+
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			if val, ok := effectiveValue(iter.Value(), joinPath(path, k)); ok {
+				m[k] = val
+			}
+		}
+		return m, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, false
+		}
+
+		// Elements of lists share the path of the list
+		l := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if val, ok := effectiveValue(v.Index(i), path); ok {
+				l = append(l, val)
+			}
+		}
+		return l, true
+	}
+
+	if isSensitivePath(path) && !v.IsZero() {
+		return redactMessage, true
+	}
+	return v.Interface(), true
+}
+
+// isSensitivePath reports whether the value of the attribute at the path is
+// sensitive.
+func isSensitivePath(path string) bool {
+	if sensitiveAttributes[path] {
+		return true
+	}
+
+	parts := strings.Split(path, ".")
+	switch {
+	case parts[0] == "terraform_provider" || parts[0] == "provider":
+		// terraform_provider.<name>.<argument>
+		return len(parts) > 3 || (len(parts) == 3 && parts[2] != "alias")
+
+	case len(parts) > 2 && parts[0] == "task" && parts[1] == "handler":
+		// task.handler.<type>.<argument>
+		return len(parts) > 4 || (len(parts) == 4 &&
+			parts[3] != handlerEnabledKey && parts[3] != handlerStageKey)
+
+	case len(parts) > 4 && strings.HasPrefix(path, "driver.terraform.backend."):
+		// driver.terraform.backend.<type>.<argument>
+		// Arguments for the paths of files, like key_file, are not sensitive
+		name := strings.ToLower(parts[len(parts)-1])
+		if strings.HasSuffix(name, "_file") {
+			return false
+		}
+		for _, key := range sensitiveBackendKeys {
+			if strings.Contains(name, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// hclWriter formats generic configuration values as HCL.
+type hclWriter struct {
+	buf     bytes.Buffer
+	sources map[string][]string
+}
+
+// writeBody writes the attributes of an object followed by its blocks. The
+// depth is the nesting depth of the object, where zero is the root object.
+func (w *hclWriter) writeBody(m map[string]interface{}, depth int) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var blocks []string
+	for _, k := range keys {
+		if isBlockValue(m[k]) {
+			blocks = append(blocks, k)
+			continue
+		}
+		w.indent(depth)
+		fmt.Fprintf(&w.buf, "%s = %s\n", hclKey(k), hclValue(m[k]))
+	}
+
+	for _, k := range blocks {
+		var bodies []map[string]interface{}
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			bodies = []map[string]interface{}{v}
+		case []interface{}:
+			for _, elem := range v {
+				bodies = append(bodies, elem.(map[string]interface{}))
+			}
+		}
+
+		for _, body := range bodies {
+			if !labeledBlocks[k] {
+				w.writeBlock(k, "", body, depth)
+				continue
+			}
+			labels := make([]string, 0, len(body))
+			for label := range body {
+				labels = append(labels, label)
+			}
+			sort.Strings(labels)
+			for _, label := range labels {
+				inner, ok := body[label].(map[string]interface{})
+				if !ok {
+					inner = map[string]interface{}{}
+				}
+				w.writeBlock(k, label, inner, depth)
+			}
+		}
+	}
+}
+
+// writeBlock writes a block with an optional label. Top-level blocks are
+// preceded by a blank line and a comment of the sources of the block.
+func (w *hclWriter) writeBlock(name, label string, body map[string]interface{}, depth int) {
+	if depth == 0 {
+		if w.buf.Len() > 0 {
+			w.buf.WriteString("\n")
+		}
+		if sources := w.sources[sourceKey(name, label, body)]; len(sources) > 0 {
+			fmt.Fprintf(&w.buf, "# %s\n", strings.Join(sources, ", "))
+		}
+	}
+
+	w.indent(depth)
+	w.buf.WriteString(hclKey(name))
+	if label != "" {
+		fmt.Fprintf(&w.buf, " %q", label)
+	}
+	if len(body) == 0 {
+		w.buf.WriteString(" {}\n")
+		return
+	}
+	w.buf.WriteString(" {\n")
+	w.writeBody(body, depth+1)
+	w.indent(depth)
+	w.buf.WriteString("}\n")
+}
+
+func (w *hclWriter) indent(depth int) {
+	w.buf.WriteString(strings.Repeat("  ", depth))
+}
+
+// sourceKey returns the key of the sources of a top-level block, matching
+// the keys of the block positions.
+func sourceKey(name, label string, body map[string]interface{}) string {
+	str := func(k string) string {
+		s, _ := body[k].(string)
+		return s
+	}
+
+	switch name {
+	case "task":
+		return blockKey(name, str("name"))
+	case "service":
+		if id := str("id"); id != "" {
+			return blockKey(name, id)
+		}
+		return blockKey(name, str("name"))
+	case "terraform_provider", "provider":
+		id := label
+		if alias := str("alias"); alias != "" {
+			id = fmt.Sprintf("%s.%s", label, alias)
+		}
+		return blockKey("terraform_provider", id)
+	}
+	return name
+}
+
+// isBlockValue reports whether a value is written as a block, which are
+// objects and lists of objects.
+func isBlockValue(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		if len(v) == 0 {
+			return false
+		}
+		for _, elem := range v {
+			if _, ok := elem.(map[string]interface{}); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hclKey returns the key of an attribute or block, quoted if the key is not a
+// valid identifier.
+func hclKey(k string) string {
+	for i, r := range k {
+		valid := r == '_' || r == '-' || (r >= 'a' && r <= 'z') ||
+			(r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')
+		if !valid {
+			return fmt.Sprintf("%q", k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
+
+// hclValue formats a literal or a list of literals as HCL.
+func hclValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []interface{}:
+		elems := make([]string, len(v))
+		for i, elem := range v {
+			elems[i] = hclValue(elem)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case map[string]interface{}:
+		// Objects within lists of mixed values
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]string, len(keys))
+		for i, k := range keys {
+			attrs[i] = fmt.Sprintf("%s = %s", hclKey(k), hclValue(v[k]))
+		}
+		return "{ " + strings.Join(attrs, ", ") + " }"
+	}
+	return fmt.Sprint(v)
+}
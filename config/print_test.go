@@ -0,0 +1,139 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Effective(t *testing.T) {
+	conf, err := BuildConfig([]string{"testdata/long.hcl", "testdata/long.json"})
+	require.NoError(t, err)
+	conf.TerraformProviders = &TerraformProviderConfigs{
+		&TerraformProviderConfig{"aws": map[string]interface{}{
+			"alias":       "west",
+			"region":      "us-west-1",
+			"assume_role": map[string]interface{}{"role_arn": "arn"},
+		}},
+	}
+	conf.Finalize()
+
+	e := conf.Effective()
+
+	consul := e.Config["consul"].(map[string]interface{})
+	assert.Equal(t, redactMessage, consul["token"])
+	assert.Equal(t, "consul-example.com", consul["address"])
+	auth := consul["auth"].(map[string]interface{})
+	assert.Equal(t, redactMessage, auth["password"])
+	assert.Equal(t, "username", auth["username"])
+	transport := consul["transport"].(map[string]interface{})
+	assert.Equal(t, "5s", transport["dial_keep_alive"])
+
+	vault := e.Config["vault"].(map[string]interface{})
+	assert.Equal(t, "", vault["token"], "empty values are not redacted")
+
+	providers := e.Config["terraform_provider"].([]interface{})
+	require.Len(t, providers, 1)
+	assert.Equal(t, map[string]interface{}{
+		"aws": map[string]interface{}{
+			"alias":       "west",
+			"region":      redactMessage,
+			"assume_role": map[string]interface{}{"role_arn": redactMessage},
+		},
+	}, providers[0])
+
+	tasks := e.Config["task"].([]interface{})
+	require.Len(t, tasks, 2)
+	task := tasks[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{"serviceA", "serviceB", "serviceC"}, task["services"])
+
+	assert.Equal(t, []string{
+		"testdata/long.hcl:14:1",
+		"testdata/long.json:13:14",
+	}, e.Sources["consul"])
+	assert.Equal(t, []string{
+		"testdata/long.hcl:79:1",
+		"testdata/long.json:84:13",
+	}, e.Sources["task.task"])
+
+	t.Run("nil", func(t *testing.T) {
+		var c *Config
+		e := c.Effective()
+		assert.Empty(t, e.Config)
+		assert.Empty(t, e.Sources)
+	})
+}
+
+func TestEffectiveConfig_HCL(t *testing.T) {
+	conf, err := BuildConfig([]string{"testdata/long.hcl"})
+	require.NoError(t, err)
+	conf.Finalize()
+
+	hcl := string(conf.Effective().HCL())
+	assert.Contains(t, hcl, "# testdata/long.hcl:14:1\nconsul {\n")
+	assert.Contains(t, hcl, "# testdata/long.hcl:41:1\ndriver \"terraform\" {\n")
+	assert.Contains(t, hcl, "  backend \"consul\" {\n")
+	assert.Contains(t, hcl, "# testdata/long.hcl:79:1\ntask {\n")
+	assert.Contains(t, hcl, "  handler \"X\" {\n    enabled = false\n  }\n")
+	assert.Contains(t, hcl, "  token = \"(redacted)\"\n")
+	assert.Contains(t, hcl, "terraform_provider \"X\" {}\n")
+
+	// The printed configuration can be loaded again
+	printed, err := decodeConfig([]byte(hcl), "hcl")
+	require.NoError(t, err)
+	printed.Finalize()
+	assert.Equal(t, conf.Tasks, printed.Tasks)
+	assert.Equal(t, conf.Services, printed.Services)
+	assert.Equal(t, conf.Driver.Terraform, printed.Driver.Terraform)
+	assert.Equal(t, conf.BufferPeriod, printed.BufferPeriod)
+}
+
+func TestEffectiveConfig_JSON(t *testing.T) {
+	conf, err := BuildConfig([]string{"testdata/long.hcl"})
+	require.NoError(t, err)
+	conf.Finalize()
+
+	b, err := conf.Effective().JSON()
+	require.NoError(t, err)
+	json := string(b)
+	assert.True(t, strings.HasPrefix(json, "{\n  \"config\": {"))
+	assert.Contains(t, json, `"token": "(redacted)"`)
+	assert.Contains(t, json, `"task.task": [`)
+}
+
+func TestIsSensitivePath(t *testing.T) {
+	testCases := []struct {
+		path      string
+		sensitive bool
+	}{
+		{"consul.token", true},
+		{"consul.address", false},
+		{"consul.auth.password", true},
+		{"vault.token", true},
+		{"vault.auth.approle.secret_id", true},
+		{"vault.auth.approle.role_id", false},
+		{"terraform_provider.aws", false},
+		{"terraform_provider.aws.alias", false},
+		{"terraform_provider.aws.secret_key", true},
+		{"terraform_provider.aws.assume_role.role_arn", true},
+		{"provider.aws.region", true},
+		{"task.handler.panos", false},
+		{"task.handler.panos.enabled", false},
+		{"task.handler.panos.stage", false},
+		{"task.handler.panos.password", true},
+		{"task.name", false},
+		{"driver.terraform.backend.consul.address", false},
+		{"driver.terraform.backend.consul.access_token", true},
+		{"driver.terraform.backend.s3.secret_key", true},
+		{"driver.terraform.backend.consul.key_file", false},
+		{"driver.terraform.working_dir", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.sensitive, isSensitivePath(tc.path))
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/consul-terraform-sync/logging"
+)
+
+const (
+	// schemaVersion is the JSON Schema draft the configuration schema follows.
+	schemaVersion = "http://json-schema.org/draft-07/schema#"
+
+	// durationPattern matches the durations parsed by time.ParseDuration.
+	durationPattern = `^-?([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$|^0$`
+
+	// interpolationPattern matches the values with ${env:NAME} or
+	// ${file:PATH} sequences, which are interpolated before the value is
+	// decoded to its type.
+	interpolationPattern = `\$\{(env|file):[^}]*\}`
+)
+
+// schemaDescriptions are the descriptions of the configuration attributes,
+// keyed by the name of the struct type and the attribute name. Every
+// attribute of the configuration requires a description.
+var schemaDescriptions = map[string]string{
+	"Config.log_level":          "The log level to filter logs, which is one of TRACE, DEBUG, INFO, WARN, or ERR.",
+	"Config.client_type":        "The client to run tasks with. Only used when developing Sync.",
+	"Config.port":               "The port the API server is served on.",
+	"Config.syslog":             "The configuration to log to syslog.",
+	"Config.consul":             "The configuration to connect to Consul.",
+	"Config.vault":              "The configuration to connect to Vault for secrets in templates.",
+	"Config.driver":             "The driver to execute tasks with.",
+	"Config.task":               "A task to execute the module of when its services change. The block may be specified multiple times.",
+	"Config.task_defaults":      "The default values merged into every task, with the values of each task taking precedence.",
+	"Config.service":            "The configuration of a Consul service used by tasks. The block may be specified multiple times.",
+	"Config.provider":           "Deprecated: use terraform_provider.",
+	"Config.terraform_provider": "The configuration of a Terraform provider labeled by the provider name. The arguments may load values from env, Consul KV, and Vault using template syntax.",
+	"Config.buffer_period":      "The default buffer period of tasks to wait for changes to settle before executing.",
+
+	"SyslogConfig.enabled":  "Whether to log to syslog.",
+	"SyslogConfig.facility": "The syslog facility to log to.",
+	"SyslogConfig.name":     "The name of the application in syslog.",
+
+	"ConsulConfig.address":      "The address of the Consul agent, as an IP or FQDN with an optional port.",
+	"ConsulConfig.auth":         "The HTTP basic authentication for Consul.",
+	"ConsulConfig.kv_namespace": "The namespace to use for Consul KV queries and operations (Consul Enterprise only).",
+	"ConsulConfig.kv_path":      "The path in Consul KV to store runtime data.",
+	"ConsulConfig.tls":          "The TLS configuration to connect to Consul over HTTPS.",
+	"ConsulConfig.token":        "The ACL token to communicate with Consul.",
+	"ConsulConfig.token_file":   "The path of a file that contains the ACL token, which takes precedence over the token. The file is watched and the token is reloaded when it changes.",
+	"ConsulConfig.transport":    "The low-level network connection details to Consul.",
+
+	"AuthConfig.enabled":  "Whether to use HTTP basic authentication.",
+	"AuthConfig.username": "The username for HTTP basic authentication.",
+	"AuthConfig.password": "The password for HTTP basic authentication.",
+
+	"TLSConfig.ca_cert":     "The path to the CA certificate to verify the server certificate.",
+	"TLSConfig.ca_path":     "The path to a directory of CA certificates to verify the server certificate.",
+	"TLSConfig.cert":        "The path to the client certificate.",
+	"TLSConfig.enabled":     "Whether to use TLS.",
+	"TLSConfig.key":         "The path to the private key of the client certificate.",
+	"TLSConfig.server_name": "The server name to use for SNI and to verify the server certificate.",
+	"TLSConfig.verify":      "Whether to verify the server certificate.",
+
+	"TransportConfig.dial_keep_alive":         "The interval between keep-alive probes of connections.",
+	"TransportConfig.dial_timeout":            "The time to wait to establish a connection.",
+	"TransportConfig.disable_keep_alives":     "Whether to disable keep-alives, which significantly decreases performance.",
+	"TransportConfig.idle_conn_timeout":       "The time before idle connections are closed.",
+	"TransportConfig.max_idle_conns":          "The maximum number of total idle connections.",
+	"TransportConfig.max_idle_conns_per_host": "The maximum number of idle connections per host.",
+	"TransportConfig.tls_handshake_timeout":   "The time to wait to complete the TLS handshake.",
+
+	"VaultConfig.address":                "The URI of the Vault server.",
+	"VaultConfig.auth":                   "The auth method to log in to Vault with instead of a static token. The token is renewed, and Sync logs in again when the token expires.",
+	"VaultConfig.enabled":                "Whether the Vault integration is enabled.",
+	"VaultConfig.namespace":              "The Vault namespace to read secrets from (Vault Enterprise only).",
+	"VaultConfig.renew_token":            "Whether to renew the Vault token from the login of the auth method, otherwise Sync logs in again when the token expires.",
+	"VaultConfig.tls":                    "The TLS configuration to connect to Vault.",
+	"VaultConfig.token":                  "The Vault token, which may be a wrapped token.",
+	"VaultConfig.vault_agent_token_file": "The path to the token file of a Vault Agent. The token is not renewed by Sync.",
+	"VaultConfig.transport":              "The low-level network connection details to Vault.",
+	"VaultConfig.unwrap_token":           "Whether to unwrap the Vault token as a wrapped token.",
+
+	"VaultAuthConfig.approle":    "Log in with the AppRole auth method.",
+	"VaultAuthConfig.cert":       "Log in with the TLS certificate auth method, using the client certificate of the Vault TLS configuration.",
+	"VaultAuthConfig.kubernetes": "Log in with the Kubernetes auth method.",
+
+	"VaultAppRoleAuthConfig.mount_path": "The path the AppRole auth method is mounted at.",
+	"VaultAppRoleAuthConfig.role_id":    "The role ID to log in with.",
+	"VaultAppRoleAuthConfig.secret_id":  "The secret ID to log in with, if the role requires one.",
+
+	"VaultCertAuthConfig.mount_path": "The path the TLS certificate auth method is mounted at.",
+	"VaultCertAuthConfig.name":       "The name of the certificate role to log in with. Defaults to trying all roles.",
+
+	"VaultKubernetesAuthConfig.mount_path": "The path the Kubernetes auth method is mounted at.",
+	"VaultKubernetesAuthConfig.role":       "The name of the role to log in with.",
+	"VaultKubernetesAuthConfig.token_path": "The path of the service account token, read for each login.",
+
+	"DriverConfig.terraform": "The Terraform driver to execute tasks with Terraform.",
+
+	"TerraformConfig.version":            "The version of Terraform to install and run. Defaults to the latest compatible version.",
+	"TerraformConfig.log":                "Whether to log the output of Terraform.",
+	"TerraformConfig.persist_log":        "Whether to persist the Terraform logs to a file in the working directory of each task.",
+	"TerraformConfig.path":               "The path to install Terraform to or to find an existing Terraform binary in.",
+	"TerraformConfig.working_dir":        "The directory to create the working directories of tasks in.",
+	"TerraformConfig.backend":            "The Terraform backend to store state in, labeled by the backend type. Defaults to Consul KV.",
+	"TerraformConfig.required_providers": "The source and version constraints of the Terraform providers used by tasks.",
+	"TerraformConfig.tfvars_format":      "The format of the input variables file generated for each task.",
+
+	"TaskConfig.description":         "The human readable text to describe the task.",
+	"TaskConfig.name":                "The unique name of the task.",
+	"TaskConfig.providers":           "The names of the providers the task uses, with an optional alias as name.alias.",
+	"TaskConfig.services":            "The service IDs or logical service names the task executes on.",
+	"TaskConfig.source":              "The module source of the task, either a local path or a remote module.",
+	"TaskConfig.variable_files":      "The paths to files of input variables for the module.",
+	"TaskConfig.sensitive_variables": "The names of the variables from the variable files or tfvars template that are sensitive. Requires Terraform 0.14.",
+	"TaskConfig.tfvars_template":     "The path to a template that assigns additional input variables for the module.",
+	"TaskConfig.version":             "The version of the module. Defaults to the latest version.",
+	"TaskConfig.connect":             "Whether to monitor the Connect-capable instances of the services of the task.",
+	"TaskConfig.buffer_period":       "The buffer period of the task to wait for changes to settle before executing.",
+	"TaskConfig.handler":             "The handlers to execute before or after the task applies changes, labeled by the handler type.",
+	"TaskConfig.for_each":            "The parameters to stamp out a task for each set of parameters, replacing ${each:NAME} within the task values.",
+
+	"ServiceConfig.datacenter":    "The datacenter of the service.",
+	"ServiceConfig.description":   "The human readable text to describe the service.",
+	"ServiceConfig.id":            "The ID of the service for tasks to refer to. Defaults to the name.",
+	"ServiceConfig.name":          "The Consul logical name of the service.",
+	"ServiceConfig.namespace":     "The namespace of the service (Consul Enterprise only).",
+	"ServiceConfig.partition":     "The admin partition of the service (Consul Enterprise only).",
+	"ServiceConfig.tag":           "The tag to filter service instances by.",
+	"ServiceConfig.tags":          "The tags to filter service instances by. Instances must have all of the tags.",
+	"ServiceConfig.node_meta":     "The node metadata to filter service instances by. Nodes must have all of the metadata.",
+	"ServiceConfig.health_status": "The health statuses to filter service instances by. Defaults to passing.",
+	"ServiceConfig.filter":        "The Consul filter expression to filter service instances by.",
+
+	"BufferPeriodConfig.enabled": "Whether the buffer period is enabled.",
+	"BufferPeriodConfig.min":     "The minimum time to wait after a change before executing.",
+	"BufferPeriodConfig.max":     "The maximum time to wait for changes to settle before executing.",
+}
+
+// schemaEnums are the allowed values of string attributes, or of the
+// elements of lists of strings, keyed like schemaDescriptions.
+var schemaEnums = map[string][]string{
+	"Config.log_level":              logLevels(),
+	"TerraformConfig.tfvars_format": {"hcl", "json"},
+	"ServiceConfig.health_status":   healthStatuses,
+}
+
+// schemaOverrides are the schemas of attributes with arbitrary keys that are
+// validated by their keys, keyed like schemaDescriptions.
+var schemaOverrides = map[string]func() map[string]interface{}{
+	"TerraformConfig.backend": func() map[string]interface{} {
+		return map[string]interface{}{
+			"type":                 "object",
+			"propertyNames":        map[string]interface{}{"enum": supportedBackends},
+			"additionalProperties": map[string]interface{}{"type": "object"},
+		}
+	},
+	"TaskConfig.handler": func() map[string]interface{} {
+		handler := map[string]interface{}{
+			"type": "object",
+			"additionalProperties": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					handlerEnabledKey: interpolated(map[string]interface{}{
+						"type": "boolean",
+					}),
+					handlerStageKey: interpolated(map[string]interface{}{
+						"type": "string",
+						"enum": []string{HandlerStagePreApply, HandlerStagePostApply},
+					}),
+				},
+			},
+			"minProperties": 1,
+			"maxProperties": 1,
+		}
+		return oneOrMany(handler)
+	},
+}
+
+// Schema returns the JSON Schema of the configuration in the JSON format. The
+// schema is generated from the mapstructure tags of the configuration structs.
+func Schema() map[string]interface{} {
+	s := objectSchema(reflect.TypeOf(Config{}))
+	s["$schema"] = schemaVersion
+	s["title"] = "Consul-Terraform-Sync configuration"
+	return s
+}
+
+// objectSchema returns the schema of a configuration struct. Unknown
+// attributes are not allowed.
+func objectSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := fieldName(f)
+		key := t.Name() + "." + name
+
+		var s map[string]interface{}
+		if override, ok := schemaOverrides[key]; ok {
+			s = override()
+		} else {
+			s = typeSchema(f.Type, schemaEnums[key])
+		}
+		if description, ok := schemaDescriptions[key]; ok {
+			s["description"] = description
+		}
+		properties[name] = s
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema returns the schema of a type. The enum is applied to strings and
+// to the elements of lists of strings.
+func typeSchema(t reflect.Type, enum []string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return interpolated(map[string]interface{}{
+			"type":    "string",
+			"pattern": durationPattern,
+		})
+
+	case t.Kind() == reflect.Struct:
+		return objectSchema(t)
+
+	case t.Kind() == reflect.Slice:
+		elem := t.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		items := typeSchema(elem, enum)
+
+		// Blocks may be specified as an object or a list of objects
+		if elem.Kind() == reflect.Struct || elem.Kind() == reflect.Map {
+			return oneOrMany(items)
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		}
+
+	case t.Kind() == reflect.Map:
+		s := map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			s["additionalProperties"] = typeSchema(t.Elem(), nil)
+		}
+		return s
+
+	case t.Kind() == reflect.String:
+		s := map[string]interface{}{"type": "string"}
+		if len(enum) > 0 {
+			s["enum"] = enum
+			return interpolated(s)
+		}
+		return s
+
+	case t.Kind() == reflect.Bool:
+		return interpolated(map[string]interface{}{"type": "boolean"})
+
+	case t.Kind() == reflect.Int:
+		return interpolated(map[string]interface{}{"type": "integer"})
+	}
+
+	return map[string]interface{}{}
+}
+
+// interpolated returns a schema that also allows strings with interpolation
+// sequences, which are decoded after they are interpolated.
+func interpolated(s map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			s,
+			map[string]interface{}{
+				"type":    "string",
+				"pattern": interpolationPattern,
+			},
+		},
+	}
+}
+
+// oneOrMany returns a schema that allows an object or a list of objects.
+func oneOrMany(s map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			s,
+			map[string]interface{}{
+				"type":  "array",
+				"items": s,
+			},
+		},
+	}
+}
+
+// logLevels returns the supported log levels in upper and lower case, since
+// log levels are case insensitive.
+func logLevels() []string {
+	levels := make([]string, 0, 2*len(logging.Levels))
+	for _, l := range logging.Levels {
+		levels = append(levels, string(l))
+	}
+	for _, l := range logging.Levels {
+		levels = append(levels, strings.ToLower(string(l)))
+	}
+	return levels
+}
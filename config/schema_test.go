@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSchema_coverage fails when an attribute is added to the configuration
+// without a description for the schema, or when the schema describes an
+// attribute that no longer exists.
+func TestSchema_coverage(t *testing.T) {
+	attributes := make(map[string]bool)
+	var walk func(t reflect.Type)
+	walk = func(typ reflect.Type) {
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice ||
+			typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || typ == durationType {
+			return
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			key := typ.Name() + "." + fieldName(f)
+			if attributes[key] {
+				continue
+			}
+			attributes[key] = true
+			walk(f.Type)
+		}
+	}
+	walk(reflect.TypeOf(Config{}))
+
+	for key := range attributes {
+		assert.NotEmpty(t, schemaDescriptions[key],
+			"missing schema description for attribute %s", key)
+	}
+
+	for key := range schemaDescriptions {
+		assert.True(t, attributes[key], "schema describes unknown attribute %s", key)
+	}
+	for key := range schemaEnums {
+		assert.True(t, attributes[key], "schema enum for unknown attribute %s", key)
+	}
+	for key := range schemaOverrides {
+		assert.True(t, attributes[key], "schema override for unknown attribute %s", key)
+	}
+
+	// Every attribute is a property of the schema of its block
+	schema := Schema()
+	var check func(typ reflect.Type, s map[string]interface{})
+	check = func(typ reflect.Type, s map[string]interface{}) {
+		properties := s["properties"].(map[string]interface{})
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := fieldName(f)
+			prop, ok := properties[name].(map[string]interface{})
+			if !assert.True(t, ok, "missing schema property %s.%s", typ.Name(), name) {
+				continue
+			}
+
+			nested := f.Type
+			for nested.Kind() == reflect.Ptr {
+				nested = nested.Elem()
+			}
+			if nested.Kind() == reflect.Struct && nested != durationType {
+				check(nested, prop)
+			}
+		}
+	}
+	check(reflect.TypeOf(Config{}), schema)
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	assert.Equal(t, schemaVersion, schema["$schema"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	b, err := json.Marshal(schema)
+	require.NoError(t, err)
+	s := string(b)
+
+	properties := schema["properties"].(map[string]interface{})
+	logLevel := properties["log_level"].(map[string]interface{})
+	assert.Contains(t, logLevel["description"], "log level")
+	assert.Contains(t, s, `"enum":["TRACE","DEBUG","INFO","WARN","ERR"`)
+
+	task := properties["task"].(map[string]interface{})
+	variants := task["anyOf"].([]interface{})
+	require.Len(t, variants, 2)
+	taskObj := variants[0].(map[string]interface{})
+	taskProps := taskObj["properties"].(map[string]interface{})
+	assert.Contains(t, taskProps, "for_each")
+	assert.Contains(t, taskProps, "handler")
+	assert.Equal(t, false, taskObj["additionalProperties"])
+
+	driver := properties["driver"].(map[string]interface{})
+	tf := driver["properties"].(map[string]interface{})["terraform"].(map[string]interface{})
+	backend := tf["properties"].(map[string]interface{})["backend"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"enum": supportedBackends}, backend["propertyNames"])
+
+	bp := properties["buffer_period"].(map[string]interface{})
+	min := bp["properties"].(map[string]interface{})["min"].(map[string]interface{})
+	duration := min["anyOf"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, durationPattern, duration["pattern"])
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hashicorp/go-bexpr"
 )
 
 // ServiceConfig defines the explicit configuration for Sync to monitor
@@ -10,7 +12,7 @@ import (
 // services.
 type ServiceConfig struct {
 	// Datacenter is the datacenter the service is deployed in.
-	Datacenter *string `mapstricture:"datacenter"`
+	Datacenter *string `mapstructure:"datacenter"`
 
 	// Description is the human readable text to describe the service.
 	Description *string `mapstructure:"description"`
@@ -27,10 +29,37 @@ type ServiceConfig struct {
 	// default to the `default` namespace.
 	Namespace *string `mapstructure:"namespace"`
 
+	// Partition is the admin partition of the service (Consul Enterprise
+	// only). If not provided, the partition will be inferred from the Sync ACL
+	// token, or default to the `default` partition.
+	Partition *string `mapstructure:"partition"`
+
 	// Tag is used to filter nodes based on the tag for the service.
 	Tag *string `mapstructure:"tag"`
+
+	// Tags is used to filter service instances that have all of the tags. This
+	// is an alternative to Tag to filter by multiple tags.
+	Tags []string `mapstructure:"tags"`
+
+	// NodeMeta is used to filter service instances by nodes with all of the
+	// node metadata key/value pairs.
+	NodeMeta map[string]string `mapstructure:"node_meta"`
+
+	// HealthStatus is used to filter service instances by their aggregated
+	// health status. Supported values are "passing", "warning", "critical",
+	// "maintenance", and "any". Only passing instances are included by
+	// default.
+	HealthStatus []string `mapstructure:"health_status"`
+
+	// Filter is a Consul filter expression to filter service instances, such
+	// as `Service.Meta.version == "v2"`. The filter is evaluated by Consul
+	// against the service entries of the health API.
+	Filter *string `mapstructure:"filter"`
 }
 
+// healthStatuses are the supported values of ServiceConfig.HealthStatus
+var healthStatuses = []string{"any", "passing", "warning", "critical", "maintenance"}
+
 // ServiceConfigs is a collection of ServiceConfig
 type ServiceConfigs []*ServiceConfig
 
@@ -46,7 +75,26 @@ func (c *ServiceConfig) Copy() *ServiceConfig {
 	o.ID = StringCopy(c.ID)
 	o.Name = StringCopy(c.Name)
 	o.Namespace = StringCopy(c.Namespace)
+	o.Partition = StringCopy(c.Partition)
 	o.Tag = StringCopy(c.Tag)
+
+	for _, t := range c.Tags {
+		o.Tags = append(o.Tags, t)
+	}
+
+	if c.NodeMeta != nil {
+		o.NodeMeta = make(map[string]string, len(c.NodeMeta))
+		for k, v := range c.NodeMeta {
+			o.NodeMeta[k] = v
+		}
+	}
+
+	for _, h := range c.HealthStatus {
+		o.HealthStatus = append(o.HealthStatus, h)
+	}
+
+	o.Filter = StringCopy(c.Filter)
+
 	return &o
 }
 
@@ -88,10 +136,31 @@ func (c *ServiceConfig) Merge(o *ServiceConfig) *ServiceConfig {
 		r.Namespace = StringCopy(o.Namespace)
 	}
 
+	if o.Partition != nil {
+		r.Partition = StringCopy(o.Partition)
+	}
+
 	if o.Tag != nil {
 		r.Tag = StringCopy(o.Tag)
 	}
 
+	r.Tags = appendUnique(r.Tags, o.Tags...)
+
+	if o.NodeMeta != nil {
+		if r.NodeMeta == nil {
+			r.NodeMeta = make(map[string]string, len(o.NodeMeta))
+		}
+		for k, v := range o.NodeMeta {
+			r.NodeMeta[k] = v
+		}
+	}
+
+	r.HealthStatus = appendUnique(r.HealthStatus, o.HealthStatus...)
+
+	if o.Filter != nil {
+		r.Filter = StringCopy(o.Filter)
+	}
+
 	return r
 }
 
@@ -121,9 +190,29 @@ func (c *ServiceConfig) Finalize() {
 		c.Namespace = String("")
 	}
 
+	if c.Partition == nil {
+		c.Partition = String("")
+	}
+
 	if c.Tag == nil {
 		c.Tag = String("")
 	}
+
+	if c.Tags == nil {
+		c.Tags = []string{}
+	}
+
+	if c.NodeMeta == nil {
+		c.NodeMeta = make(map[string]string)
+	}
+
+	if c.HealthStatus == nil {
+		c.HealthStatus = []string{}
+	}
+
+	if c.Filter == nil {
+		c.Filter = String("")
+	}
 }
 
 // Validate validates the values and nested values of the configuration struct
@@ -136,9 +225,73 @@ func (c *ServiceConfig) Validate() error {
 		return fmt.Errorf("logical name for the Consul service is required")
 	}
 
+	if c.Tag != nil && *c.Tag != "" && len(c.Tags) > 0 {
+		return fmt.Errorf("only one of tag or tags can be configured for "+
+			"service %q", *c.Name)
+	}
+
+	for _, t := range c.Tags {
+		if t == "" {
+			return fmt.Errorf("empty tag in tags for service %q", *c.Name)
+		}
+	}
+
+	for _, h := range c.HealthStatus {
+		if !validHealthStatus(h) {
+			return fmt.Errorf("unsupported health_status %q for service %q. "+
+				"Expected one of: %s", h, *c.Name, strings.Join(healthStatuses, ", "))
+		}
+	}
+
+	if c.Filter != nil && *c.Filter != "" {
+		if _, err := bexpr.CreateEvaluator(*c.Filter, nil); err != nil {
+			return fmt.Errorf("invalid filter for service %q: %s", *c.Name, err)
+		}
+	}
+
 	return nil
 }
 
+// id returns the unique identifier of the service configuration, which
+// defaults to the service name when the ID is not set.
+func (c *ServiceConfig) id() string {
+	if c == nil {
+		return ""
+	}
+	if c.ID != nil && *c.ID != "" {
+		return *c.ID
+	}
+	return StringVal(c.Name)
+}
+
+// appendUnique appends the values that are not already in the slice
+func appendUnique(s []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, e := range s {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+// validHealthStatus returns whether the health status is supported to filter
+// service instances
+func validHealthStatus(status string) bool {
+	for _, h := range healthStatuses {
+		if status == h {
+			return true
+		}
+	}
+	return false
+}
+
 // GoString defines the printable version of this struct.
 // Sensitive information is redacted.
 func (c *ServiceConfig) GoString() string {
@@ -149,14 +302,24 @@ func (c *ServiceConfig) GoString() string {
 	return fmt.Sprintf("&ServiceConfig{"+
 		"Name:%s, "+
 		"Namespace:%s, "+
+		"Partition:%s, "+
 		"Datacenter:%s, "+
 		"Tag:%s, "+
+		"Tags:%s, "+
+		"NodeMeta:%s, "+
+		"HealthStatus:%s, "+
+		"Filter:%s, "+
 		"Description:%s"+
 		"}",
 		StringVal(c.Name),
 		StringVal(c.Namespace),
+		StringVal(c.Partition),
 		StringVal(c.Datacenter),
 		StringVal(c.Tag),
+		c.Tags,
+		c.NodeMeta,
+		c.HealthStatus,
+		StringVal(c.Filter),
 		StringVal(c.Description),
 	)
 }
@@ -236,10 +399,7 @@ func (c *ServiceConfigs) Validate() error {
 			return err
 		}
 
-		id := *s.Name
-		if s.ID != nil {
-			id = *s.ID
-		}
+		id := s.id()
 		if ids[id] {
 			return fmt.Errorf("unique service IDs are required: %s", id)
 		}
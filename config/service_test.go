@@ -25,9 +25,14 @@ func TestServiceConfig_Copy(t *testing.T) {
 		{
 			"same_enabled",
 			&ServiceConfig{
-				Description: String("description"),
-				Name:        String("name"),
-				Namespace:   String("namespace"),
+				Description:  String("description"),
+				Name:         String("name"),
+				Namespace:    String("namespace"),
+				Partition:    String("partition"),
+				Tags:         []string{"a", "b"},
+				NodeMeta:     map[string]string{"rack": "rack-1"},
+				HealthStatus: []string{"passing", "warning"},
+				Filter:       String(`Service.Meta.version == "v2"`),
 			},
 		},
 	}
@@ -145,6 +150,84 @@ func TestServiceConfig_Merge(t *testing.T) {
 			&ServiceConfig{Namespace: String("namespace")},
 			&ServiceConfig{Namespace: String("namespace")},
 		},
+		{
+			"partition_overrides",
+			&ServiceConfig{Partition: String("partition")},
+			&ServiceConfig{Partition: String("")},
+			&ServiceConfig{Partition: String("")},
+		},
+		{
+			"partition_empty_one",
+			&ServiceConfig{Partition: String("partition")},
+			&ServiceConfig{},
+			&ServiceConfig{Partition: String("partition")},
+		},
+		{
+			"partition_empty_two",
+			&ServiceConfig{},
+			&ServiceConfig{Partition: String("partition")},
+			&ServiceConfig{Partition: String("partition")},
+		},
+		{
+			"tags_merges",
+			&ServiceConfig{Tags: []string{"a"}},
+			&ServiceConfig{Tags: []string{"b"}},
+			&ServiceConfig{Tags: []string{"a", "b"}},
+		},
+		{
+			"tags_deduplicates",
+			&ServiceConfig{Tags: []string{"a", "b"}},
+			&ServiceConfig{Tags: []string{"b", "c"}},
+			&ServiceConfig{Tags: []string{"a", "b", "c"}},
+		},
+		{
+			"tags_empty_one",
+			&ServiceConfig{Tags: []string{"a"}},
+			&ServiceConfig{},
+			&ServiceConfig{Tags: []string{"a"}},
+		},
+		{
+			"node_meta_merges",
+			&ServiceConfig{NodeMeta: map[string]string{"a": "1", "b": "2"}},
+			&ServiceConfig{NodeMeta: map[string]string{"b": "3", "c": "4"}},
+			&ServiceConfig{NodeMeta: map[string]string{"a": "1", "b": "3", "c": "4"}},
+		},
+		{
+			"health_status_merges",
+			&ServiceConfig{HealthStatus: []string{"passing"}},
+			&ServiceConfig{HealthStatus: []string{"warning"}},
+			&ServiceConfig{HealthStatus: []string{"passing", "warning"}},
+		},
+		{
+			"health_status_deduplicates",
+			&ServiceConfig{HealthStatus: []string{"passing", "warning"}},
+			&ServiceConfig{HealthStatus: []string{"passing"}},
+			&ServiceConfig{HealthStatus: []string{"passing", "warning"}},
+		},
+		{
+			"filter_overrides",
+			&ServiceConfig{Filter: String("Service.Port == 80")},
+			&ServiceConfig{Filter: String("")},
+			&ServiceConfig{Filter: String("")},
+		},
+		{
+			"filter_empty_one",
+			&ServiceConfig{Filter: String("Service.Port == 80")},
+			&ServiceConfig{},
+			&ServiceConfig{Filter: String("Service.Port == 80")},
+		},
+		{
+			"filter_empty_two",
+			&ServiceConfig{},
+			&ServiceConfig{Filter: String("Service.Port == 80")},
+			&ServiceConfig{Filter: String("Service.Port == 80")},
+		},
+		{
+			"node_meta_empty_two",
+			&ServiceConfig{},
+			&ServiceConfig{NodeMeta: map[string]string{"a": "1"}},
+			&ServiceConfig{NodeMeta: map[string]string{"a": "1"}},
+		},
 	}
 
 	for i, tc := range cases {
@@ -167,12 +250,17 @@ func TestServiceConfig_Finalize(t *testing.T) {
 			"empty",
 			&ServiceConfig{},
 			&ServiceConfig{
-				Datacenter:  String(""),
-				Description: String(""),
-				ID:          String(""),
-				Name:        String(""),
-				Namespace:   String(""),
-				Tag:         String(""),
+				Datacenter:   String(""),
+				Description:  String(""),
+				ID:           String(""),
+				Name:         String(""),
+				Namespace:    String(""),
+				Partition:    String(""),
+				Tag:          String(""),
+				Tags:         []string{},
+				NodeMeta:     map[string]string{},
+				HealthStatus: []string{},
+				Filter:       String(""),
 			},
 		},
 		{
@@ -181,12 +269,17 @@ func TestServiceConfig_Finalize(t *testing.T) {
 				Name: String("service"),
 			},
 			&ServiceConfig{
-				Datacenter:  String(""),
-				Description: String(""),
-				ID:          String("service"),
-				Name:        String("service"),
-				Namespace:   String(""),
-				Tag:         String(""),
+				Datacenter:   String(""),
+				Description:  String(""),
+				ID:           String("service"),
+				Name:         String("service"),
+				Namespace:    String(""),
+				Partition:    String(""),
+				Tag:          String(""),
+				Tags:         []string{},
+				NodeMeta:     map[string]string{},
+				HealthStatus: []string{},
+				Filter:       String(""),
 			},
 		},
 	}
@@ -229,6 +322,64 @@ func TestServiceConfig_Validate(t *testing.T) {
 			&ServiceConfig{Description: String("description")},
 			false,
 		},
+		{
+			"tags",
+			&ServiceConfig{
+				Name:     String("service"),
+				Tags:     []string{"canary", "v2"},
+				NodeMeta: map[string]string{"rack": "rack-1"},
+			},
+			true,
+		},
+		{
+			"tag and tags",
+			&ServiceConfig{
+				Name: String("service"),
+				Tag:  String("canary"),
+				Tags: []string{"v2"},
+			},
+			false,
+		},
+		{
+			"health status",
+			&ServiceConfig{
+				Name:         String("service"),
+				HealthStatus: []string{"passing", "critical"},
+			},
+			true,
+		},
+		{
+			"unsupported health status",
+			&ServiceConfig{
+				Name:         String("service"),
+				HealthStatus: []string{"healthy"},
+			},
+			false,
+		},
+		{
+			"filter",
+			&ServiceConfig{
+				Name:   String("service"),
+				Filter: String(`Service.Meta.version == "v2" and "primary" in Service.Tags`),
+			},
+			true,
+		},
+		{
+			"invalid filter",
+			&ServiceConfig{
+				Name:   String("service"),
+				Filter: String("Service.Meta.version =="),
+			},
+			false,
+		},
+		{
+			"empty tag in tags",
+			&ServiceConfig{
+				Name: String("service"),
+				Tags: []string{"canary", ""},
+			},
+			false,
+		},
 	}
 
 	for i, tc := range cases {
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+// Position is the location of a configuration block within a configuration
+// file.
+type Position struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+// IsValid reports whether the position is known.
+func (p Position) IsValid() bool {
+	return p.Filename != ""
+}
+
+// String returns the position in the format file:line:column. The line and
+// column are omitted when unknown.
+func (p Position) String() string {
+	if p.Line <= 0 {
+		return p.Filename
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// blockPositions records the positions of the configuration blocks within the
+// configuration files. Blocks are keyed by the block type and the identifier
+// of the block. A block identifier that is defined multiple times has a
+// position for each definition, in the order the blocks are merged.
+type blockPositions map[string][]Position
+
+// blockKey returns the key of a block, which is the block type followed by the
+// identifier of the block. Blocks without identifiers, like consul, are keyed
+// by the block type.
+func blockKey(blockType, id string) string {
+	if id == "" {
+		return blockType
+	}
+	return blockType + "." + id
+}
+
+// add records the position of the next definition of a block.
+func (p blockPositions) add(blockType, id string, pos Position) {
+	key := blockKey(blockType, id)
+	p[key] = append(p[key], pos)
+}
+
+// get returns the position of the nth definition of a block, starting from
+// zero. An invalid position is returned if the position is unknown.
+func (p blockPositions) get(blockType, id string, n int) Position {
+	positions := p[blockKey(blockType, id)]
+	if n < 0 || n >= len(positions) {
+		return Position{}
+	}
+	return positions[n]
+}
+
+// describe returns the position of the nth definition of a block as a string.
+// When the position is unknown, the block is described by its index within
+// the blocks of its type.
+func (p blockPositions) describe(blockType, id string, n, index int) string {
+	if pos := p.get(blockType, id, n); pos.IsValid() {
+		return pos.String()
+	}
+	return fmt.Sprintf("%s block #%d", blockType, index+1)
+}
+
+// merge returns the positions of both sets of blocks. The definitions of the
+// other blocks follow the definitions of these blocks.
+func (p blockPositions) merge(o blockPositions) blockPositions {
+	if p == nil && o == nil {
+		return nil
+	}
+
+	r := make(blockPositions, len(p)+len(o))
+	for k, v := range p {
+		r[k] = append([]Position{}, v...)
+	}
+	for k, v := range o {
+		r[k] = append(r[k], v...)
+	}
+	return r
+}
+
+// loadBlockPositions parses the configuration files at the paths and records
+// the positions of the configuration blocks.
+func loadBlockPositions(paths []string) (blockPositions, error) {
+	positions := make(blockPositions)
+	for _, path := range paths {
+		files, err := configFilePaths(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			f, err := hcl.ParseBytes(content)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", file, err)
+			}
+			if list, ok := f.Node.(*ast.ObjectList); ok {
+				positions.addFile(file, list)
+			}
+		}
+	}
+	return positions, nil
+}
+
+// addFile records the positions of the blocks within the root object of a
+// configuration file.
+func (p blockPositions) addFile(filename string, root *ast.ObjectList) {
+	for _, item := range root.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+
+		blockType := keyValue(item.Keys[0])
+		switch blockType {
+		case "task", "service":
+			for _, obj := range valueObjects(item.Val) {
+				id := attrValue(obj, "name")
+				if blockType == "service" && attrValue(obj, "id") != "" {
+					id = attrValue(obj, "id")
+				}
+				p.add(blockType, id, itemPosition(filename, item, obj))
+			}
+
+		case "terraform_provider", "provider":
+			// Provider blocks are labeled by the provider name and are
+			// identified by the name and alias.
+			for _, obj := range valueObjects(item.Val) {
+				name, body := providerLabel(item, obj)
+				id := name
+				if alias := attrValue(body, "alias"); alias != "" {
+					id = fmt.Sprintf("%s.%s", name, alias)
+				}
+				p.add("terraform_provider", id, itemPosition(filename, item, obj))
+			}
+
+		case "syslog", "consul", "vault", "driver", "buffer_period":
+			// These blocks are merged across files and each definition is
+			// recorded.
+			for _, obj := range valueObjects(item.Val) {
+				p.add(blockType, "", itemPosition(filename, item, obj))
+			}
+		}
+	}
+}
+
+// providerLabel returns the provider name and the body of a provider block.
+// The name is a label of the item in HCL and a key of the object in JSON.
+func providerLabel(item *ast.ObjectItem, obj *ast.ObjectType) (string, *ast.ObjectType) {
+	if len(item.Keys) > 1 {
+		return keyValue(item.Keys[1]), obj
+	}
+
+	for _, inner := range obj.List.Items {
+		if len(inner.Keys) == 0 {
+			continue
+		}
+		body, _ := inner.Val.(*ast.ObjectType)
+		return keyValue(inner.Keys[0]), body
+	}
+	return "", nil
+}
+
+// attrValue returns the string value of an attribute of an object. An empty
+// string is returned if the attribute is not set to a literal value.
+func attrValue(obj *ast.ObjectType, name string) string {
+	if obj == nil {
+		return ""
+	}
+
+	for _, item := range obj.List.Items {
+		if len(item.Keys) != 1 || keyValue(item.Keys[0]) != name {
+			continue
+		}
+		if lit, ok := item.Val.(*ast.LiteralType); ok {
+			if s, ok := lit.Token.Value().(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// itemPosition returns the position of a block. The JSON parser does not
+// record the positions of keys, so the position of the first attribute of the
+// block is used instead.
+func itemPosition(filename string, item *ast.ObjectItem, obj *ast.ObjectType) Position {
+	pos := item.Keys[0].Pos()
+	if !pos.IsValid() && len(obj.List.Items) > 0 {
+		pos = obj.List.Items[0].Assign
+	}
+	if !pos.IsValid() {
+		pos = item.Assign
+	}
+	return newPosition(filename, pos)
+}
+
+// newPosition converts a position of the HCL parser.
+func newPosition(filename string, pos token.Pos) Position {
+	if !pos.IsValid() {
+		return Position{Filename: filename}
+	}
+	return Position{Filename: filename, Line: pos.Line, Column: pos.Column}
+}
+
+// keyValue returns the unquoted value of an object key.
+func keyValue(key *ast.ObjectKey) string {
+	if s, ok := key.Token.Value().(string); ok {
+		return s
+	}
+	return key.Token.Text
+}
+
+// configFilePaths returns the paths of the configuration files to load for a
+// path. Directories are not loaded recursively and the files within a
+// directory are in the order returned by ioutil.ReadDir, sorted by name.
+func configFilePaths(path string) ([]string, error) {
+	// Ensure the given filepath exists
+	stat, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		log.Printf("[ERR] (config) missing file/folder: %s\n", path)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stat.Mode().IsRegular() {
+		// Skip files when we can
+		if stat.Size() == 0 || !supportedFormat(fileFormat(path)) {
+			return nil, nil
+		}
+		return []string{path}, nil
+	}
+
+	if !stat.Mode().IsDir() {
+		return nil, fmt.Errorf("unknown filetype %q: %s", stat.Mode().String(), path)
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		log.Printf("[ERR] (config) failed listing directory: %s\n", path)
+		return nil, err
+	}
+
+	var paths []string
+	for _, fileInfo := range files {
+		// Skip subdirectories
+		if fileInfo.IsDir() {
+			continue
+		}
+
+		// Skip file based on extension before processing
+		if !supportedFormat(fileFormat(fileInfo.Name())) {
+			continue
+		}
+
+		paths = append(paths, filepath.Join(path, fileInfo.Name()))
+	}
+	return paths, nil
+}
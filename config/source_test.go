@@ -0,0 +1,128 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPosition_String(t *testing.T) {
+	assert.Equal(t, "", Position{}.String())
+	assert.Equal(t, "a.hcl", Position{Filename: "a.hcl"}.String())
+	assert.Equal(t, "a.hcl:12:3", Position{Filename: "a.hcl", Line: 12, Column: 3}.String())
+}
+
+func TestLoadBlockPositions(t *testing.T) {
+	testCases := []struct {
+		name      string
+		paths     []string
+		blockType string
+		id        string
+		n         int
+		expected  Position
+	}{
+		{
+			"hcl task",
+			[]string{"testdata/long.hcl"},
+			"task",
+			"task",
+			0,
+			Position{Filename: "testdata/long.hcl", Line: 79, Column: 1},
+		}, {
+			"hcl service",
+			[]string{"testdata/long.hcl"},
+			"service",
+			"serviceB",
+			0,
+			Position{Filename: "testdata/long.hcl", Line: 65, Column: 1},
+		}, {
+			"hcl provider",
+			[]string{"testdata/long.hcl"},
+			"terraform_provider",
+			"X",
+			0,
+			Position{Filename: "testdata/long.hcl", Line: 77, Column: 1},
+		}, {
+			"json task",
+			[]string{"testdata/long.json"},
+			"task",
+			"task",
+			0,
+			Position{Filename: "testdata/long.json", Line: 84, Column: 13},
+		}, {
+			"json service in array",
+			[]string{"testdata/long.json"},
+			"service",
+			"serviceB",
+			0,
+			Position{Filename: "testdata/long.json", Line: 66, Column: 13},
+		}, {
+			"hcl consul",
+			[]string{"testdata/long.hcl"},
+			"consul",
+			"",
+			0,
+			Position{Filename: "testdata/long.hcl", Line: 14, Column: 1},
+		}, {
+			"hcl labeled driver",
+			[]string{"testdata/long.hcl"},
+			"driver",
+			"",
+			0,
+			Position{Filename: "testdata/long.hcl", Line: 41, Column: 1},
+		}, {
+			"dir tasks",
+			[]string{"testdata/merge"},
+			"task",
+			"taskB",
+			0,
+			Position{Filename: "testdata/merge/taskB.hcl", Line: 6, Column: 1},
+		}, {
+			"second definition",
+			[]string{"testdata/long.hcl", "testdata/long.json"},
+			"task",
+			"task",
+			1,
+			Position{Filename: "testdata/long.json", Line: 84, Column: 13},
+		}, {
+			"unknown",
+			[]string{"testdata/long.hcl"},
+			"task",
+			"task",
+			1,
+			Position{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			positions, err := loadBlockPositions(tc.paths)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, positions.get(tc.blockType, tc.id, tc.n))
+		})
+	}
+
+	t.Run("missing path", func(t *testing.T) {
+		_, err := loadBlockPositions([]string{"testdata/dne.hcl"})
+		assert.Error(t, err)
+	})
+}
+
+func TestBlockPositions_merge(t *testing.T) {
+	a := blockPositions{"task.a": {{Filename: "a.hcl", Line: 1}}}
+	b := blockPositions{
+		"task.a": {{Filename: "b.hcl", Line: 2}},
+		"task.b": {{Filename: "b.hcl", Line: 5}},
+	}
+
+	merged := a.merge(b)
+	assert.Equal(t, blockPositions{
+		"task.a": {{Filename: "a.hcl", Line: 1}, {Filename: "b.hcl", Line: 2}},
+		"task.b": {{Filename: "b.hcl", Line: 5}},
+	}, merged)
+	assert.Len(t, a["task.a"], 1, "original positions should not change")
+
+	var empty blockPositions
+	assert.Nil(t, empty.merge(nil))
+}
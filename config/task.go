@@ -39,13 +39,43 @@ type TaskConfig struct {
 	// of the files. Duplicate variables are overwritten with the later value.
 	VarFiles []string `mapstructure:"variable_files"`
 
+	// SensitiveVariables is a list of names of variables from the variable
+	// files that contain sensitive values. For the Terraform driver, the
+	// variables are declared as sensitive to redact their values from the
+	// Terraform plan output. This requires Terraform 0.14 or later.
+	SensitiveVariables []string `mapstructure:"sensitive_variables"`
+
+	// TFVarsTemplate is the path to a template file following hcat syntax that
+	// assigns additional variables for the task. For the Terraform driver, the
+	// template is rendered along with the generated tfvars template and the
+	// variables are passed as arguments to the Terraform module.
+	TFVarsTemplate *string `mapstructure:"tfvars_template"`
+
 	// Version is the version of source the task will use. For the Terraform
 	// driver, this is the module version. The latest version will be used as
 	// the default if omitted.
 	Version *string `mapstructure:"version"`
 
+	// Connect configures the task to monitor the Connect-capable instances of
+	// its services instead of the service instances. For services with a
+	// sidecar proxy, the address and port of the proxy are used.
+	Connect *bool `mapstructure:"connect"`
+
 	// BufferPeriod configures per-task buffer timers.
 	BufferPeriod *BufferPeriodConfig `mapstructure:"buffer_period"`
+
+	// Handlers configures the chains of handlers that execute out-of-band
+	// actions before and after the driver applies changes for the task.
+	// Handlers are executed in the order they are defined within their stage.
+	// A pre-apply handler error vetoes the apply. When no handler blocks are
+	// configured, post-apply handlers are detected by the providers of the task.
+	Handlers *HandlerConfigs `mapstructure:"handler"`
+
+	// ForEach is a list of parameters to stamp out a task for each set of
+	// parameters. The ${each:NAME} sequences within the values of the task
+	// are replaced with the value of the parameter NAME. The names of the
+	// expanded tasks must be unique.
+	ForEach []map[string]string `mapstructure:"for_each"`
 }
 
 // TaskConfigs is a collection of TaskConfig
@@ -75,10 +105,28 @@ func (c *TaskConfig) Copy() *TaskConfig {
 		o.VarFiles = append(o.VarFiles, vf)
 	}
 
+	for _, name := range c.SensitiveVariables {
+		o.SensitiveVariables = append(o.SensitiveVariables, name)
+	}
+
+	o.TFVarsTemplate = StringCopy(c.TFVarsTemplate)
+
 	o.Version = StringCopy(c.Version)
 
+	o.Connect = BoolCopy(c.Connect)
+
 	o.BufferPeriod = c.BufferPeriod.Copy()
 
+	o.Handlers = c.Handlers.Copy()
+
+	for _, params := range c.ForEach {
+		copy := make(map[string]string, len(params))
+		for k, v := range params {
+			copy[k] = v
+		}
+		o.ForEach = append(o.ForEach, copy)
+	}
+
 	return &o
 }
 
@@ -124,14 +172,34 @@ func (c *TaskConfig) Merge(o *TaskConfig) *TaskConfig {
 		r.VarFiles = append(r.VarFiles, vf)
 	}
 
+	for _, name := range o.SensitiveVariables {
+		r.SensitiveVariables = append(r.SensitiveVariables, name)
+	}
+
+	if o.TFVarsTemplate != nil {
+		r.TFVarsTemplate = StringCopy(o.TFVarsTemplate)
+	}
+
 	if o.Version != nil {
 		r.Version = StringCopy(o.Version)
 	}
 
+	if o.Connect != nil {
+		r.Connect = BoolCopy(o.Connect)
+	}
+
 	if o.BufferPeriod != nil {
 		r.BufferPeriod = r.BufferPeriod.Merge(o.BufferPeriod)
 	}
 
+	if o.Handlers != nil {
+		r.Handlers = r.Handlers.Merge(o.Handlers)
+	}
+
+	for _, params := range o.Copy().ForEach {
+		r.ForEach = append(r.ForEach, params)
+	}
+
 	return r
 }
 
@@ -165,14 +233,31 @@ func (c *TaskConfig) Finalize() {
 		c.VarFiles = []string{}
 	}
 
+	if c.SensitiveVariables == nil {
+		c.SensitiveVariables = []string{}
+	}
+
+	if c.TFVarsTemplate == nil {
+		c.TFVarsTemplate = String("")
+	}
+
 	if c.Version == nil {
 		c.Version = String("")
 	}
 
+	if c.Connect == nil {
+		c.Connect = Bool(false)
+	}
+
 	if c.BufferPeriod == nil {
 		c.BufferPeriod = DefaultTaskBufferPeriodConfig()
 	}
 	c.BufferPeriod.Finalize()
+
+	if c.Handlers == nil {
+		c.Handlers = DefaultHandlerConfigs()
+	}
+	c.Handlers.Finalize()
 }
 
 // Validate validates the values and required options. This method is recommended
@@ -212,10 +297,21 @@ func (c *TaskConfig) Validate() error {
 		pNames[name] = true
 	}
 
+	for _, name := range c.SensitiveVariables {
+		if !hclsyntax.ValidIdentifier(name) {
+			return fmt.Errorf("invalid sensitive variable name for task %q: %q",
+				*c.Name, name)
+		}
+	}
+
 	if err := c.BufferPeriod.Validate(); err != nil {
 		return err
 	}
 
+	if err := c.Handlers.Validate(); err != nil {
+		return fmt.Errorf("invalid handler for task %q: %s", *c.Name, err)
+	}
+
 	return nil
 }
 
@@ -233,8 +329,13 @@ func (c *TaskConfig) GoString() string {
 		"Services:%s, "+
 		"Source:%s, "+
 		"VarFiles:%s, "+
+		"SensitiveVariables:%s, "+
+		"TFVarsTemplate:%s, "+
 		"Version:%s, "+
-		"BufferPeriod:%s"+
+		"Connect:%t, "+
+		"BufferPeriod:%s, "+
+		"Handlers:%s, "+
+		"ForEach:%s"+
 		"}",
 		StringVal(c.Name),
 		StringVal(c.Description),
@@ -242,8 +343,13 @@ func (c *TaskConfig) GoString() string {
 		c.Services,
 		StringVal(c.Source),
 		c.VarFiles,
+		c.SensitiveVariables,
+		StringVal(c.TFVarsTemplate),
 		StringVal(c.Version),
+		BoolVal(c.Connect),
 		c.BufferPeriod.GoString(),
+		c.Handlers.GoString(),
+		c.ForEach,
 	)
 }
 
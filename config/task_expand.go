@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// eachRegexp matches the ${each:NAME} sequences of a task with for_each. A
+// sequence escaped with an additional $, like $${each:NAME}, is matched to
+// leave it unexpanded.
+var eachRegexp = regexp.MustCompile(`\$?\$\{each:([^}]*)\}`)
+
+// mergeTaskDefaults merges the task defaults with a task, with values of the
+// task taking precedence. A provider of the task replaces the default
+// provider of the same name, like a provider alias of the task in place of
+// the default provider configuration.
+func mergeTaskDefaults(defaults, task *TaskConfig) *TaskConfig {
+	if defaults == nil {
+		return task
+	}
+
+	r := defaults.Merge(task)
+
+	taskProviders := make(map[string]bool)
+	for _, p := range task.Providers {
+		taskProviders[strings.Split(p, ".")[0]] = true
+	}
+	r.Providers = nil
+	for _, p := range defaults.Providers {
+		if !taskProviders[strings.Split(p, ".")[0]] {
+			r.Providers = append(r.Providers, p)
+		}
+	}
+	r.Providers = append(r.Providers, task.Providers...)
+
+	return r
+}
+
+// expand returns a task for each set of parameters of for_each. A task
+// without for_each is returned as the only task. It is an error for a task to
+// use a parameter that is not defined.
+func (c *TaskConfig) expand() ([]*TaskConfig, error) {
+	if len(c.ForEach) == 0 {
+		t, err := c.withParams(nil)
+		if err != nil {
+			return nil, err
+		}
+		return []*TaskConfig{t}, nil
+	}
+
+	tasks := make([]*TaskConfig, len(c.ForEach))
+	for i, params := range c.ForEach {
+		t, err := c.withParams(params)
+		if err != nil {
+			return nil, fmt.Errorf("for_each parameters #%d: %s", i+1, err)
+		}
+		t.ForEach = nil
+		tasks[i] = t
+	}
+	return tasks, nil
+}
+
+// withParams returns a copy of the task with the ${each:NAME} sequences
+// within its values replaced by the parameters.
+func (c *TaskConfig) withParams(params map[string]string) (*TaskConfig, error) {
+	var err error
+	expand := func(s string) string {
+		return eachRegexp.ReplaceAllStringFunc(s, func(m string) string {
+			if strings.HasPrefix(m, "$$") {
+				return m[1:]
+			}
+			name := eachRegexp.FindStringSubmatch(m)[1]
+			v, ok := params[name]
+			if !ok && err == nil {
+				err = undefinedParamError(name, params)
+			}
+			return v
+		})
+	}
+	expandString := func(s *string) {
+		if s != nil {
+			*s = expand(*s)
+		}
+	}
+	expandStrings := func(l []string) {
+		for i, s := range l {
+			l[i] = expand(s)
+		}
+	}
+
+	r := c.Copy()
+	expandString(r.Name)
+	expandString(r.Description)
+	expandString(r.Source)
+	expandString(r.TFVarsTemplate)
+	expandString(r.Version)
+	expandStrings(r.Providers)
+	expandStrings(r.Services)
+	expandStrings(r.VarFiles)
+	expandStrings(r.SensitiveVariables)
+
+	if r.Handlers != nil {
+		for _, h := range *r.Handlers {
+			for k, v := range *h {
+				(*h)[k] = expandValue(v, expand)
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// expandValue returns a copy of a decoded value with the strings expanded.
+func expandValue(v interface{}, expand func(string) string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return expand(v)
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			m[k] = expandValue(elem, expand)
+		}
+		return m
+
+	case []map[string]interface{}:
+		l := make([]map[string]interface{}, len(v))
+		for i, elem := range v {
+			l[i] = expandValue(elem, expand).(map[string]interface{})
+		}
+		return l
+
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, elem := range v {
+			l[i] = expandValue(elem, expand)
+		}
+		return l
+	}
+	return v
+}
+
+// undefinedParamError describes a parameter used by a task that is not
+// defined by the parameters.
+func undefinedParamError(name string, params map[string]string) error {
+	if len(params) == 0 {
+		return fmt.Errorf("${each:%s} is used by a task without for_each", name)
+	}
+
+	names := make([]string, 0, len(params))
+	for k := range params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("parameter %q of ${each:%s} is not defined, expected "+
+		"one of: %s", name, name, strings.Join(names, ", "))
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTaskDefaults(t *testing.T) {
+	testCases := []struct {
+		name     string
+		defaults *TaskConfig
+		task     *TaskConfig
+		expected *TaskConfig
+	}{
+		{
+			"nil defaults",
+			nil,
+			&TaskConfig{Name: String("task")},
+			&TaskConfig{Name: String("task")},
+		}, {
+			"task overrides",
+			&TaskConfig{
+				Source:   String("default/module"),
+				Version:  String("1.0.0"),
+				Services: []string{"api"},
+				VarFiles: []string{"default.tfvars"},
+			},
+			&TaskConfig{
+				Name:     String("task"),
+				Version:  String("2.0.0"),
+				Services: []string{"web"},
+				VarFiles: []string{"task.tfvars"},
+			},
+			&TaskConfig{
+				Name:     String("task"),
+				Source:   String("default/module"),
+				Version:  String("2.0.0"),
+				Services: []string{"api", "web"},
+				VarFiles: []string{"default.tfvars", "task.tfvars"},
+			},
+		}, {
+			"providers",
+			&TaskConfig{Providers: []string{"aws", "local"}},
+			&TaskConfig{Providers: []string{"aws.west", "null"}},
+			&TaskConfig{Providers: []string{"local", "aws.west", "null"}},
+		}, {
+			"default providers",
+			&TaskConfig{Providers: []string{"aws"}},
+			&TaskConfig{},
+			&TaskConfig{Providers: []string{"aws"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := mergeTaskDefaults(tc.defaults, tc.task)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestTaskConfig_expand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		task     *TaskConfig
+		expected []*TaskConfig
+		err      string
+	}{
+		{
+			"no for_each",
+			&TaskConfig{Name: String("task"), Source: String("$${each:escaped}")},
+			[]*TaskConfig{{Name: String("task"), Source: String("${each:escaped}")}},
+			"",
+		}, {
+			"for_each",
+			&TaskConfig{
+				Name:               String("task-${each:dc}"),
+				Description:        String("${each:dc} in ${each:env}"),
+				Providers:          []string{"aws.${each:env}"},
+				Services:           []string{"api-${each:dc}"},
+				Source:             String("module"),
+				VarFiles:           []string{"${each:env}.tfvars"},
+				SensitiveVariables: []string{"password"},
+				Handlers: &HandlerConfigs{{
+					"panos": map[string]interface{}{
+						"device_groups": []interface{}{"${each:dc}"},
+					},
+				}},
+				ForEach: []map[string]string{
+					{"dc": "dc1", "env": "prod"},
+					{"dc": "dc2", "env": "dev"},
+				},
+			},
+			[]*TaskConfig{
+				{
+					Name:               String("task-dc1"),
+					Description:        String("dc1 in prod"),
+					Providers:          []string{"aws.prod"},
+					Services:           []string{"api-dc1"},
+					Source:             String("module"),
+					VarFiles:           []string{"prod.tfvars"},
+					SensitiveVariables: []string{"password"},
+					Handlers: &HandlerConfigs{{
+						"panos": map[string]interface{}{
+							"device_groups": []interface{}{"dc1"},
+						},
+					}},
+				}, {
+					Name:               String("task-dc2"),
+					Description:        String("dc2 in dev"),
+					Providers:          []string{"aws.dev"},
+					Services:           []string{"api-dc2"},
+					Source:             String("module"),
+					VarFiles:           []string{"dev.tfvars"},
+					SensitiveVariables: []string{"password"},
+					Handlers: &HandlerConfigs{{
+						"panos": map[string]interface{}{
+							"device_groups": []interface{}{"dc2"},
+						},
+					}},
+				},
+			},
+			"",
+		}, {
+			"undefined parameter",
+			&TaskConfig{
+				Name:    String("task-${each:datacenter}"),
+				ForEach: []map[string]string{{"dc": "dc1"}},
+			},
+			nil,
+			`parameter "datacenter" of ${each:datacenter} is not defined, ` +
+				`expected one of: dc`,
+		}, {
+			"parameter without for_each",
+			&TaskConfig{Name: String("task-${each:dc}")},
+			nil,
+			"${each:dc} is used by a task without for_each",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original := tc.task.Copy()
+			actual, err := tc.task.expand()
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, original, tc.task, "task is not modified")
+		})
+	}
+}
+
+func TestBuildConfig_taskDefaults(t *testing.T) {
+	conf, err := BuildConfig([]string{"testdata/defaults"})
+	require.NoError(t, err)
+	assert.Nil(t, conf.TaskDefaults)
+
+	require.Equal(t, 3, conf.Tasks.Len())
+	tasks := *conf.Tasks
+	assert.Equal(t, &TaskConfig{
+		Name:        String("web-dc1"),
+		Description: String("web services in dc1"),
+		Providers:   []string{"local"},
+		Services:    []string{"web"},
+		Source:      String("org/module/local"),
+		Version:     String("1.2.0"),
+		VarFiles:    []string{"dc1.tfvars"},
+		BufferPeriod: &BufferPeriodConfig{
+			Min: TimeDuration(10 * time.Second),
+		},
+	}, tasks[0])
+	assert.Equal(t, "web-dc2", *tasks[1].Name)
+	assert.Equal(t, "api", *tasks[2].Name)
+	assert.Equal(t, []string{"local.alias"}, tasks[2].Providers)
+	assert.Equal(t, "1.3.0", *tasks[2].Version)
+
+	pos := Position{Filename: "testdata/defaults/tasks.hcl", Line: 1, Column: 1}
+	assert.Equal(t, pos, conf.positions.get("task", "web-dc2", 0))
+	assert.Equal(t, Position{}, conf.positions.get("task", "api", 1))
+
+	conf.Finalize()
+	assert.NoError(t, conf.Validate())
+
+	hcl := string(conf.Effective().HCL())
+	assert.Contains(t, hcl, "# testdata/defaults/tasks.hcl:1:1\ntask {\n")
+	assert.Contains(t, hcl, `  name = "web-dc2"`)
+	assert.NotContains(t, hcl, "task_defaults")
+	assert.NotContains(t, hcl, "for_each")
+}
+
+func TestDecodeConfig_forEach(t *testing.T) {
+	testCases := []struct {
+		name    string
+		format  string
+		content string
+	}{
+		{
+			"hcl",
+			"hcl",
+			`task {
+  name = "task-${each:dc}"
+  for_each = [
+    { dc = "dc1" },
+    { dc = "dc2" },
+  ]
+}`,
+		}, {
+			"json",
+			"json",
+			`{"task": [{
+  "name": "task-${each:dc}",
+  "for_each": [{"dc": "dc1"}, {"dc": "dc2"}]
+}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := decodeConfig([]byte(tc.content), tc.format)
+			require.NoError(t, err)
+			require.Equal(t, 1, c.Tasks.Len())
+			assert.Equal(t, []map[string]string{{"dc": "dc1"}, {"dc": "dc2"}},
+				(*c.Tasks)[0].ForEach)
+		})
+	}
+}
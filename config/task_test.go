@@ -23,12 +23,19 @@ func TestTaskConfig_Copy(t *testing.T) {
 		{
 			"same_enabled",
 			&TaskConfig{
-				Description: String("description"),
-				Name:        String("name"),
-				Providers:   []string{"provider"},
-				Services:    []string{"service"},
-				Source:      String("source"),
-				Version:     String("0.0.0"),
+				Description:        String("description"),
+				Name:               String("name"),
+				Providers:          []string{"provider"},
+				Services:           []string{"service"},
+				Source:             String("source"),
+				Version:            String("0.0.0"),
+				Connect:            Bool(true),
+				TFVarsTemplate:     String("path/to/tfvars.tmpl"),
+				SensitiveVariables: []string{"password"},
+				Handlers: &HandlerConfigs{{
+					"handler": map[string]interface{}{"attr": "value"},
+				}},
+				ForEach: []map[string]string{{"dc": "dc1"}},
 			},
 		},
 	}
@@ -204,6 +211,66 @@ func TestTaskConfig_Merge(t *testing.T) {
 			&TaskConfig{Version: String("0.0.0")},
 			&TaskConfig{Version: String("0.0.0")},
 		},
+		{
+			"sensitive_variables_merges",
+			&TaskConfig{SensitiveVariables: []string{"a"}},
+			&TaskConfig{SensitiveVariables: []string{"b"}},
+			&TaskConfig{SensitiveVariables: []string{"a", "b"}},
+		},
+		{
+			"sensitive_variables_empty_one",
+			&TaskConfig{SensitiveVariables: []string{"a"}},
+			&TaskConfig{},
+			&TaskConfig{SensitiveVariables: []string{"a"}},
+		},
+		{
+			"sensitive_variables_empty_two",
+			&TaskConfig{},
+			&TaskConfig{SensitiveVariables: []string{"b"}},
+			&TaskConfig{SensitiveVariables: []string{"b"}},
+		},
+		{
+			"tfvars_template_overrides",
+			&TaskConfig{TFVarsTemplate: String("a.tmpl")},
+			&TaskConfig{TFVarsTemplate: String("b.tmpl")},
+			&TaskConfig{TFVarsTemplate: String("b.tmpl")},
+		},
+		{
+			"tfvars_template_empty_one",
+			&TaskConfig{TFVarsTemplate: String("a.tmpl")},
+			&TaskConfig{},
+			&TaskConfig{TFVarsTemplate: String("a.tmpl")},
+		},
+		{
+			"connect_overrides",
+			&TaskConfig{Connect: Bool(true)},
+			&TaskConfig{Connect: Bool(false)},
+			&TaskConfig{Connect: Bool(false)},
+		},
+		{
+			"connect_empty_one",
+			&TaskConfig{Connect: Bool(true)},
+			&TaskConfig{},
+			&TaskConfig{Connect: Bool(true)},
+		},
+		{
+			"connect_empty_two",
+			&TaskConfig{},
+			&TaskConfig{Connect: Bool(true)},
+			&TaskConfig{Connect: Bool(true)},
+		},
+		{
+			"for_each_merges",
+			&TaskConfig{ForEach: []map[string]string{{"dc": "dc1"}}},
+			&TaskConfig{ForEach: []map[string]string{{"dc": "dc2"}}},
+			&TaskConfig{ForEach: []map[string]string{{"dc": "dc1"}, {"dc": "dc2"}}},
+		},
+		{
+			"for_each_empty_one",
+			&TaskConfig{ForEach: []map[string]string{{"dc": "dc1"}}},
+			&TaskConfig{},
+			&TaskConfig{ForEach: []map[string]string{{"dc": "dc1"}}},
+		},
 	}
 
 	for i, tc := range cases {
@@ -224,14 +291,18 @@ func TestTaskConfig_Finalize(t *testing.T) {
 			"empty",
 			&TaskConfig{},
 			&TaskConfig{
-				Description:  String(""),
-				Name:         String(""),
-				Providers:    []string{},
-				Services:     []string{},
-				Source:       String(""),
-				VarFiles:     []string{},
-				Version:      String(""),
-				BufferPeriod: DefaultTaskBufferPeriodConfig(),
+				Description:        String(""),
+				Name:               String(""),
+				Providers:          []string{},
+				Services:           []string{},
+				Source:             String(""),
+				VarFiles:           []string{},
+				SensitiveVariables: []string{},
+				TFVarsTemplate:     String(""),
+				Version:            String(""),
+				Connect:            Bool(false),
+				BufferPeriod:       DefaultTaskBufferPeriodConfig(),
+				Handlers:           DefaultHandlerConfigs(),
 			},
 		},
 		{
@@ -240,14 +311,18 @@ func TestTaskConfig_Finalize(t *testing.T) {
 				Name: String("task"),
 			},
 			&TaskConfig{
-				Description:  String(""),
-				Name:         String("task"),
-				Providers:    []string{},
-				Services:     []string{},
-				Source:       String(""),
-				VarFiles:     []string{},
-				Version:      String(""),
-				BufferPeriod: DefaultTaskBufferPeriodConfig(),
+				Description:        String(""),
+				Name:               String("task"),
+				Providers:          []string{},
+				Services:           []string{},
+				Source:             String(""),
+				VarFiles:           []string{},
+				SensitiveVariables: []string{},
+				TFVarsTemplate:     String(""),
+				Version:            String(""),
+				Connect:            Bool(false),
+				BufferPeriod:       DefaultTaskBufferPeriodConfig(),
+				Handlers:           DefaultHandlerConfigs(),
 			},
 		},
 	}
@@ -310,6 +385,26 @@ func TestTaskConfig_Validate(t *testing.T) {
 			&TaskConfig{Name: String("task"), Services: []string{"service"}},
 			false,
 		},
+		{
+			"sensitive variables",
+			&TaskConfig{
+				Name:               String("task"),
+				Services:           []string{"serviceA"},
+				Source:             String("source"),
+				SensitiveVariables: []string{"password", "api_token"},
+			},
+			true,
+		},
+		{
+			"invalid sensitive variable",
+			&TaskConfig{
+				Name:               String("task"),
+				Services:           []string{"serviceA"},
+				Source:             String("source"),
+				SensitiveVariables: []string{""},
+			},
+			false,
+		},
 		{
 			"duplicate provider",
 			&TaskConfig{
@@ -330,6 +425,18 @@ func TestTaskConfig_Validate(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"invalid handler",
+			&TaskConfig{
+				Name:     String("task"),
+				Services: []string{"serviceA", "serviceB"},
+				Source:   String("source"),
+				Handlers: &HandlerConfigs{{
+					"handler": map[string]interface{}{"enabled": "no"},
+				}},
+			},
+			false,
+		},
 	}
 
 	for i, tc := range cases {
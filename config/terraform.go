@@ -19,6 +19,10 @@ const (
 	// DefaultTFWorkingDir is the default location where Sync will use as the
 	// working directory to manage infrastructure.
 	DefaultTFWorkingDir = "sync-tasks"
+
+	// DefaultTFVarsFormat is the default format of the input variables file
+	// generated for each task.
+	DefaultTFVarsFormat = "hcl"
 )
 
 // TerraformConfig is the configuration for the Terraform driver.
@@ -30,6 +34,11 @@ type TerraformConfig struct {
 	WorkingDir        *string                `mapstructure:"working_dir"`
 	Backend           map[string]interface{} `mapstructure:"backend"`
 	RequiredProviders map[string]interface{} `mapstructure:"required_providers"`
+
+	// TFVarsFormat is the format of the input variables file generated for
+	// each task, either "hcl" or "json". The JSON format encodes Consul values
+	// with JSON escaping.
+	TFVarsFormat *string `mapstructure:"tfvars_format"`
 }
 
 // DefaultTerraformConfig returns the default configuration struct.
@@ -48,10 +57,39 @@ func DefaultTerraformConfig() *TerraformConfig {
 		WorkingDir:        String(path.Join(wd, DefaultTFWorkingDir)),
 		Backend:           make(map[string]interface{}),
 		RequiredProviders: make(map[string]interface{}),
+		TFVarsFormat:      String(DefaultTFVarsFormat),
+	}
+}
+
+// supportedBackends are the labels of the Terraform backends supported by
+// Sync for state store.
+var supportedBackends = []string{
+	"azurerm",
+	"consul",
+	"cos",
+	"gcs",
+	"kubernetes",
+	"local",
+	"manta",
+	"pg",
+	"s3",
+}
+
+// supportedBackend returns whether the Terraform backend is supported.
+func supportedBackend(label string) bool {
+	for _, b := range supportedBackends {
+		if b == label {
+			return true
+		}
 	}
+	return false
 }
 
 // DefaultTerraformBackend returns the default configuration to Consul KV.
+// The Consul token is not part of the configuration so that a token from the
+// Consul token_file can change without initializing Terraform again. Instead
+// the token is given to Terraform with the CONSUL_HTTP_TOKEN environment
+// variable.
 func DefaultTerraformBackend(consul *ConsulConfig) (map[string]interface{}, error) {
 	if consul == nil {
 		return nil, fmt.Errorf("Consul is not configured to set the default backend for Terraform")
@@ -129,6 +167,10 @@ func (c *TerraformConfig) Copy() *TerraformConfig {
 		}
 	}
 
+	if c.TFVarsFormat != nil {
+		o.TFVarsFormat = StringCopy(c.TFVarsFormat)
+	}
+
 	return &o
 }
 
@@ -191,6 +233,10 @@ func (c *TerraformConfig) Merge(o *TerraformConfig) *TerraformConfig {
 		}
 	}
 
+	if o.TFVarsFormat != nil {
+		r.TFVarsFormat = StringCopy(o.TFVarsFormat)
+	}
+
 	return r
 }
 
@@ -243,6 +289,10 @@ func (c *TerraformConfig) Finalize(consul *ConsulConfig) {
 	if c.RequiredProviders == nil {
 		c.RequiredProviders = make(map[string]interface{})
 	}
+
+	if c.TFVarsFormat == nil || *c.TFVarsFormat == "" {
+		c.TFVarsFormat = String(DefaultTFVarsFormat)
+	}
 }
 
 // Validate validates the values and nested values of the configuration struct
@@ -276,21 +326,20 @@ func (c *TerraformConfig) Validate() error {
 	// configuration options are verified at run time. The allowed backends
 	// for state store have state locking and workspace suppport.
 	for k := range c.Backend {
-		switch k {
-		case "azurerm",
-			"consul",
-			"cos",
-			"gcs",
-			"kubernetes",
-			"local",
-			"manta",
-			"pg",
-			"s3":
-		default:
+		if !supportedBackend(k) {
 			return fmt.Errorf("unsupported Terraform backend by Sync %q", k)
 		}
 	}
 
+	if c.TFVarsFormat != nil {
+		switch *c.TFVarsFormat {
+		case "", "hcl", "json":
+		default:
+			return fmt.Errorf("unsupported tfvars_format %q, expected \"hcl\" "+
+				"or \"json\"", *c.TFVarsFormat)
+		}
+	}
+
 	return nil
 }
 
@@ -307,7 +356,8 @@ func (c *TerraformConfig) GoString() string {
 		"Path:%s, "+
 		"WorkingDir:%s, "+
 		"Backend:%+v, "+
-		"RequiredProviders:%+v"+
+		"RequiredProviders:%+v, "+
+		"TFVarsFormat:%s"+
 		"}",
 		StringVal(c.Version),
 		BoolVal(c.Log),
@@ -316,6 +366,7 @@ func (c *TerraformConfig) GoString() string {
 		StringVal(c.WorkingDir),
 		c.Backend,
 		c.RequiredProviders,
+		StringVal(c.TFVarsFormat),
 	)
 }
 
@@ -39,6 +39,7 @@ func TestTerraformConfig_Copy(t *testing.T) {
 						"source":  "namespace/pName2",
 					},
 				},
+				TFVarsFormat: String("json"),
 			},
 		},
 	}
@@ -108,6 +109,24 @@ func TestTerraformConfig_Merge(t *testing.T) {
 			&TerraformConfig{Version: String("version")},
 			&TerraformConfig{Version: String("version")},
 		},
+		{
+			"tfvars_format_overrides",
+			&TerraformConfig{TFVarsFormat: String("hcl")},
+			&TerraformConfig{TFVarsFormat: String("json")},
+			&TerraformConfig{TFVarsFormat: String("json")},
+		},
+		{
+			"tfvars_format_empty_one",
+			&TerraformConfig{TFVarsFormat: String("json")},
+			&TerraformConfig{},
+			&TerraformConfig{TFVarsFormat: String("json")},
+		},
+		{
+			"tfvars_format_empty_two",
+			&TerraformConfig{},
+			&TerraformConfig{TFVarsFormat: String("json")},
+			&TerraformConfig{TFVarsFormat: String("json")},
+		},
 		{
 			"log_overrides",
 			&TerraformConfig{Log: Bool(false)},
@@ -424,6 +443,7 @@ func TestTerraformConfig_Finalize(t *testing.T) {
 				WorkingDir:        String(path.Join(wd, DefaultTFWorkingDir)),
 				Backend:           map[string]interface{}{},
 				RequiredProviders: map[string]interface{}{},
+				TFVarsFormat:      String(DefaultTFVarsFormat),
 			},
 		},
 		{
@@ -444,6 +464,7 @@ func TestTerraformConfig_Finalize(t *testing.T) {
 					},
 				},
 				RequiredProviders: map[string]interface{}{},
+				TFVarsFormat:      String(DefaultTFVarsFormat),
 			},
 		},
 		{
@@ -474,6 +495,7 @@ func TestTerraformConfig_Finalize(t *testing.T) {
 					},
 				},
 				RequiredProviders: map[string]interface{}{},
+				TFVarsFormat:      String(DefaultTFVarsFormat),
 			},
 		},
 		{
@@ -497,6 +519,7 @@ func TestTerraformConfig_Finalize(t *testing.T) {
 					},
 				},
 				RequiredProviders: map[string]interface{}{},
+				TFVarsFormat:      String(DefaultTFVarsFormat),
 			},
 		},
 	}
@@ -545,6 +568,20 @@ func TestTerraformConfig_Validate(t *testing.T) {
 			"backend_invalid",
 			&TerraformConfig{Backend: map[string]interface{}{"unsupported": nil}},
 			false,
+		}, {
+			"json tfvars format",
+			&TerraformConfig{
+				Backend:      map[string]interface{}{"local": nil},
+				TFVarsFormat: String("json"),
+			},
+			true,
+		}, {
+			"unsupported tfvars format",
+			&TerraformConfig{
+				Backend:      map[string]interface{}{"local": nil},
+				TFVarsFormat: String("yaml"),
+			},
+			false,
 		},
 	}
 
@@ -0,0 +1,15 @@
+task_defaults {
+  source = "org/module/local"
+  version = "1.2.0"
+  providers = ["local"]
+  variable_files = ["${each:datacenter}.tfvars"]
+  buffer_period {
+    min = "10s"
+  }
+}
+
+terraform_provider "local" {}
+
+terraform_provider "local" {
+  alias = "alias"
+}
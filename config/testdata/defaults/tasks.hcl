@@ -0,0 +1,19 @@
+task {
+  name = "web-${each:datacenter}"
+  description = "web services in ${each:datacenter}"
+  services = ["web"]
+  for_each = [
+    { datacenter = "dc1" },
+    { datacenter = "dc2" },
+  ]
+}
+
+task {
+  name = "api"
+  services = ["api"]
+  version = "1.3.0"
+  providers = ["local.alias"]
+  for_each = [
+    { datacenter = "dc1" },
+  ]
+}
@@ -42,6 +42,7 @@ driver "terraform" {
   log = true
   path = "path"
   working_dir = "working"
+  tfvars_format = "json"
   backend "consul" {
     address = "consul-example.com"
     path = "kv-path/terraform"
@@ -64,7 +65,13 @@ service {
 service {
   name = "serviceB"
   namespace = "teamB"
+  datacenter = "dc2"
   description = "descriptionB"
+  tags = ["canary", "v2"]
+  health_status = "any"
+  node_meta {
+    rack = "rack-1"
+  }
 }
 
 terraform_provider "X" {}
@@ -75,4 +82,8 @@ task {
   services = ["serviceA", "serviceB", "serviceC"]
   providers = ["X"]
   source = "Y"
+  connect = true
+  handler "X" {
+    enabled = false
+  }
 }
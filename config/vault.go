@@ -22,6 +22,11 @@ type VaultConfig struct {
 	// Address is the URI to the Vault server.
 	Address *string `mapstructure:"address"`
 
+	// Auth is the auth method to log in to Vault with. The token from the
+	// login is renewed and Consul-Terraform-Sync logs in again when the token
+	// can no longer be renewed. It takes precedence over the Token.
+	Auth *VaultAuthConfig `mapstructure:"auth"`
+
 	// Enabled controls whether the Vault integration is active.
 	Enabled *bool `mapstructure:"enabled"`
 
@@ -29,7 +34,8 @@ type VaultConfig struct {
 	// also be set via the VAULT_NAMESPACE environment variable.
 	Namespace *string `mapstructure:"namespace"`
 
-	// RenewToken renews the Vault token.
+	// RenewToken renews the Vault token from the login of the auth method.
+	// When false, Consul-Terraform-Sync logs in again once the token expires.
 	RenewToken *bool `mapstructure:"renew_token"`
 
 	// TLS indicates we should use a secure connection while talking to Vault.
@@ -78,6 +84,8 @@ func (c *VaultConfig) Copy() *VaultConfig {
 	var o VaultConfig
 	o.Address = StringCopy(c.Address)
 
+	o.Auth = c.Auth.Copy()
+
 	o.Enabled = BoolCopy(c.Enabled)
 
 	o.Namespace = StringCopy(c.Namespace)
@@ -123,6 +131,10 @@ func (c *VaultConfig) Merge(o *VaultConfig) *VaultConfig {
 		r.Address = StringCopy(o.Address)
 	}
 
+	if o.Auth != nil {
+		r.Auth = r.Auth.Merge(o.Auth)
+	}
+
 	if o.Enabled != nil {
 		r.Enabled = BoolCopy(o.Enabled)
 	}
@@ -166,6 +178,11 @@ func (c *VaultConfig) Finalize() {
 		}, "")
 	}
 
+	if c.Auth == nil {
+		c.Auth = DefaultVaultAuthConfig()
+	}
+	c.Auth.Finalize()
+
 	if c.Namespace == nil {
 		c.Namespace = stringFromEnv([]string{api.EnvVaultNamespace}, "")
 	}
@@ -227,7 +244,7 @@ func (c *VaultConfig) Finalize() {
 		defaultRenew := DefaultVaultRenewToken
 		if c.VaultAgentTokenFile != nil {
 			defaultRenew = false
-		} else if StringVal(c.Token) == "" {
+		} else if StringVal(c.Token) == "" && c.Auth.Method() == "" {
 			defaultRenew = false
 		}
 		c.RenewToken = boolFromEnv([]string{
@@ -251,6 +268,35 @@ func (c *VaultConfig) Finalize() {
 	}
 }
 
+// Validate validates the values and required options. This method is
+// recommended to run after Finalize() to ensure the configuration is safe to
+// proceed.
+func (c *VaultConfig) Validate() error {
+	if c == nil || !BoolVal(c.Enabled) {
+		return nil
+	}
+
+	if err := c.Auth.Validate(); err != nil {
+		return fmt.Errorf("vault.auth: %s", err)
+	}
+
+	switch c.Auth.Method() {
+	case "":
+		return nil
+	case "cert":
+		if c.TLS == nil || StringVal(c.TLS.Cert) == "" || StringVal(c.TLS.Key) == "" {
+			return fmt.Errorf("vault.auth: cert: the client certificate and " +
+				"key of vault.tls are required to log in")
+		}
+	}
+
+	if StringPresent(c.VaultAgentTokenFile) {
+		return fmt.Errorf("vault.auth: cannot be configured with " +
+			"vault_agent_token_file")
+	}
+	return nil
+}
+
 // GoString defines the printable version of this struct.
 func (c *VaultConfig) GoString() string {
 	if c == nil {
@@ -259,6 +305,7 @@ func (c *VaultConfig) GoString() string {
 
 	return fmt.Sprintf("&VaultConfig{"+
 		"Address:%s, "+
+		"Auth:%s, "+
 		"Enabled:%v, "+
 		"Namespace:%s,"+
 		"RenewToken:%v, "+
@@ -269,6 +316,7 @@ func (c *VaultConfig) GoString() string {
 		"UnwrapToken:%v"+
 		"}",
 		StringVal(c.Address),
+		c.Auth.GoString(),
 		BoolVal(c.Enabled),
 		StringVal(c.Namespace),
 		BoolVal(c.RenewToken),
@@ -0,0 +1,460 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultVaultAppRoleMountPath is the default path the AppRole auth method
+	// is mounted at.
+	DefaultVaultAppRoleMountPath = "approle"
+
+	// DefaultVaultCertMountPath is the default path the TLS certificate auth
+	// method is mounted at.
+	DefaultVaultCertMountPath = "cert"
+
+	// DefaultVaultKubernetesMountPath is the default path the Kubernetes auth
+	// method is mounted at.
+	DefaultVaultKubernetesMountPath = "kubernetes"
+
+	// DefaultVaultKubernetesTokenPath is the default path of the service
+	// account token mounted into a Kubernetes pod.
+	DefaultVaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// VaultAuthConfig is the configuration of the auth method to log in to Vault
+// with instead of a static token. At most one auth method can be configured.
+type VaultAuthConfig struct {
+	// AppRole logs in with a role ID and secret ID.
+	AppRole *VaultAppRoleAuthConfig `mapstructure:"approle"`
+
+	// Cert logs in with the client certificate of the Vault TLS configuration.
+	Cert *VaultCertAuthConfig `mapstructure:"cert"`
+
+	// Kubernetes logs in with the Kubernetes service account token.
+	Kubernetes *VaultKubernetesAuthConfig `mapstructure:"kubernetes"`
+}
+
+// DefaultVaultAuthConfig returns a configuration without an auth method.
+func DefaultVaultAuthConfig() *VaultAuthConfig {
+	return &VaultAuthConfig{}
+}
+
+// Copy returns a deep copy of this configuration.
+func (c *VaultAuthConfig) Copy() *VaultAuthConfig {
+	if c == nil {
+		return nil
+	}
+
+	var o VaultAuthConfig
+	o.AppRole = c.AppRole.Copy()
+	o.Cert = c.Cert.Copy()
+	o.Kubernetes = c.Kubernetes.Copy()
+	return &o
+}
+
+// Merge combines all values in this configuration with the values in the other
+// configuration, with values in the other configuration taking precedence.
+// Maps and slices are merged, most other values are overwritten. Complex
+// structs define their own merge functionality.
+func (c *VaultAuthConfig) Merge(o *VaultAuthConfig) *VaultAuthConfig {
+	if c == nil {
+		if o == nil {
+			return nil
+		}
+		return o.Copy()
+	}
+
+	if o == nil {
+		return c.Copy()
+	}
+
+	r := c.Copy()
+
+	if o.AppRole != nil {
+		r.AppRole = r.AppRole.Merge(o.AppRole)
+	}
+
+	if o.Cert != nil {
+		r.Cert = r.Cert.Merge(o.Cert)
+	}
+
+	if o.Kubernetes != nil {
+		r.Kubernetes = r.Kubernetes.Merge(o.Kubernetes)
+	}
+
+	return r
+}
+
+// Finalize ensures there no nil pointers for the configured auth methods.
+// Auth methods that are not configured remain nil.
+func (c *VaultAuthConfig) Finalize() {
+	if c == nil {
+		return
+	}
+
+	c.AppRole.Finalize()
+	c.Cert.Finalize()
+	c.Kubernetes.Finalize()
+}
+
+// Validate validates the values and that at most one auth method is
+// configured.
+func (c *VaultAuthConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if methods := c.methods(); len(methods) > 1 {
+		return fmt.Errorf("only one auth method can be configured, found: %s",
+			strings.Join(methods, ", "))
+	}
+
+	if err := c.AppRole.Validate(); err != nil {
+		return err
+	}
+	return c.Kubernetes.Validate()
+}
+
+// Method returns the name of the configured auth method, or an empty string
+// if no auth method is configured.
+func (c *VaultAuthConfig) Method() string {
+	if methods := c.methods(); len(methods) > 0 {
+		return methods[0]
+	}
+	return ""
+}
+
+func (c *VaultAuthConfig) methods() []string {
+	if c == nil {
+		return nil
+	}
+
+	var methods []string
+	if c.AppRole != nil {
+		methods = append(methods, "approle")
+	}
+	if c.Cert != nil {
+		methods = append(methods, "cert")
+	}
+	if c.Kubernetes != nil {
+		methods = append(methods, "kubernetes")
+	}
+	return methods
+}
+
+// GoString defines the printable version of this struct.
+func (c *VaultAuthConfig) GoString() string {
+	if c == nil {
+		return "(*VaultAuthConfig)(nil)"
+	}
+
+	return fmt.Sprintf("&VaultAuthConfig{"+
+		"AppRole:%s, "+
+		"Cert:%s, "+
+		"Kubernetes:%s"+
+		"}",
+		c.AppRole.GoString(),
+		c.Cert.GoString(),
+		c.Kubernetes.GoString(),
+	)
+}
+
+// VaultAppRoleAuthConfig is the configuration to log in to Vault with the
+// AppRole auth method.
+type VaultAppRoleAuthConfig struct {
+	// MountPath is the path the auth method is mounted at.
+	MountPath *string `mapstructure:"mount_path"`
+
+	// RoleID is the role ID to log in with.
+	RoleID *string `mapstructure:"role_id"`
+
+	// SecretID is the secret ID to log in with. It is optional for roles that
+	// do not require a secret ID.
+	SecretID *string `mapstructure:"secret_id"`
+}
+
+// Copy returns a deep copy of this configuration.
+func (c *VaultAppRoleAuthConfig) Copy() *VaultAppRoleAuthConfig {
+	if c == nil {
+		return nil
+	}
+
+	var o VaultAppRoleAuthConfig
+	o.MountPath = StringCopy(c.MountPath)
+	o.RoleID = StringCopy(c.RoleID)
+	o.SecretID = StringCopy(c.SecretID)
+	return &o
+}
+
+// Merge combines all values in this configuration with the values in the other
+// configuration, with values in the other configuration taking precedence.
+func (c *VaultAppRoleAuthConfig) Merge(o *VaultAppRoleAuthConfig) *VaultAppRoleAuthConfig {
+	if c == nil {
+		if o == nil {
+			return nil
+		}
+		return o.Copy()
+	}
+
+	if o == nil {
+		return c.Copy()
+	}
+
+	r := c.Copy()
+
+	if o.MountPath != nil {
+		r.MountPath = StringCopy(o.MountPath)
+	}
+
+	if o.RoleID != nil {
+		r.RoleID = StringCopy(o.RoleID)
+	}
+
+	if o.SecretID != nil {
+		r.SecretID = StringCopy(o.SecretID)
+	}
+
+	return r
+}
+
+// Finalize ensures there no nil pointers.
+func (c *VaultAppRoleAuthConfig) Finalize() {
+	if c == nil {
+		return
+	}
+
+	if c.MountPath == nil {
+		c.MountPath = String(DefaultVaultAppRoleMountPath)
+	}
+
+	if c.RoleID == nil {
+		c.RoleID = String("")
+	}
+
+	if c.SecretID == nil {
+		c.SecretID = String("")
+	}
+}
+
+// Validate validates the values and required options. This method is
+// recommended to run after Finalize() to ensure the configuration is safe to
+// proceed.
+func (c *VaultAppRoleAuthConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if StringVal(c.RoleID) == "" {
+		return fmt.Errorf("approle: role_id is required")
+	}
+	return nil
+}
+
+// GoString defines the printable version of this struct.
+// Sensitive information is redacted.
+func (c *VaultAppRoleAuthConfig) GoString() string {
+	if c == nil {
+		return "(*VaultAppRoleAuthConfig)(nil)"
+	}
+
+	return fmt.Sprintf("&VaultAppRoleAuthConfig{"+
+		"MountPath:%s, "+
+		"RoleID:%s, "+
+		"SecretID:%s"+
+		"}",
+		StringVal(c.MountPath),
+		StringVal(c.RoleID),
+		sensitiveGoString(c.SecretID),
+	)
+}
+
+// VaultCertAuthConfig is the configuration to log in to Vault with the TLS
+// certificate auth method. The client certificate and key of the Vault TLS
+// configuration are used to log in.
+type VaultCertAuthConfig struct {
+	// MountPath is the path the auth method is mounted at.
+	MountPath *string `mapstructure:"mount_path"`
+
+	// Name is the name of the certificate role to log in with. When empty,
+	// Vault tries all of the certificate roles.
+	Name *string `mapstructure:"name"`
+}
+
+// Copy returns a deep copy of this configuration.
+func (c *VaultCertAuthConfig) Copy() *VaultCertAuthConfig {
+	if c == nil {
+		return nil
+	}
+
+	var o VaultCertAuthConfig
+	o.MountPath = StringCopy(c.MountPath)
+	o.Name = StringCopy(c.Name)
+	return &o
+}
+
+// Merge combines all values in this configuration with the values in the other
+// configuration, with values in the other configuration taking precedence.
+func (c *VaultCertAuthConfig) Merge(o *VaultCertAuthConfig) *VaultCertAuthConfig {
+	if c == nil {
+		if o == nil {
+			return nil
+		}
+		return o.Copy()
+	}
+
+	if o == nil {
+		return c.Copy()
+	}
+
+	r := c.Copy()
+
+	if o.MountPath != nil {
+		r.MountPath = StringCopy(o.MountPath)
+	}
+
+	if o.Name != nil {
+		r.Name = StringCopy(o.Name)
+	}
+
+	return r
+}
+
+// Finalize ensures there no nil pointers.
+func (c *VaultCertAuthConfig) Finalize() {
+	if c == nil {
+		return
+	}
+
+	if c.MountPath == nil {
+		c.MountPath = String(DefaultVaultCertMountPath)
+	}
+
+	if c.Name == nil {
+		c.Name = String("")
+	}
+}
+
+// GoString defines the printable version of this struct.
+func (c *VaultCertAuthConfig) GoString() string {
+	if c == nil {
+		return "(*VaultCertAuthConfig)(nil)"
+	}
+
+	return fmt.Sprintf("&VaultCertAuthConfig{"+
+		"MountPath:%s, "+
+		"Name:%s"+
+		"}",
+		StringVal(c.MountPath),
+		StringVal(c.Name),
+	)
+}
+
+// VaultKubernetesAuthConfig is the configuration to log in to Vault with the
+// Kubernetes auth method.
+type VaultKubernetesAuthConfig struct {
+	// MountPath is the path the auth method is mounted at.
+	MountPath *string `mapstructure:"mount_path"`
+
+	// Role is the name of the role to log in with.
+	Role *string `mapstructure:"role"`
+
+	// TokenPath is the path of the service account token. The token is read
+	// for each login so that a rotated token is used.
+	TokenPath *string `mapstructure:"token_path"`
+}
+
+// Copy returns a deep copy of this configuration.
+func (c *VaultKubernetesAuthConfig) Copy() *VaultKubernetesAuthConfig {
+	if c == nil {
+		return nil
+	}
+
+	var o VaultKubernetesAuthConfig
+	o.MountPath = StringCopy(c.MountPath)
+	o.Role = StringCopy(c.Role)
+	o.TokenPath = StringCopy(c.TokenPath)
+	return &o
+}
+
+// Merge combines all values in this configuration with the values in the other
+// configuration, with values in the other configuration taking precedence.
+func (c *VaultKubernetesAuthConfig) Merge(o *VaultKubernetesAuthConfig) *VaultKubernetesAuthConfig {
+	if c == nil {
+		if o == nil {
+			return nil
+		}
+		return o.Copy()
+	}
+
+	if o == nil {
+		return c.Copy()
+	}
+
+	r := c.Copy()
+
+	if o.MountPath != nil {
+		r.MountPath = StringCopy(o.MountPath)
+	}
+
+	if o.Role != nil {
+		r.Role = StringCopy(o.Role)
+	}
+
+	if o.TokenPath != nil {
+		r.TokenPath = StringCopy(o.TokenPath)
+	}
+
+	return r
+}
+
+// Finalize ensures there no nil pointers.
+func (c *VaultKubernetesAuthConfig) Finalize() {
+	if c == nil {
+		return
+	}
+
+	if c.MountPath == nil {
+		c.MountPath = String(DefaultVaultKubernetesMountPath)
+	}
+
+	if c.Role == nil {
+		c.Role = String("")
+	}
+
+	if c.TokenPath == nil {
+		c.TokenPath = String(DefaultVaultKubernetesTokenPath)
+	}
+}
+
+// Validate validates the values and required options. This method is
+// recommended to run after Finalize() to ensure the configuration is safe to
+// proceed.
+func (c *VaultKubernetesAuthConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if StringVal(c.Role) == "" {
+		return fmt.Errorf("kubernetes: role is required")
+	}
+	return nil
+}
+
+// GoString defines the printable version of this struct.
+func (c *VaultKubernetesAuthConfig) GoString() string {
+	if c == nil {
+		return "(*VaultKubernetesAuthConfig)(nil)"
+	}
+
+	return fmt.Sprintf("&VaultKubernetesAuthConfig{"+
+		"MountPath:%s, "+
+		"Role:%s, "+
+		"TokenPath:%s"+
+		"}",
+		StringVal(c.MountPath),
+		StringVal(c.Role),
+		StringVal(c.TokenPath),
+	)
+}
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaultAuthConfig_Copy(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		a    *VaultAuthConfig
+	}{
+		{
+			"nil",
+			nil,
+		},
+		{
+			"empty",
+			&VaultAuthConfig{},
+		},
+		{
+			"same_enabled",
+			&VaultAuthConfig{
+				AppRole: &VaultAppRoleAuthConfig{
+					MountPath: String("approle"),
+					RoleID:    String("role-id"),
+					SecretID:  String("secret-id"),
+				},
+				Cert: &VaultCertAuthConfig{
+					MountPath: String("cert"),
+					Name:      String("web"),
+				},
+				Kubernetes: &VaultKubernetesAuthConfig{
+					MountPath: String("kubernetes"),
+					Role:      String("cts"),
+					TokenPath: String("/path/to/token"),
+				},
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d_%s", i, tc.name), func(t *testing.T) {
+			r := tc.a.Copy()
+			assert.Equal(t, tc.a, r)
+		})
+	}
+}
+
+func TestVaultAuthConfig_Merge(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		a    *VaultAuthConfig
+		b    *VaultAuthConfig
+		r    *VaultAuthConfig
+	}{
+		{
+			"nil_a",
+			nil,
+			&VaultAuthConfig{},
+			&VaultAuthConfig{},
+		},
+		{
+			"nil_b",
+			&VaultAuthConfig{},
+			nil,
+			&VaultAuthConfig{},
+		},
+		{
+			"nil_both",
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"empty",
+			&VaultAuthConfig{},
+			&VaultAuthConfig{},
+			&VaultAuthConfig{},
+		},
+		{
+			"approle_overrides",
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{
+				RoleID: String("role-a"), SecretID: String("secret-a")}},
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{
+				SecretID: String("secret-b")}},
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{
+				RoleID: String("role-a"), SecretID: String("secret-b")}},
+		},
+		{
+			"approle_empty_one",
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{RoleID: String("role")}},
+			&VaultAuthConfig{},
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{RoleID: String("role")}},
+		},
+		{
+			"cert_overrides",
+			&VaultAuthConfig{Cert: &VaultCertAuthConfig{
+				MountPath: String("cert"), Name: String("a")}},
+			&VaultAuthConfig{Cert: &VaultCertAuthConfig{Name: String("b")}},
+			&VaultAuthConfig{Cert: &VaultCertAuthConfig{
+				MountPath: String("cert"), Name: String("b")}},
+		},
+		{
+			"kubernetes_overrides",
+			&VaultAuthConfig{Kubernetes: &VaultKubernetesAuthConfig{
+				Role: String("a"), TokenPath: String("/token")}},
+			&VaultAuthConfig{Kubernetes: &VaultKubernetesAuthConfig{
+				Role: String("b"), MountPath: String("k8s")}},
+			&VaultAuthConfig{Kubernetes: &VaultKubernetesAuthConfig{
+				Role: String("b"), MountPath: String("k8s"), TokenPath: String("/token")}},
+		},
+		{
+			"different_methods",
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{RoleID: String("role")}},
+			&VaultAuthConfig{Kubernetes: &VaultKubernetesAuthConfig{Role: String("cts")}},
+			&VaultAuthConfig{
+				AppRole:    &VaultAppRoleAuthConfig{RoleID: String("role")},
+				Kubernetes: &VaultKubernetesAuthConfig{Role: String("cts")},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tc.a.Merge(tc.b)
+			assert.Equal(t, tc.r, r)
+		})
+	}
+}
+
+func TestVaultAuthConfig_Finalize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		i    *VaultAuthConfig
+		r    *VaultAuthConfig
+	}{
+		{
+			"nil",
+			nil,
+			nil,
+		},
+		{
+			"empty",
+			&VaultAuthConfig{},
+			&VaultAuthConfig{},
+		},
+		{
+			"approle",
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{}},
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{
+				MountPath: String(DefaultVaultAppRoleMountPath),
+				RoleID:    String(""),
+				SecretID:  String(""),
+			}},
+		},
+		{
+			"cert",
+			&VaultAuthConfig{Cert: &VaultCertAuthConfig{}},
+			&VaultAuthConfig{Cert: &VaultCertAuthConfig{
+				MountPath: String(DefaultVaultCertMountPath),
+				Name:      String(""),
+			}},
+		},
+		{
+			"kubernetes",
+			&VaultAuthConfig{Kubernetes: &VaultKubernetesAuthConfig{
+				MountPath: String("k8s"),
+			}},
+			&VaultAuthConfig{Kubernetes: &VaultKubernetesAuthConfig{
+				MountPath: String("k8s"),
+				Role:      String(""),
+				TokenPath: String(DefaultVaultKubernetesTokenPath),
+			}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.i.Finalize()
+			assert.Equal(t, tc.r, tc.i)
+		})
+	}
+}
+
+func TestVaultAuthConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		i       *VaultAuthConfig
+		method  string
+		isValid bool
+	}{
+		{
+			"nil",
+			nil,
+			"",
+			true,
+		},
+		{
+			"empty",
+			&VaultAuthConfig{},
+			"",
+			true,
+		},
+		{
+			"approle",
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{RoleID: String("role")}},
+			"approle",
+			true,
+		},
+		{
+			"approle_missing_role_id",
+			&VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{SecretID: String("secret")}},
+			"approle",
+			false,
+		},
+		{
+			"cert",
+			&VaultAuthConfig{Cert: &VaultCertAuthConfig{}},
+			"cert",
+			true,
+		},
+		{
+			"kubernetes",
+			&VaultAuthConfig{Kubernetes: &VaultKubernetesAuthConfig{Role: String("cts")}},
+			"kubernetes",
+			true,
+		},
+		{
+			"kubernetes_missing_role",
+			&VaultAuthConfig{Kubernetes: &VaultKubernetesAuthConfig{}},
+			"kubernetes",
+			false,
+		},
+		{
+			"multiple_methods",
+			&VaultAuthConfig{
+				Cert:       &VaultCertAuthConfig{},
+				Kubernetes: &VaultKubernetesAuthConfig{Role: String("cts")},
+			},
+			"cert",
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.i.Validate()
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+			assert.Equal(t, tc.method, tc.i.Method())
+		})
+	}
+}
+
+func TestVaultAuthConfig_GoString(t *testing.T) {
+	t.Parallel()
+
+	c := &VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{
+		MountPath: String("approle"),
+		RoleID:    String("role-id"),
+		SecretID:  String("secret-id"),
+	}}
+	assert.NotContains(t, c.GoString(), "secret-id")
+	assert.Contains(t, c.GoString(), "role-id")
+}
@@ -27,7 +27,10 @@ func TestVaultConfig_Copy(t *testing.T) {
 		{
 			"same_enabled",
 			&VaultConfig{
-				Address:    String("address"),
+				Address: String("address"),
+				Auth: &VaultAuthConfig{
+					AppRole: &VaultAppRoleAuthConfig{RoleID: String("role")},
+				},
 				Enabled:    Bool(true),
 				Namespace:  String("foo"),
 				RenewToken: Bool(true),
@@ -298,6 +301,7 @@ func TestVaultConfig_Finalize(t *testing.T) {
 			&VaultConfig{},
 			&VaultConfig{
 				Address:    String(""),
+				Auth:       &VaultAuthConfig{},
 				Enabled:    Bool(false),
 				Namespace:  String(""),
 				RenewToken: Bool(false),
@@ -330,6 +334,7 @@ func TestVaultConfig_Finalize(t *testing.T) {
 			},
 			&VaultConfig{
 				Address:    String("address"),
+				Auth:       &VaultAuthConfig{},
 				Enabled:    Bool(true),
 				Namespace:  String(""),
 				RenewToken: Bool(false),
@@ -362,6 +367,7 @@ func TestVaultConfig_Finalize(t *testing.T) {
 			},
 			&VaultConfig{
 				Address:    String("address"),
+				Auth:       &VaultAuthConfig{},
 				Enabled:    Bool(true),
 				Namespace:  String(""),
 				RenewToken: Bool(false),
@@ -467,3 +473,117 @@ func TestVaultConfig_TokenRenew(t *testing.T) {
 		})
 	}
 }
+
+func TestVaultConfig_Finalize_auth(t *testing.T) {
+	t.Parallel()
+
+	c := &VaultConfig{
+		Address: String("address"),
+		Auth: &VaultAuthConfig{
+			Kubernetes: &VaultKubernetesAuthConfig{Role: String("cts")},
+		},
+		test: true,
+	}
+	c.Finalize()
+
+	assert.Equal(t, &VaultKubernetesAuthConfig{
+		MountPath: String(DefaultVaultKubernetesMountPath),
+		Role:      String("cts"),
+		TokenPath: String(DefaultVaultKubernetesTokenPath),
+	}, c.Auth.Kubernetes)
+	assert.True(t, *c.RenewToken, "token from the login is renewed by default")
+}
+
+func TestVaultConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		i       *VaultConfig
+		isValid bool
+	}{
+		{
+			"nil",
+			nil,
+			true,
+		},
+		{
+			"disabled",
+			&VaultConfig{
+				Enabled: Bool(false),
+				Auth:    &VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{}},
+			},
+			true,
+		},
+		{
+			"token",
+			&VaultConfig{
+				Enabled: Bool(true),
+				Token:   String("token"),
+				Auth:    &VaultAuthConfig{},
+			},
+			true,
+		},
+		{
+			"approle",
+			&VaultConfig{
+				Enabled: Bool(true),
+				Auth: &VaultAuthConfig{
+					AppRole: &VaultAppRoleAuthConfig{RoleID: String("role")},
+				},
+			},
+			true,
+		},
+		{
+			"invalid_auth",
+			&VaultConfig{
+				Enabled: Bool(true),
+				Auth:    &VaultAuthConfig{AppRole: &VaultAppRoleAuthConfig{}},
+			},
+			false,
+		},
+		{
+			"cert",
+			&VaultConfig{
+				Enabled: Bool(true),
+				Auth:    &VaultAuthConfig{Cert: &VaultCertAuthConfig{}},
+				TLS: &TLSConfig{
+					Cert: String("/path/to/cert.pem"),
+					Key:  String("/path/to/key.pem"),
+				},
+			},
+			true,
+		},
+		{
+			"cert_missing_client_key",
+			&VaultConfig{
+				Enabled: Bool(true),
+				Auth:    &VaultAuthConfig{Cert: &VaultCertAuthConfig{}},
+				TLS:     &TLSConfig{Cert: String("/path/to/cert.pem")},
+			},
+			false,
+		},
+		{
+			"auth_with_agent_token_file",
+			&VaultConfig{
+				Enabled: Bool(true),
+				Auth: &VaultAuthConfig{
+					Kubernetes: &VaultKubernetesAuthConfig{Role: String("cts")},
+				},
+				VaultAgentTokenFile: String("/path/to/token"),
+			},
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.i.Validate()
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat"
+)
+
+// consulTokenEnv is the environment variable that gives the Consul token to
+// Terraform for the Consul backend.
+const consulTokenEnv = "CONSUL_HTTP_TOKEN"
+
+// tokenFileInterval is the interval between checks of the Consul token file
+// for changes.
+var tokenFileInterval = 2 * time.Second
+
+// clientSet is the client set for the watcher. It extends the hcat client
+// set to keep the credentials of the clients current without stopping the
+// watches: the token of the Vault client is kept current by the Vault auth
+// method, and the Consul client is replaced when the Consul token file
+// changes. Dependencies fetch with the Consul client of the client set for
+// each request, so requests after the change use the new token.
+type clientSet struct {
+	*hcat.ClientSet
+
+	// consulInput is the input to create the Consul client with a new token
+	consulInput hcat.ConsulInput
+
+	mu     sync.RWMutex
+	consul *hcat.ClientSet
+
+	vaultAuth *vaultAuth
+
+	readFile func(string) ([]byte, error)
+	setenv   func(string, string) error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newClientSet creates the client set with a Consul client.
+func newClientSet(consul hcat.ConsulInput) (*clientSet, error) {
+	clients := hcat.NewClientSet()
+	if err := clients.AddConsul(consul); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &clientSet{
+		ClientSet:   clients,
+		consulInput: consul,
+		consul:      clients,
+		readFile:    ioutil.ReadFile,
+		setenv:      os.Setenv,
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// Consul returns the current Consul client.
+func (cs *clientSet) Consul() *consulapi.Client {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.consul.Consul()
+}
+
+// Stop stops keeping the credentials current and closes all idle
+// connections of the clients.
+func (cs *clientSet) Stop() {
+	cs.cancel()
+	cs.vaultAuth.stop()
+
+	cs.mu.RLock()
+	if cs.consul != cs.ClientSet {
+		cs.consul.Stop()
+	}
+	cs.mu.RUnlock()
+	cs.ClientSet.Stop()
+}
+
+// setConsulToken replaces the Consul client with a client for the token and
+// gives the token to Terraform.
+func (cs *clientSet) setConsulToken(token string) error {
+	input := cs.consulInput
+	input.Token = token
+
+	clients := hcat.NewClientSet()
+	if err := clients.AddConsul(input); err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	prev := cs.consul
+	cs.consul = clients
+	cs.consulInput = input
+	cs.mu.Unlock()
+
+	// Close idle connections for the previous token. Requests in progress,
+	// like blocking queries, complete with the previous client.
+	if prev != cs.ClientSet {
+		prev.Stop()
+	}
+
+	return cs.setenv(consulTokenEnv, token)
+}
+
+// watchConsulTokenFile gives the token from the Consul token file to
+// Terraform and checks the file for changes to the token until the client set
+// is stopped. An empty or unreadable file leaves the current token in use.
+func (cs *clientSet) watchConsulTokenFile(path string) error {
+	if err := cs.setenv(consulTokenEnv, cs.consulInput.Token); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(tokenFileInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cs.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			content, err := cs.readFile(path)
+			if err != nil {
+				log.Printf("[WARN] (ctrl) unable to read Consul token file: %s", err)
+				continue
+			}
+			token := strings.TrimSpace(string(content))
+			if token == "" || token == cs.consulInput.Token {
+				continue
+			}
+
+			log.Printf("[INFO] (ctrl) Consul token file changed, reloading token")
+			if err := cs.setConsulToken(token); err != nil {
+				log.Printf("[ERR] (ctrl) unable to reload Consul token: %s", err)
+			}
+		}
+	}()
+	return nil
+}
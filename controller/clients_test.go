@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConsulTokenServer is a fake Consul server that records the token of the
+// latest request.
+type testConsulTokenServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	token string
+}
+
+func newTestConsulTokenServer() *testConsulTokenServer {
+	s := &testConsulTokenServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			s.token = r.Header.Get("X-Consul-Token")
+			s.mu.Unlock()
+
+			if r.URL.Path == "/v1/status/leader" {
+				fmt.Fprint(w, `"127.0.0.1:8300"`)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		}))
+	return s
+}
+
+func (s *testConsulTokenServer) requestToken(t *testing.T, cs *clientSet) string {
+	_, _, err := cs.Consul().Catalog().Services(nil)
+	require.NoError(t, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+func TestClientSet_setConsulToken(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestConsulTokenServer()
+	defer ts.Close()
+
+	cs, err := newClientSet(hcat.ConsulInput{Address: ts.URL, Token: "token-1"})
+	require.NoError(t, err)
+	defer cs.Stop()
+
+	env := make(map[string]string)
+	cs.setenv = func(k, v string) error {
+		env[k] = v
+		return nil
+	}
+
+	assert.Equal(t, "token-1", ts.requestToken(t, cs))
+
+	require.NoError(t, cs.setConsulToken("token-2"))
+	assert.Equal(t, "token-2", ts.requestToken(t, cs))
+	assert.Equal(t, "token-2", env[consulTokenEnv])
+}
+
+func TestClientSet_watchConsulTokenFile(t *testing.T) {
+	// Not parallel since the interval to check the token file is changed
+	interval := tokenFileInterval
+	tokenFileInterval = 10 * time.Millisecond
+	defer func() { tokenFileInterval = interval }()
+
+	ts := newTestConsulTokenServer()
+	defer ts.Close()
+
+	cs, err := newClientSet(hcat.ConsulInput{Address: ts.URL, Token: "token-1"})
+	require.NoError(t, err)
+	defer cs.Stop()
+
+	var mu sync.Mutex
+	content := "token-1\n"
+	env := make(map[string]string)
+	cs.readFile = func(string) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return []byte(content), nil
+	}
+	cs.setenv = func(k, v string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		env[k] = v
+		return nil
+	}
+
+	require.NoError(t, cs.watchConsulTokenFile("/path/to/token"))
+	mu.Lock()
+	assert.Equal(t, "token-1", env[consulTokenEnv])
+	content = "token-2\n"
+	mu.Unlock()
+
+	assert.Eventually(t, func() bool {
+		return ts.requestToken(t, cs) == "token-2"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "token-2", env[consulTokenEnv])
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/hashicorp/consul-terraform-sync/config"
 	"github.com/hashicorp/consul-terraform-sync/driver"
+	"github.com/hashicorp/consul-terraform-sync/handler"
 	"github.com/hashicorp/consul-terraform-sync/templates"
 	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
 	"github.com/hashicorp/consul-terraform-sync/templates/tftmpl"
@@ -57,6 +59,10 @@ type baseController struct {
 	units      []unit
 	watcher    templates.Watcher
 	resolver   templates.Resolver
+
+	// providerConfigs are the provider blocks with the rendered dynamic
+	// values, in the order of the terraform_provider configuration
+	providerConfigs []hcltmpl.NamedBlock
 }
 
 func newBaseController(conf *config.Config) (*baseController, error) {
@@ -95,7 +101,10 @@ func (ctrl *baseController) init(ctx context.Context) error {
 
 	// Future: improve by combining tasks into workflows.
 	log.Printf("[INFO] (ctrl) initializing all tasks")
-	tasks := newDriverTasks(ctrl.conf, providerConfigs)
+	tasks, err := newDriverTasks(ctrl.conf, providerConfigs)
+	if err != nil {
+		return err
+	}
 	units := make([]unit, 0, len(tasks))
 
 	for _, task := range tasks {
@@ -106,40 +115,57 @@ func (ctrl *baseController) init(ctx context.Context) error {
 		default:
 		}
 
-		log.Printf("[DEBUG] (ctrl) initializing task %q", task.Name)
-		d, err := ctrl.newDriver(ctrl.conf, task)
+		u, err := ctrl.newUnit(task)
 		if err != nil {
 			return err
 		}
 
-		err = d.InitTask(true)
-		if err != nil {
-			log.Printf("[ERR] (ctrl) error initializing task %q: %s", task.Name, err)
+		// Modules that are not local are installed to be validated, so that
+		// an incompatible module fails on startup like a local module.
+		if err := u.driver.ValidateTask(ctx); err != nil {
+			log.Printf("[ERR] (ctrl) error validating task %q: %s", task.Name, err)
 			return err
 		}
-
-		template, err := newTaskTemplate(task.Name, ctrl.conf, ctrl.fileReader)
-		if err != nil {
-			log.Printf("[ERR] (ctrl) error initializing template "+
-				"for task %q: %s", task.Name, err)
-			return err
-		}
-
-		units = append(units, unit{
-			taskName:  task.Name,
-			template:  template,
-			driver:    d,
-			providers: task.ProviderNames(),
-			services:  task.ServiceNames(),
-			source:    task.Source,
-		})
+		units = append(units, u)
 	}
 	ctrl.units = units
+	ctrl.providerConfigs = providerConfigs
 
 	log.Printf("[INFO] (ctrl) driver initialized")
 	return nil
 }
 
+// newUnit initializes the driver and the template for a task.
+func (ctrl *baseController) newUnit(task driver.Task) (unit, error) {
+	log.Printf("[DEBUG] (ctrl) initializing task %q", task.Name)
+	d, err := ctrl.newDriver(ctrl.conf, task)
+	if err != nil {
+		return unit{}, err
+	}
+
+	err = d.InitTask(true)
+	if err != nil {
+		log.Printf("[ERR] (ctrl) error initializing task %q: %s", task.Name, err)
+		return unit{}, err
+	}
+
+	template, err := newTaskTemplate(task.Name, ctrl.conf, ctrl.fileReader)
+	if err != nil {
+		log.Printf("[ERR] (ctrl) error initializing template "+
+			"for task %q: %s", task.Name, err)
+		return unit{}, err
+	}
+
+	return unit{
+		taskName:  task.Name,
+		template:  template,
+		driver:    d,
+		providers: task.ProviderNames(),
+		services:  task.ServiceNames(),
+		source:    task.Source,
+	}, nil
+}
+
 // loadProviderConfigs loads provider configs and evaluates provider blocks
 // for dynamic values in parallel.
 func (ctrl *baseController) loadProviderConfigs(ctx context.Context) ([]hcltmpl.NamedBlock, error) {
@@ -173,6 +199,114 @@ func (ctrl *baseController) loadProviderConfigs(ctx context.Context) ([]hcltmpl.
 	return providerConfigs, nil
 }
 
+// reloadProviderConfigs renders the dynamic provider configuration again with
+// the latest values of the watcher. The rendered values are part of the tfvars
+// template of a task, so each task that uses a provider with changed values is
+// re-initialized and its template is run again on the next run. The units are
+// only replaced once all tasks are re-initialized, so that a failed task is
+// re-initialized along with the others on the next change. It returns the
+// number of tasks that were re-initialized.
+func (ctrl *baseController) reloadProviderConfigs() (int, error) {
+	providerConfigs := make([]hcltmpl.NamedBlock, len(ctrl.providerConfigs))
+	copy(providerConfigs, ctrl.providerConfigs)
+
+	changed := false
+	for i, conf := range *ctrl.conf.TerraformProviders {
+		if i >= len(providerConfigs) || !hcltmpl.ContainsDynamicTemplate(fmt.Sprint(*conf)) {
+			continue
+		}
+
+		block, complete, err := hcltmpl.RenderDynamicConfig(ctrl.watcher, ctrl.resolver, *conf)
+		if err != nil {
+			return 0, fmt.Errorf("error loading dynamic configuration for "+
+				"provider %q: %s", block.Name, err)
+		}
+		if !complete || variablesEqual(block.Variables, providerConfigs[i].Variables) {
+			continue
+		}
+
+		log.Printf("[INFO] (ctrl) dynamic configuration changed for provider %q", block.Name)
+		providerConfigs[i] = block
+		changed = true
+	}
+	if !changed {
+		return 0, nil
+	}
+
+	tasks, err := newDriverTasks(ctrl.conf, providerConfigs)
+	if err != nil {
+		return 0, err
+	}
+
+	units := make(map[string]unit)
+	for i, t := range *ctrl.conf.Tasks {
+		if !providersChanged(t.Providers, ctrl.providerConfigs, providerConfigs) {
+			continue
+		}
+
+		task := tasks[i]
+		log.Printf("[INFO] (ctrl) re-initializing task %q for changes to "+
+			"provider configuration", task.Name)
+		u, err := ctrl.newUnit(task)
+		if err != nil {
+			return 0, err
+		}
+		units[task.Name] = u
+	}
+
+	for i, prev := range ctrl.units {
+		u, ok := units[prev.taskName]
+		if !ok {
+			continue
+		}
+
+		// The watcher only notifies the first template registered for an ID,
+		// so the template already registered is kept when the content of the
+		// template is unchanged and is marked to run with the new driver.
+		if prev.template != nil && prev.template.ID() == u.template.ID() {
+			u.template = prev.template
+			u.template.Notify(nil)
+		}
+		ctrl.units[i] = u
+	}
+
+	ctrl.providerConfigs = providerConfigs
+	return len(units), nil
+}
+
+// providersChanged checks whether any of the providers by ID resolve to a
+// provider block with different values between the provider configurations.
+func providersChanged(ids []string, prev, next []hcltmpl.NamedBlock) bool {
+	for _, id := range ids {
+		p, err := getProvider(prev, id)
+		if err != nil {
+			return true
+		}
+		n, err := getProvider(next, id)
+		if err != nil {
+			return true
+		}
+		if !variablesEqual(p.Variables, n.Variables) {
+			return true
+		}
+	}
+	return false
+}
+
+// variablesEqual checks whether both variables have the same values.
+func variablesEqual(a, b hcltmpl.Variables) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		other, ok := b[k]
+		if !ok || !v.RawEquals(other) {
+			return false
+		}
+	}
+	return true
+}
+
 // logDepSize logs the watcher dependency size every nth iteration. Set the
 // iterator to a negative value to log each iteration.
 func (ctrl *baseController) logDepSize(n uint, i int64) {
@@ -215,15 +349,16 @@ func newTerraformDriver(conf *config.Config, task driver.Task) (driver.Driver, e
 		WorkingDir:        filepath.Join(*tfConf.WorkingDir, task.Name),
 		Backend:           tfConf.Backend,
 		RequiredProviders: tfConf.RequiredProviders,
+		TFVarsFormat:      config.StringVal(tfConf.TFVarsFormat),
 		ClientType:        *conf.ClientType,
 	})
 }
 
 // newDriverTasks converts user-defined task configurations to the task object
 // used by drivers.
-func newDriverTasks(conf *config.Config, providerConfigs []hcltmpl.NamedBlock) []driver.Task {
+func newDriverTasks(conf *config.Config, providerConfigs []hcltmpl.NamedBlock) ([]driver.Task, error) {
 	if conf == nil {
-		return []driver.Task{}
+		return []driver.Task{}, nil
 	}
 	tasks := make([]driver.Task, len(*conf.Tasks))
 	for i, t := range *conf.Tasks {
@@ -236,7 +371,12 @@ func newDriverTasks(conf *config.Config, providerConfigs []hcltmpl.NamedBlock) [
 		providers := make([]hcltmpl.NamedBlock, len(t.Providers))
 		providerInfo := make(map[string]interface{})
 		for pi, providerID := range t.Providers {
-			providers[pi] = getProvider(providerConfigs, providerID)
+			p, err := getProvider(providerConfigs, providerID)
+			if err != nil {
+				return nil, fmt.Errorf("error configuring providers for task "+
+					"%q: %s", *t.Name, err)
+			}
+			providers[pi] = p
 
 			// This is Terraform specific to pass version and source info for
 			// providers from the required_provider block
@@ -248,19 +388,36 @@ func newDriverTasks(conf *config.Config, providerConfigs []hcltmpl.NamedBlock) [
 			}
 		}
 
+		// Handlers are only explicitly set when configured for the task.
+		// Otherwise the driver detects handlers by the task's providers.
+		var handlers []driver.Handler
+		if t.Handlers.Len() > 0 {
+			handlers = make([]driver.Handler, 0, t.Handlers.Len())
+			for _, h := range *t.Handlers {
+				if !h.Enabled() {
+					continue
+				}
+				handlers = append(handlers, getHandler(providers, h))
+			}
+		}
+
 		tasks[i] = driver.Task{
-			Description:  *t.Description,
-			Name:         *t.Name,
-			Providers:    providers,
-			ProviderInfo: providerInfo,
-			Services:     services,
-			Source:       *t.Source,
-			VarFiles:     t.VarFiles,
-			Version:      *t.Version,
+			Description:        *t.Description,
+			Name:               *t.Name,
+			Handlers:           handlers,
+			Providers:          providers,
+			ProviderInfo:       providerInfo,
+			Services:           services,
+			Connect:            config.BoolVal(t.Connect),
+			Source:             *t.Source,
+			VarFiles:           t.VarFiles,
+			TFVarsTemplate:     config.StringVal(t.TFVarsTemplate),
+			SensitiveVariables: t.SensitiveVariables,
+			Version:            *t.Version,
 		}
 	}
 
-	return tasks
+	return tasks, nil
 }
 
 // newTaskTemplate creates templates to be monitored and rendered.
@@ -269,8 +426,10 @@ func newTaskTemplate(taskName string, conf *config.Config, fileReader func(strin
 		return nil, errors.New("unsupported driver to run tasks")
 	}
 
-	tmplFullpath := filepath.Join(*conf.Driver.Terraform.WorkingDir, taskName, tftmpl.TFVarsTmplFilename)
-	tfvarsFilepath := filepath.Join(*conf.Driver.Terraform.WorkingDir, taskName, tftmpl.TFVarsFilename)
+	tmplFilename, tfvarsFilename := tftmpl.TFVarsFilenames(
+		config.StringVal(conf.Driver.Terraform.TFVarsFormat))
+	tmplFullpath := filepath.Join(*conf.Driver.Terraform.WorkingDir, taskName, tmplFilename)
+	tfvarsFilepath := filepath.Join(*conf.Driver.Terraform.WorkingDir, taskName, tfvarsFilename)
 
 	content, err := fileReader(tmplFullpath)
 	if err != nil {
@@ -297,11 +456,16 @@ func getService(services *config.ServiceConfigs, id string) driver.Service {
 	for _, s := range *services {
 		if *s.ID == id {
 			return driver.Service{
-				Datacenter:  *s.Datacenter,
-				Description: *s.Description,
-				Name:        *s.Name,
-				Namespace:   *s.Namespace,
-				Tag:         *s.Tag,
+				Datacenter:   *s.Datacenter,
+				Description:  *s.Description,
+				Name:         *s.Name,
+				Namespace:    *s.Namespace,
+				Partition:    *s.Partition,
+				Tag:          *s.Tag,
+				Tags:         s.Tags,
+				NodeMeta:     s.NodeMeta,
+				HealthStatus: s.HealthStatus,
+				Filter:       *s.Filter,
 			}
 		}
 	}
@@ -309,6 +473,35 @@ func getService(services *config.ServiceConfigs, id string) driver.Service {
 	return driver.Service{Name: id}
 }
 
+// getHandler is a helper to convert a user-defined handler configuration to
+// a driver handler type. If the task has a provider with the same name as the
+// handler type, the handler configuration is layered over the provider
+// configuration so that the handler can reuse the provider's connection
+// details. Handler options are only read from the handler configuration.
+func getHandler(providers []hcltmpl.NamedBlock, h *config.HandlerConfig) driver.Handler {
+	handlerType := h.Type()
+	conf := make(map[string]interface{})
+	for _, p := range providers {
+		if p.Name != handlerType {
+			continue
+		}
+		for k, v := range handler.TerraformProviderConfig(handlerType, p.RawConfig()) {
+			conf[k] = v
+		}
+		break
+	}
+
+	for k, v := range h.Config() {
+		conf[k] = v
+	}
+
+	return driver.Handler{
+		Type:     handlerType,
+		Config:   conf,
+		PreApply: h.Stage() == config.HandlerStagePreApply,
+	}
+}
+
 func splitProviderID(id string) (string, string) {
 	var name, alias string
 	split := strings.SplitN(id, ".", 2)
@@ -322,11 +515,12 @@ func splitProviderID(id string) (string, string) {
 
 // getProvider is a helper to find and convert a user-defined provider
 // configuration by the provider ID, which is either the provider name
-// or <name>.<alias>. If a provider is not explicitly configured, it
-// assumes the default provider block that is empty.
+// or <name>.<alias>. If a provider referenced by name is not explicitly
+// configured, it assumes the default provider block that is empty. A provider
+// referenced by alias must be configured.
 //
 // terraform_provider "name" { }
-func getProvider(providers []hcltmpl.NamedBlock, id string) hcltmpl.NamedBlock {
+func getProvider(providers []hcltmpl.NamedBlock, id string) (hcltmpl.NamedBlock, error) {
 	name, alias := splitProviderID(id)
 
 	for _, p := range providers {
@@ -336,18 +530,23 @@ func getProvider(providers []hcltmpl.NamedBlock, id string) hcltmpl.NamedBlock {
 		}
 
 		if alias == "" {
-			return p
+			return p, nil
 		}
 
 		// Match by alias
 		a, ok := p.Variables["alias"]
 		if ok && a.AsString() == alias {
-			return p
+			return p, nil
 		}
 	}
 
+	if alias != "" {
+		return hcltmpl.NamedBlock{}, fmt.Errorf("missing terraform_provider "+
+			"configuration for provider %q with alias %q", name, alias)
+	}
+
 	return hcltmpl.NamedBlock{
 		Name:      name,
 		Variables: make(hcltmpl.Variables),
-	}
+	}, nil
 }
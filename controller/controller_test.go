@@ -13,10 +13,13 @@ import (
 	"github.com/hashicorp/consul-terraform-sync/driver"
 	"github.com/hashicorp/consul-terraform-sync/event"
 	mocksD "github.com/hashicorp/consul-terraform-sync/mocks/driver"
+	mocks "github.com/hashicorp/consul-terraform-sync/mocks/templates"
 	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/hashicorp/hcat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestNewControllers(t *testing.T) {
@@ -91,18 +94,20 @@ func TestBaseControllerInit(t *testing.T) {
 	conf := singleTaskConfig()
 
 	cases := []struct {
-		name        string
-		expectError bool
-		initErr     error
-		initTaskErr error
-		fileReader  func(string) ([]byte, error)
-		config      *config.Config
+		name            string
+		expectError     bool
+		initErr         error
+		initTaskErr     error
+		validateTaskErr error
+		fileReader      func(string) ([]byte, error)
+		config          *config.Config
 	}{
 		{
 			"error on driver.InitTask()",
 			true,
 			nil,
 			errors.New("error on driver.InitTask()"),
+			nil,
 			func(string) ([]byte, error) { return []byte{}, nil },
 			conf,
 		},
@@ -111,16 +116,27 @@ func TestBaseControllerInit(t *testing.T) {
 			true,
 			nil,
 			nil,
+			nil,
 			func(string) ([]byte, error) {
 				return []byte{}, errors.New("error on newTaskTemplates()")
 			},
 			conf,
 		},
+		{
+			"error on driver.ValidateTask()",
+			true,
+			nil,
+			nil,
+			errors.New("error on driver.ValidateTask()"),
+			func(string) ([]byte, error) { return []byte{}, nil },
+			conf,
+		},
 		{
 			"happy path",
 			false,
 			nil,
 			nil,
+			nil,
 			func(string) ([]byte, error) { return []byte{}, nil },
 			conf,
 		},
@@ -131,6 +147,7 @@ func TestBaseControllerInit(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			d := new(mocksD.Driver)
 			d.On("InitTask", mock.Anything, mock.Anything).Return(tc.initTaskErr).Once()
+			d.On("ValidateTask", mock.Anything).Return(tc.validateTaskErr).Once()
 
 			baseCtrl := baseController{
 				newDriver: func(*config.Config, driver.Task) (driver.Driver, error) {
@@ -208,9 +225,10 @@ func TestNewDriverTasks(t *testing.T) {
 						"source": "source/providerA",
 					},
 				},
-				Services: []driver.Service{},
-				Source:   "source",
-				VarFiles: []string{},
+				Services:           []driver.Service{},
+				Source:             "source",
+				VarFiles:           []string{},
+				SensitiveVariables: []string{},
 			}},
 		}, {
 			// Fetches correct provider and required_providers blocks from config
@@ -263,9 +281,59 @@ func TestNewDriverTasks(t *testing.T) {
 						"source": "source/providerA",
 					},
 				},
-				Services: []driver.Service{},
-				Source:   "source",
-				VarFiles: []string{},
+				Services:           []driver.Service{},
+				Source:             "source",
+				VarFiles:           []string{},
+				SensitiveVariables: []string{},
+			}},
+		}, {
+			// Converts enabled handler blocks in the order they are configured
+			"handlers",
+			&config.Config{
+				Tasks: &config.TaskConfigs{
+					{
+						Name:   config.String("name"),
+						Source: config.String("source"),
+						Handlers: &config.HandlerConfigs{
+							{"fake-sync": map[string]interface{}{
+								"name": "first",
+							}},
+							{"panos": map[string]interface{}{
+								"enabled": false,
+							}},
+							{"fake-sync": map[string]interface{}{
+								"enabled": true,
+								"name":    "second",
+							}},
+							{"fake-sync": map[string]interface{}{
+								"name":  "veto",
+								"stage": "pre-apply",
+							}},
+						},
+					},
+				},
+			},
+			[]driver.Task{{
+				Name: "name",
+				Handlers: []driver.Handler{
+					{
+						Type:   "fake-sync",
+						Config: map[string]interface{}{"name": "first"},
+					}, {
+						Type:   "fake-sync",
+						Config: map[string]interface{}{"name": "second"},
+					}, {
+						Type:     "fake-sync",
+						Config:   map[string]interface{}{"name": "veto"},
+						PreApply: true,
+					},
+				},
+				Providers:          []hcltmpl.NamedBlock{},
+				ProviderInfo:       map[string]interface{}{},
+				Services:           []driver.Service{},
+				Source:             "source",
+				VarFiles:           []string{},
+				SensitiveVariables: []string{},
 			}},
 		},
 	}
@@ -281,8 +349,326 @@ func TestNewDriverTasks(t *testing.T) {
 				}
 			}
 
-			tasks := newDriverTasks(tc.conf, providerConfigs)
+			tasks, err := newDriverTasks(tc.conf, providerConfigs)
+			require.NoError(t, err)
 			assert.Equal(t, tc.tasks, tasks)
 		})
 	}
+
+	t.Run("missing provider alias", func(t *testing.T) {
+		conf := &config.Config{
+			Tasks: &config.TaskConfigs{{
+				Name:      config.String("name"),
+				Providers: []string{"providerA.west"},
+				Source:    config.String("source"),
+			}},
+		}
+		conf.Finalize()
+
+		providerConfigs := hcltmpl.NewNamedBlocksTest([]map[string]interface{}{{
+			"providerA": map[string]interface{}{"alias": "east"},
+		}})
+		_, err := newDriverTasks(conf, providerConfigs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"providerA" with alias "west"`)
+	})
+}
+
+func TestNewTaskTemplate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		tfvarsFormat *string
+		expected     string
+	}{
+		{
+			"default",
+			nil,
+			"working/task/terraform.tfvars.tmpl",
+		}, {
+			"hcl",
+			config.String("hcl"),
+			"working/task/terraform.tfvars.tmpl",
+		}, {
+			"json",
+			config.String("json"),
+			"working/task/terraform.tfvars.json.tmpl",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := singleTaskConfig()
+			conf.Driver.Terraform.TFVarsFormat = tc.tfvarsFormat
+
+			var actual string
+			fileReader := func(path string) ([]byte, error) {
+				actual = path
+				return []byte{}, nil
+			}
+
+			tmpl, err := newTaskTemplate("task", conf, fileReader)
+			require.NoError(t, err)
+			assert.NotNil(t, tmpl)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGetHandler(t *testing.T) {
+	providers := []hcltmpl.NamedBlock{
+		hcltmpl.NewNamedBlock(map[string]interface{}{
+			"panos": map[string]interface{}{
+				"hostname": "10.10.10.10",
+				"username": "provider-admin",
+				"panorama": true,
+			},
+		}),
+	}
+
+	testCases := []struct {
+		name     string
+		handler  *config.HandlerConfig
+		expected driver.Handler
+	}{
+		{
+			"provider config",
+			&config.HandlerConfig{"panos": map[string]interface{}{}},
+			driver.Handler{
+				Type: "panos",
+				Config: map[string]interface{}{
+					"hostname": "10.10.10.10",
+					"username": "provider-admin",
+				},
+			},
+		}, {
+			"handler config takes precedence",
+			&config.HandlerConfig{"panos": map[string]interface{}{
+				"username": "handler-admin",
+			}},
+			driver.Handler{
+				Type: "panos",
+				Config: map[string]interface{}{
+					"hostname": "10.10.10.10",
+					"username": "handler-admin",
+				},
+			},
+		}, {
+			"handler options",
+			&config.HandlerConfig{"panos": map[string]interface{}{
+				"panorama": true,
+			}},
+			driver.Handler{
+				Type: "panos",
+				Config: map[string]interface{}{
+					"hostname": "10.10.10.10",
+					"username": "provider-admin",
+					"panorama": true,
+				},
+			},
+		}, {
+			"no matching provider",
+			&config.HandlerConfig{"fake-sync": map[string]interface{}{
+				"name": "fake",
+			}},
+			driver.Handler{
+				Type:   "fake-sync",
+				Config: map[string]interface{}{"name": "fake"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := getHandler(providers, tc.handler)
+			assert.Equal(t, tc.expected, h)
+		})
+	}
+}
+
+func TestBaseController_reloadProviderConfigs(t *testing.T) {
+	t.Parallel()
+
+	conf := &config.Config{
+		Driver: &config.DriverConfig{
+			Terraform: &config.TerraformConfig{WorkingDir: config.String("working")},
+		},
+		Tasks: &config.TaskConfigs{
+			{
+				Name:      config.String("task_dynamic"),
+				Providers: []string{"dynamic"},
+				Source:    config.String("source"),
+			}, {
+				Name:      config.String("task_static"),
+				Providers: []string{"static"},
+				Source:    config.String("source"),
+			},
+		},
+		TerraformProviders: &config.TerraformProviderConfigs{
+			{"dynamic": map[string]interface{}{"token": "{{ key \"token\" }}"}},
+			{"static": map[string]interface{}{"attr": "value"}},
+		},
+	}
+	conf.Finalize()
+
+	cases := []struct {
+		name        string
+		event       hcat.ResolveEvent
+		initTaskErr error
+		reloaded    int
+		expectError bool
+	}{
+		{
+			"unchanged",
+			hcat.ResolveEvent{Complete: true, Contents: []byte("old")},
+			nil,
+			0,
+			false,
+		},
+		{
+			"incomplete",
+			hcat.ResolveEvent{Complete: false},
+			nil,
+			0,
+			false,
+		},
+		{
+			"changed",
+			hcat.ResolveEvent{Complete: true, Contents: []byte("new")},
+			nil,
+			1,
+			false,
+		},
+		{
+			"error_init_task",
+			hcat.ResolveEvent{Complete: true, Contents: []byte("new")},
+			errors.New("error"),
+			0,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			providerConfigs := []hcltmpl.NamedBlock{
+				{Name: "dynamic", Variables: hcltmpl.Variables{"token": cty.StringVal("old")}},
+				{Name: "static", Variables: hcltmpl.Variables{"attr": cty.StringVal("value")}},
+			}
+			prevDriver := new(mocksD.Driver)
+			units := []unit{
+				{taskName: "task_dynamic", driver: prevDriver},
+				{taskName: "task_static", driver: prevDriver},
+			}
+
+			r := new(mocks.Resolver)
+			r.On("Run", mock.Anything, mock.Anything).Return(tc.event, nil)
+
+			d := new(mocksD.Driver)
+			d.On("InitTask", true).Return(tc.initTaskErr)
+			var initTasks []driver.Task
+
+			ctrl := baseController{
+				conf: conf,
+				newDriver: func(_ *config.Config, task driver.Task) (driver.Driver, error) {
+					initTasks = append(initTasks, task)
+					return d, nil
+				},
+				fileReader:      func(string) ([]byte, error) { return []byte{}, nil },
+				units:           units,
+				watcher:         new(mocks.Watcher),
+				resolver:        r,
+				providerConfigs: providerConfigs,
+			}
+
+			reloaded, err := ctrl.reloadProviderConfigs()
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.reloaded, reloaded)
+
+			// The static task is not re-initialized
+			assert.Equal(t, prevDriver, ctrl.units[1].driver)
+
+			if tc.reloaded == 0 {
+				assert.Equal(t, prevDriver, ctrl.units[0].driver)
+				assert.Equal(t, providerConfigs, ctrl.providerConfigs)
+				return
+			}
+
+			assert.Equal(t, d, ctrl.units[0].driver)
+			assert.NotNil(t, ctrl.units[0].template)
+			require.Len(t, initTasks, 1)
+			require.Len(t, initTasks[0].Providers, 1)
+			assert.Equal(t, cty.StringVal("new"),
+				initTasks[0].Providers[0].Variables["token"])
+			assert.Equal(t, cty.StringVal("new"),
+				ctrl.providerConfigs[0].Variables["token"])
+		})
+	}
+}
+
+func TestBaseController_reloadProviderConfigs_partialError(t *testing.T) {
+	t.Parallel()
+
+	conf := &config.Config{
+		Driver: &config.DriverConfig{
+			Terraform: &config.TerraformConfig{WorkingDir: config.String("working")},
+		},
+		Tasks: &config.TaskConfigs{
+			{
+				Name:      config.String("task_a"),
+				Providers: []string{"dynamic"},
+				Source:    config.String("source"),
+			}, {
+				Name:      config.String("task_b"),
+				Providers: []string{"dynamic"},
+				Source:    config.String("source"),
+			},
+		},
+		TerraformProviders: &config.TerraformProviderConfigs{
+			{"dynamic": map[string]interface{}{"token": "{{ key \"token\" }}"}},
+		},
+	}
+	conf.Finalize()
+
+	providerConfigs := []hcltmpl.NamedBlock{
+		{Name: "dynamic", Variables: hcltmpl.Variables{"token": cty.StringVal("old")}},
+	}
+	prevDriver := new(mocksD.Driver)
+	units := []unit{
+		{taskName: "task_a", driver: prevDriver},
+		{taskName: "task_b", driver: prevDriver},
+	}
+
+	r := new(mocks.Resolver)
+	r.On("Run", mock.Anything, mock.Anything).
+		Return(hcat.ResolveEvent{Complete: true, Contents: []byte("new")}, nil)
+
+	d := new(mocksD.Driver)
+	d.On("InitTask", true).Return(nil).Once()
+	d.On("InitTask", true).Return(errors.New("error")).Once()
+
+	ctrl := baseController{
+		conf: conf,
+		newDriver: func(*config.Config, driver.Task) (driver.Driver, error) {
+			return d, nil
+		},
+		fileReader:      func(string) ([]byte, error) { return []byte{}, nil },
+		units:           units,
+		watcher:         new(mocks.Watcher),
+		resolver:        r,
+		providerConfigs: providerConfigs,
+	}
+
+	// None of the tasks are replaced so that both are re-initialized on the
+	// next change
+	reloaded, err := ctrl.reloadProviderConfigs()
+	assert.Error(t, err)
+	assert.Equal(t, 0, reloaded)
+	assert.Equal(t, prevDriver, ctrl.units[0].driver)
+	assert.Equal(t, prevDriver, ctrl.units[1].driver)
+	assert.Equal(t, providerConfigs, ctrl.providerConfigs)
 }
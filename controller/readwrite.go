@@ -72,6 +72,16 @@ func (rw *ReadWrite) Run(ctx context.Context) error {
 			return ctx.Err()
 		}
 
+		// Re-initialize tasks for changes to dynamic provider configuration
+		// before checking the tasks for changes to run.
+		reloaded, err := rw.reloadProviderConfigs()
+		if err != nil {
+			log.Printf("[ERR] (ctrl) error reloading provider configuration: %s", err)
+		}
+		if reloaded > 0 {
+			rw.setTemplateBufferPeriods()
+		}
+
 		for err := range rw.runUnits(ctx) {
 			// aggregate error collector for runUnits, just logs everything for now
 			log.Printf("[ERR] (ctrl) %s", err)
@@ -198,11 +208,17 @@ func (rw *ReadWrite) checkApply(ctx context.Context, u unit, retry bool) (bool,
 
 		d := u.driver
 		log.Printf("[INFO] (ctrl) executing task %s", taskName)
+		// Record the handler results of the last attempt on the event
+		applyTask := func(ctx context.Context) error {
+			var err error
+			ev.Handlers, err = d.ApplyTask(ctx)
+			return err
+		}
 		if retry {
 			desc := fmt.Sprintf("ApplyTask %s", taskName)
-			storedErr = rw.retry.Do(ctx, d.ApplyTask, desc)
+			storedErr = rw.retry.Do(ctx, applyTask, desc)
 		} else {
-			storedErr = d.ApplyTask(ctx)
+			storedErr = applyTask(ctx)
 		}
 		if storedErr != nil {
 			return false, fmt.Errorf("could not apply changes for task %s: %s",
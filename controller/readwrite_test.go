@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -13,10 +16,12 @@ import (
 	"github.com/hashicorp/consul-terraform-sync/handler"
 	mocksD "github.com/hashicorp/consul-terraform-sync/mocks/driver"
 	mocks "github.com/hashicorp/consul-terraform-sync/mocks/templates"
+	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
 	"github.com/hashicorp/hcat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestReadWrite_CheckApply(t *testing.T) {
@@ -89,7 +94,7 @@ func TestReadWrite_CheckApply(t *testing.T) {
 				Return(hcat.ResolveEvent{Complete: true}, tc.resolverRunErr)
 
 			d := new(mocksD.Driver)
-			d.On("ApplyTask", mock.Anything).Return(tc.applyTaskErr)
+			d.On("ApplyTask", mock.Anything).Return(nil, tc.applyTaskErr)
 
 			controller := ReadWrite{
 				baseController: &baseController{
@@ -141,7 +146,7 @@ func TestReadWrite_CheckApply_Store(t *testing.T) {
 			Return(hcat.ResolveEvent{Complete: true}, nil)
 
 		d := new(mocksD.Driver)
-		d.On("ApplyTask", mock.Anything).Return(nil)
+		d.On("ApplyTask", mock.Anything).Return(nil, nil)
 
 		controller := ReadWrite{
 			baseController: &baseController{
@@ -168,6 +173,40 @@ func TestReadWrite_CheckApply_Store(t *testing.T) {
 	})
 }
 
+func TestReadWrite_CheckApply_HandlerResults(t *testing.T) {
+	t.Run("apply-ok-handler-failed", func(t *testing.T) {
+		tmpl := new(mocks.Template)
+		tmpl.On("Render", mock.Anything).Return(hcat.RenderResult{}, nil)
+
+		r := new(mocks.Resolver)
+		r.On("Run", mock.Anything, mock.Anything).
+			Return(hcat.ResolveEvent{Complete: true}, nil)
+
+		commitErr := errors.New("commit error")
+		results := []event.HandlerResult{
+			event.NewHandlerResult("panos", handler.StagePostApply, time.Second, commitErr),
+		}
+		d := new(mocksD.Driver)
+		d.On("ApplyTask", mock.Anything).Return(results, commitErr)
+
+		controller := ReadWrite{
+			baseController: &baseController{
+				resolver: r,
+			},
+			store: event.NewStore(),
+		}
+
+		u := unit{taskName: "task_a", template: tmpl, driver: d}
+		_, err := controller.checkApply(context.Background(), u, false)
+		assert.Error(t, err)
+
+		events := controller.store.Read("task_a")["task_a"]
+		require.Equal(t, 1, len(events))
+		assert.Equal(t, results, events[0].Handlers)
+		assert.Equal(t, event.OutcomeHandlerFailed, events[0].Outcome)
+	})
+}
+
 func TestOnce(t *testing.T) {
 	t.Run("init-wraps-units", func(t *testing.T) {
 		conf := singleTaskConfig()
@@ -194,7 +233,8 @@ func TestOnce(t *testing.T) {
 
 		d := new(mocksD.Driver)
 		d.On("InitTask", mock.Anything).Return(nil).Once()
-		d.On("ApplyTask", mock.Anything).Return(nil).Once()
+		d.On("ValidateTask", mock.Anything).Return(nil).Once()
+		d.On("ApplyTask", mock.Anything).Return(nil, nil).Once()
 
 		rw := &ReadWrite{
 			baseController: &baseController{
@@ -258,8 +298,8 @@ func TestReadWriteUnits(t *testing.T) {
 	t.Run("simple-success", func(t *testing.T) {
 		d := new(mocksD.Driver)
 		d.On("InitWork", mock.Anything).Return(nil)
-		d.On("ApplyTask", mock.Anything).Return(nil)
-		d.On("ApplyTask", mock.Anything).Return(fmt.Errorf("test"))
+		d.On("ApplyTask", mock.Anything).Return(nil, nil)
+		d.On("ApplyTask", mock.Anything).Return(nil, fmt.Errorf("test"))
 
 		u := unit{taskName: "foo", template: tmpl, driver: d}
 		controller := ReadWrite{
@@ -282,7 +322,7 @@ func TestReadWriteUnits(t *testing.T) {
 	t.Run("apply-error", func(t *testing.T) {
 		d := new(mocksD.Driver)
 		d.On("InitWork", mock.Anything).Return(nil)
-		d.On("ApplyTask", mock.Anything).Return(fmt.Errorf("test"))
+		d.On("ApplyTask", mock.Anything).Return(nil, fmt.Errorf("test"))
 
 		u := unit{taskName: "foo", template: tmpl, driver: d}
 		controller := ReadWrite{
@@ -385,3 +425,117 @@ func singleTaskConfig() *config.Config {
 	c.Finalize()
 	return c
 }
+
+func TestReadWrite_reloadProviderConfigs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ctrl-reload-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "task"), 0755))
+
+	conf := &config.Config{
+		Driver: &config.DriverConfig{
+			Terraform: &config.TerraformConfig{WorkingDir: config.String(dir)},
+		},
+		Tasks: &config.TaskConfigs{{
+			Name:      config.String("task"),
+			Providers: []string{"dynamic"},
+			Source:    config.String("source"),
+		}},
+		TerraformProviders: &config.TerraformProviderConfigs{
+			{"dynamic": map[string]interface{}{"token": "{{ key \"token\" }}"}},
+		},
+	}
+	conf.Finalize()
+
+	fileReader := func(string) ([]byte, error) { return []byte("services"), nil }
+	tmpl, err := newTaskTemplate("task", conf, fileReader)
+	require.NoError(t, err)
+
+	prevDriver := new(mocksD.Driver)
+	prevDriver.On("ApplyTask", mock.Anything).Return(nil, nil).Once()
+
+	d := new(mocksD.Driver)
+	d.On("InitTask", true).Return(nil).Once()
+
+	r := new(mocks.Resolver)
+	r.On("Run", mock.Anything, mock.Anything).
+		Return(hcat.ResolveEvent{Complete: true, Contents: []byte("new")}, nil)
+
+	w := newTestWatcher()
+	rw := &ReadWrite{
+		baseController: &baseController{
+			conf:       conf,
+			fileReader: fileReader,
+			newDriver: func(*config.Config, driver.Task) (driver.Driver, error) {
+				return d, nil
+			},
+			units:    []unit{{taskName: "task", template: tmpl, driver: prevDriver}},
+			watcher:  w,
+			resolver: hcat.NewResolver(),
+			providerConfigs: []hcltmpl.NamedBlock{
+				{Name: "dynamic", Variables: hcltmpl.Variables{"token": cty.StringVal("old")}},
+			},
+		},
+		store: event.NewStore(),
+	}
+	ctx := context.Background()
+
+	complete, err := rw.checkApply(ctx, rw.units[0], false)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	prevDriver.AssertExpectations(t)
+
+	rw.resolver = r
+	reloaded, err := rw.reloadProviderConfigs()
+	require.NoError(t, err)
+	assert.Equal(t, 1, reloaded)
+	assert.Equal(t, d, rw.units[0].driver)
+	assert.Equal(t, tmpl, rw.units[0].template)
+	rw.resolver = hcat.NewResolver()
+
+	// The task runs with the new driver after it is re-initialized
+	d.On("ApplyTask", mock.Anything).Return(nil, nil).Once()
+	_, err = rw.checkApply(ctx, rw.units[0], false)
+	require.NoError(t, err)
+	d.AssertExpectations(t)
+
+	// The task does not run again without changes
+	complete, err = rw.checkApply(ctx, rw.units[0], false)
+	require.NoError(t, err)
+	assert.False(t, complete)
+
+	// The task runs for changes to its dependencies after the reload
+	w.change()
+	d.On("ApplyTask", mock.Anything).Return(nil, nil).Once()
+	complete, err = rw.checkApply(ctx, rw.units[0], false)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	d.AssertExpectations(t)
+}
+
+// testWatcher tracks the first template to complete for an ID like the hcat
+// watcher and notifies the tracked templates of a change.
+type testWatcher struct {
+	*mocks.Watcher
+	notifiers map[string]hcat.Notifier
+}
+
+func newTestWatcher() *testWatcher {
+	w := new(mocks.Watcher)
+	w.On("Buffer", mock.Anything).Return(false)
+	w.On("Recaller", mock.Anything).Return(nil)
+	return &testWatcher{Watcher: w, notifiers: make(map[string]hcat.Notifier)}
+}
+
+func (w *testWatcher) Complete(n hcat.Notifier) bool {
+	if _, ok := w.notifiers[n.ID()]; !ok {
+		w.notifiers[n.ID()] = n
+	}
+	return true
+}
+
+func (w *testWatcher) change() {
+	for _, n := range w.notifiers {
+		n.Notify(nil)
+	}
+}
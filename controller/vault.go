@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul-terraform-sync/config"
+	"github.com/hashicorp/consul-terraform-sync/retry"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// vaultLoginRetry is the number of times to retry logging in to Vault before
+// the failure is logged and logging in starts over.
+const vaultLoginRetry uint = 5
+
+// vaultAuth logs in to Vault with the configured auth method and keeps the
+// token of the Vault client used by the watcher current. The token is renewed
+// until it can no longer be renewed, or until it expires when renewal is
+// disabled, and then vaultAuth logs in again.
+type vaultAuth struct {
+	// client is the Vault client of the watcher that the token is set for
+	client *vaultapi.Client
+
+	// loginClient is a copy of the client without a token to log in with
+	loginClient *vaultapi.Client
+
+	conf     *config.VaultAuthConfig
+	renew    bool
+	retry    retry.Retry
+	readFile func(string) ([]byte, error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newVaultAuth returns a vaultAuth to log in and set the token for the Vault
+// client.
+func newVaultAuth(client *vaultapi.Client, conf *config.VaultConfig) (*vaultAuth, error) {
+	loginClient, err := client.Clone()
+	if err != nil {
+		return nil, err
+	}
+	loginClient.ClearToken()
+	if ns := config.StringVal(conf.Namespace); ns != "" {
+		loginClient.SetNamespace(ns)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &vaultAuth{
+		client:      client,
+		loginClient: loginClient,
+		conf:        conf.Auth,
+		renew:       config.BoolVal(conf.RenewToken),
+		retry:       retry.NewRetry(vaultLoginRetry, time.Now().UnixNano()),
+		readFile:    ioutil.ReadFile,
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// start logs in to Vault and starts keeping the token current in the
+// background until stopped.
+func (a *vaultAuth) start() error {
+	secret, err := a.login()
+	if err != nil {
+		return err
+	}
+	go a.run(secret)
+	return nil
+}
+
+// stop stops keeping the token current.
+func (a *vaultAuth) stop() {
+	if a != nil {
+		a.cancel()
+	}
+}
+
+// run watches the lifetime of the token from a login, renewing it when
+// enabled, and logs in again once the token can no longer be used.
+func (a *vaultAuth) run(secret *vaultapi.Secret) {
+	for {
+		if err := a.watch(secret); err != nil {
+			log.Printf("[WARN] (ctrl) stopped renewing the Vault token: %s", err)
+		}
+
+		select {
+		case <-a.ctx.Done():
+			return
+		default:
+		}
+
+		log.Printf("[INFO] (ctrl) logging in to Vault again before the token expires")
+		login := func(context.Context) error {
+			var err error
+			secret, err = a.login()
+			return err
+		}
+		for {
+			err := a.retry.Do(a.ctx, login, "log in to Vault")
+			if err == nil {
+				break
+			}
+			if a.ctx.Err() != nil {
+				return
+			}
+			log.Printf("[ERR] (ctrl) unable to log in to Vault: %s", err)
+		}
+	}
+}
+
+// watch blocks until the token of the secret can no longer be renewed, it
+// expires, or vaultAuth is stopped. A token without a lease never expires and
+// blocks until stopped.
+func (a *vaultAuth) watch(secret *vaultapi.Secret) error {
+	if secret.Auth.LeaseDuration == 0 {
+		<-a.ctx.Done()
+		return nil
+	}
+
+	behavior := vaultapi.RenewBehaviorIgnoreErrors
+	if !a.renew {
+		behavior = vaultapi.RenewBehaviorRenewDisabled
+	}
+	watcher, err := a.client.NewLifetimeWatcher(&vaultapi.LifetimeWatcherInput{
+		Secret:        secret,
+		RenewBehavior: behavior,
+	})
+	if err != nil {
+		return err
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case err := <-watcher.DoneCh():
+			return err
+		case renewal := <-watcher.RenewCh():
+			log.Printf("[DEBUG] (ctrl) renewed Vault token at %s",
+				renewal.RenewedAt.Format(time.RFC3339))
+		case <-a.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// login logs in to Vault with the auth method and sets the token for the
+// client.
+func (a *vaultAuth) login() (*vaultapi.Secret, error) {
+	method := a.conf.Method()
+	path, data, err := a.loginRequest()
+	if err != nil {
+		return nil, fmt.Errorf("error logging in to Vault with %s: %s", method, err)
+	}
+
+	secret, err := a.loginClient.Logical().Write(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("error logging in to Vault with %s: %s", method, err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("error logging in to Vault with %s: no token "+
+			"returned", method)
+	}
+
+	log.Printf("[INFO] (ctrl) logged in to Vault with %s, token expires in %s",
+		method, time.Duration(secret.Auth.LeaseDuration)*time.Second)
+	a.client.SetToken(secret.Auth.ClientToken)
+	return secret, nil
+}
+
+// loginRequest returns the path and data to log in with the auth method.
+func (a *vaultAuth) loginRequest() (string, map[string]interface{}, error) {
+	var mountPath string
+	data := make(map[string]interface{})
+
+	switch a.conf.Method() {
+	case "approle":
+		c := a.conf.AppRole
+		mountPath = config.StringVal(c.MountPath)
+		data["role_id"] = config.StringVal(c.RoleID)
+		if secretID := config.StringVal(c.SecretID); secretID != "" {
+			data["secret_id"] = secretID
+		}
+
+	case "cert":
+		c := a.conf.Cert
+		mountPath = config.StringVal(c.MountPath)
+		if name := config.StringVal(c.Name); name != "" {
+			data["name"] = name
+		}
+
+	case "kubernetes":
+		c := a.conf.Kubernetes
+		mountPath = config.StringVal(c.MountPath)
+		jwt, err := a.readFile(config.StringVal(c.TokenPath))
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to read service account token: %s", err)
+		}
+		data["role"] = config.StringVal(c.Role)
+		data["jwt"] = strings.TrimSpace(string(jwt))
+
+	default:
+		return "", nil, fmt.Errorf("no auth method configured")
+	}
+
+	return fmt.Sprintf("auth/%s/login", strings.Trim(mountPath, "/")), data, nil
+}
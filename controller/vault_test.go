@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-terraform-sync/config"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultAuth_loginRequest(t *testing.T) {
+	t.Parallel()
+
+	readFile := func(path string) ([]byte, error) {
+		if path == "/token" {
+			return []byte("jwt\n"), nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	cases := []struct {
+		name        string
+		conf        *config.VaultAuthConfig
+		path        string
+		data        map[string]interface{}
+		expectError bool
+	}{
+		{
+			"approle",
+			&config.VaultAuthConfig{AppRole: &config.VaultAppRoleAuthConfig{
+				MountPath: config.String("approle"),
+				RoleID:    config.String("role-id"),
+				SecretID:  config.String("secret-id"),
+			}},
+			"auth/approle/login",
+			map[string]interface{}{"role_id": "role-id", "secret_id": "secret-id"},
+			false,
+		},
+		{
+			"approle_without_secret_id",
+			&config.VaultAuthConfig{AppRole: &config.VaultAppRoleAuthConfig{
+				MountPath: config.String("/custom/approle/"),
+				RoleID:    config.String("role-id"),
+				SecretID:  config.String(""),
+			}},
+			"auth/custom/approle/login",
+			map[string]interface{}{"role_id": "role-id"},
+			false,
+		},
+		{
+			"cert",
+			&config.VaultAuthConfig{Cert: &config.VaultCertAuthConfig{
+				MountPath: config.String("cert"),
+				Name:      config.String("web"),
+			}},
+			"auth/cert/login",
+			map[string]interface{}{"name": "web"},
+			false,
+		},
+		{
+			"kubernetes",
+			&config.VaultAuthConfig{Kubernetes: &config.VaultKubernetesAuthConfig{
+				MountPath: config.String("kubernetes"),
+				Role:      config.String("cts"),
+				TokenPath: config.String("/token"),
+			}},
+			"auth/kubernetes/login",
+			map[string]interface{}{"role": "cts", "jwt": "jwt"},
+			false,
+		},
+		{
+			"kubernetes_missing_token",
+			&config.VaultAuthConfig{Kubernetes: &config.VaultKubernetesAuthConfig{
+				MountPath: config.String("kubernetes"),
+				Role:      config.String("cts"),
+				TokenPath: config.String("/missing"),
+			}},
+			"",
+			nil,
+			true,
+		},
+		{
+			"no_method",
+			&config.VaultAuthConfig{},
+			"",
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &vaultAuth{conf: tc.conf, readFile: readFile}
+			path, data, err := a.loginRequest()
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.path, path)
+			assert.Equal(t, tc.data, data)
+		})
+	}
+}
+
+func TestVaultAuth_login(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		status      int
+		response    string
+		expectError bool
+	}{
+		{
+			"happy_path",
+			http.StatusOK,
+			`{"auth": {"client_token": "new-token", "lease_duration": 60, "renewable": true}}`,
+			false,
+		},
+		{
+			"error",
+			http.StatusBadRequest,
+			`{"errors": ["invalid role ID"]}`,
+			true,
+		},
+		{
+			"no_token",
+			http.StatusOK,
+			`{"data": {}}`,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "/v1/auth/approle/login", r.URL.Path)
+					assert.Empty(t, r.Header.Get("X-Vault-Token"),
+						"login should not send the previous token")
+					w.WriteHeader(tc.status)
+					fmt.Fprint(w, tc.response)
+				}))
+			defer ts.Close()
+
+			a := testVaultAuth(t, ts.URL, &config.VaultAppRoleAuthConfig{
+				MountPath: config.String("approle"),
+				RoleID:    config.String("role-id"),
+			})
+			a.client.SetToken("old-token")
+
+			secret, err := a.login()
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Equal(t, "old-token", a.client.Token())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "new-token", secret.Auth.ClientToken)
+			assert.Equal(t, "new-token", a.client.Token())
+		})
+	}
+}
+
+func TestVaultAuth_run(t *testing.T) {
+	t.Parallel()
+
+	// Logs in with short non-renewable tokens to require logging in again
+	var mu sync.Mutex
+	logins := 0
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			logins++
+			token := fmt.Sprintf("token-%d", logins)
+			mu.Unlock()
+
+			json.NewEncoder(w).Encode(&vaultapi.Secret{Auth: &vaultapi.SecretAuth{
+				ClientToken:   token,
+				LeaseDuration: 1,
+				Renewable:     false,
+			}})
+		}))
+	defer ts.Close()
+
+	a := testVaultAuth(t, ts.URL, &config.VaultAppRoleAuthConfig{
+		MountPath: config.String("approle"),
+		RoleID:    config.String("role-id"),
+	})
+	require.NoError(t, a.start())
+	defer a.stop()
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return logins >= 2
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.NotEqual(t, "token-1", a.client.Token())
+}
+
+func testVaultAuth(t *testing.T, addr string, approle *config.VaultAppRoleAuthConfig) *vaultAuth {
+	vaultConf := vaultapi.DefaultConfig()
+	vaultConf.Address = addr
+	client, err := vaultapi.NewClient(vaultConf)
+	require.NoError(t, err)
+
+	a, err := newVaultAuth(client, &config.VaultConfig{
+		Auth:       &config.VaultAuthConfig{AppRole: approle},
+		Namespace:  config.String(""),
+		RenewToken: config.Bool(true),
+	})
+	require.NoError(t, err)
+	return a
+}
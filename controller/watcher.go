@@ -6,7 +6,8 @@ import (
 )
 
 // newWatcher initializes a new hcat Watcher with a Consul client and optional
-// Vault client if configured.
+// Vault client if configured. The credentials of the clients are kept current
+// until the watcher is stopped.
 func newWatcher(conf *config.Config) (*hcat.Watcher, error) {
 	consulConf := conf.Consul
 	transport := hcat.TransportInput{
@@ -36,12 +37,20 @@ func newWatcher(conf *config.Config) (*hcat.Watcher, error) {
 		Transport:    transport,
 	}
 
-	clients := hcat.NewClientSet()
-	if err := clients.AddConsul(consul); err != nil {
+	clients, err := newClientSet(consul)
+	if err != nil {
 		return nil, err
 	}
 
+	if tokenFile := *consulConf.TokenFile; tokenFile != "" {
+		if err := clients.watchConsulTokenFile(tokenFile); err != nil {
+			clients.Stop()
+			return nil, err
+		}
+	}
+
 	if err := setVaultClient(clients, conf); err != nil {
+		clients.Stop()
 		return nil, err
 	}
 
@@ -51,17 +60,27 @@ func newWatcher(conf *config.Config) (*hcat.Watcher, error) {
 	}), nil
 }
 
-func setVaultClient(clients *hcat.ClientSet, conf *config.Config) error {
+func setVaultClient(clients *clientSet, conf *config.Config) error {
 	vaultConf := conf.Vault
 	if !*vaultConf.Enabled {
 		return nil
 	}
 
+	// The token from logging in with an auth method is set after the client
+	// is created
+	useAuth := vaultConf.Auth.Method() != ""
+	token := *vaultConf.Token
+	unwrapToken := *vaultConf.UnwrapToken
+	if useAuth {
+		token = ""
+		unwrapToken = false
+	}
+
 	vault := hcat.VaultInput{
 		Address:     *vaultConf.Address,
 		Namespace:   *vaultConf.Namespace,
-		Token:       *vaultConf.Token,
-		UnwrapToken: *vaultConf.UnwrapToken,
+		Token:       token,
+		UnwrapToken: unwrapToken,
 		Transport: hcat.TransportInput{
 			SSLEnabled: *vaultConf.TLS.Enabled,
 			SSLVerify:  *vaultConf.TLS.Verify,
@@ -81,5 +100,20 @@ func setVaultClient(clients *hcat.ClientSet, conf *config.Config) error {
 		},
 	}
 
-	return clients.AddVault(vault)
+	if err := clients.AddVault(vault); err != nil {
+		return err
+	}
+	if !useAuth {
+		return nil
+	}
+
+	auth, err := newVaultAuth(clients.Vault(), vaultConf)
+	if err != nil {
+		return err
+	}
+	if err := auth.start(); err != nil {
+		return err
+	}
+	clients.vaultAuth = auth
+	return nil
 }
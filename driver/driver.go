@@ -1,6 +1,10 @@
 package driver
 
-import "context"
+import (
+	"context"
+
+	"github.com/hashicorp/consul-terraform-sync/event"
+)
 
 //go:generate mockery --name=Driver --filename=driver.go  --output=../mocks/driver
 
@@ -10,12 +14,17 @@ type Driver interface {
 	// InitTask initializes the task that the driver executes
 	InitTask(force bool) error
 
+	// ValidateTask validates the task against the module of the task, and
+	// installs the module if needed to do so
+	ValidateTask(ctx context.Context) error
+
 	// InspectTask inspects for any differences pertaining to the task between
 	// the state of Consul and network infrastructure
 	InspectTask(ctx context.Context) error
 
-	// ApplyTask applies change for the task managed by the driver
-	ApplyTask(ctx context.Context) error
+	// ApplyTask applies change for the task managed by the driver and returns
+	// the results of any handlers executed for the task
+	ApplyTask(ctx context.Context) ([]event.HandlerResult, error)
 
 	// Version returns the version of the driver.
 	Version() string
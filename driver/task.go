@@ -10,23 +10,42 @@ import (
 
 // Service contains service configuration information
 type Service struct {
-	Datacenter  string
-	Description string
-	Name        string
-	Namespace   string
-	Tag         string
+	Datacenter   string
+	Description  string
+	Name         string
+	Namespace    string
+	Partition    string
+	Tag          string
+	Tags         []string
+	NodeMeta     map[string]string
+	HealthStatus []string
+	Filter       string
+}
+
+// Handler contains handler configuration information
+type Handler struct {
+	Type     string
+	Config   map[string]interface{}
+	PreApply bool // executes before apply and can veto it
 }
 
 // Task contains task configuration information
 type Task struct {
-	Description  string
-	Name         string
-	Providers    []hcltmpl.NamedBlock   // task.providers config info
-	ProviderInfo map[string]interface{} // driver.required_provider config info
-	Services     []Service
-	Source       string
-	VarFiles     []string
-	Version      string
+	Description    string
+	Name           string
+	Handlers       []Handler              // task.handler config info
+	Providers      []hcltmpl.NamedBlock   // task.providers config info
+	ProviderInfo   map[string]interface{} // driver.required_provider config info
+	Services       []Service
+	Connect        bool // query Connect-capable service instances
+	Source         string
+	VarFiles       []string
+	TFVarsTemplate string // path to the user-supplied tfvars template
+	Version        string
+
+	// SensitiveVariables are the names of the variables from the variable
+	// files and the tfvars template that are declared as sensitive
+	SensitiveVariables []string
 }
 
 // ProviderNames returns the list of providers that the task has configured
@@ -49,12 +68,13 @@ func (t *Task) ServiceNames() []string {
 
 // clientConfig configures a driver client for a task
 type clientConfig struct {
-	task       Task
-	clientType string
-	log        bool
-	persistLog bool
-	path       string
-	workingDir string
+	task           Task
+	clientType     string
+	log            bool
+	persistLog     bool
+	path           string
+	workingDir     string
+	tfvarsFilename string
 }
 
 // newClient initializes a specific type of client given a task
@@ -78,12 +98,13 @@ func newClient(conf *clientConfig) (client.Client, error) {
 	default:
 		log.Printf("[TRACE] (driver) creating terraform cli client for task '%s'", taskName)
 		c, err = client.NewTerraformCLI(&client.TerraformCLIConfig{
-			Log:        conf.log,
-			PersistLog: conf.persistLog,
-			ExecPath:   conf.path,
-			WorkingDir: conf.workingDir,
-			Workspace:  taskName,
-			VarFiles:   conf.task.VarFiles,
+			Log:            conf.log,
+			PersistLog:     conf.persistLog,
+			ExecPath:       conf.path,
+			WorkingDir:     conf.workingDir,
+			Workspace:      taskName,
+			VarFiles:       conf.task.VarFiles,
+			TFVarsFilename: conf.tfvarsFilename,
 		})
 	}
 
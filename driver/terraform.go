@@ -2,14 +2,22 @@ package driver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/consul-terraform-sync/client"
+	"github.com/hashicorp/consul-terraform-sync/event"
 	"github.com/hashicorp/consul-terraform-sync/handler"
 	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
 	"github.com/hashicorp/consul-terraform-sync/templates/tftmpl"
+	goVersion "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 )
 
@@ -38,10 +46,17 @@ type Terraform struct {
 	task              Task
 	backend           map[string]interface{}
 	requiredProviders map[string]interface{}
+	tfvarsFormat      string
+
+	// variables and templateVariables are the module variables assigned by
+	// the variable files and the tfvars template of the task.
+	variables         hcltmpl.Variables
+	templateVariables []string
 
 	workingDir string
 	client     client.Client
-	postApply  handler.Handler
+	preApply   []taskHandler
+	postApply  []taskHandler
 
 	inited bool
 }
@@ -56,6 +71,10 @@ type TerraformConfig struct {
 	Backend           map[string]interface{}
 	RequiredProviders map[string]interface{}
 
+	// TFVarsFormat is the format of the generated input variables file.
+	// Defaults to HCL when empty.
+	TFVarsFormat string
+
 	// empty/unknown string will default to TerraformCLI client
 	ClientType string
 }
@@ -70,20 +89,22 @@ func NewTerraform(config *TerraformConfig) (*Terraform, error) {
 		}
 	}
 
+	_, tfvarsFilename := tftmpl.TFVarsFilenames(config.TFVarsFormat)
 	tfClient, err := newClient(&clientConfig{
-		task:       config.Task,
-		clientType: config.ClientType,
-		log:        config.Log,
-		persistLog: config.PersistLog,
-		path:       config.Path,
-		workingDir: config.WorkingDir,
+		task:           config.Task,
+		clientType:     config.ClientType,
+		log:            config.Log,
+		persistLog:     config.PersistLog,
+		path:           config.Path,
+		workingDir:     config.WorkingDir,
+		tfvarsFilename: tfvarsFilename,
 	})
 	if err != nil {
 		log.Printf("[ERR] (driver.terraform) init client type '%s' error: %s", config.ClientType, err)
 		return nil, err
 	}
 
-	handler, err := getTerraformHandlers(config.Task)
+	preApply, postApply, err := getTerraformHandlers(config.Task)
 	if err != nil {
 		return nil, err
 	}
@@ -92,9 +113,11 @@ func NewTerraform(config *TerraformConfig) (*Terraform, error) {
 		task:              config.Task,
 		backend:           config.Backend,
 		requiredProviders: config.RequiredProviders,
+		tfvarsFormat:      config.TFVarsFormat,
 		workingDir:        config.WorkingDir,
 		client:            tfClient,
-		postApply:         handler,
+		preApply:          preApply,
+		postApply:         postApply,
 	}, nil
 }
 
@@ -111,11 +134,17 @@ func (tf *Terraform) InitTask(force bool) error {
 	services := make([]tftmpl.Service, len(task.Services))
 	for i, s := range task.Services {
 		services[i] = tftmpl.Service{
-			Datacenter:  s.Datacenter,
-			Description: s.Description,
-			Name:        s.Name,
-			Namespace:   s.Namespace,
-			Tag:         s.Tag,
+			Datacenter:   s.Datacenter,
+			Description:  s.Description,
+			Name:         s.Name,
+			Namespace:    s.Namespace,
+			Partition:    s.Partition,
+			Tag:          s.Tag,
+			Tags:         s.Tags,
+			NodeMeta:     s.NodeMeta,
+			HealthStatus: s.HealthStatus,
+			Filter:       s.Filter,
+			Connect:      task.Connect,
 		}
 	}
 
@@ -136,9 +165,50 @@ func (tf *Terraform) InitTask(force bool) error {
 		}
 	}
 
+	var tmplContent []byte
+	var tmplVars []string
+	if task.TFVarsTemplate != "" {
+		if tf.tfvarsFormat == tftmpl.TFVarsFormatJSON {
+			err := fmt.Errorf("tfvars template is not supported with the %q "+
+				"tfvars format for task '%s'", tf.tfvarsFormat, task.Name)
+			log.Printf("[ERR] (driver.terraform) %s", err)
+			return err
+		}
+
+		var err error
+		tmplContent, tmplVars, err = loadTFVarsTemplate(task, vars)
+		if err != nil {
+			log.Printf("[ERR] (driver.terraform) error loading tfvars template "+
+				"for task '%s': %s", task.Name, err)
+			return err
+		}
+	}
+
+	sensitive, err := sensitiveVariables(task, vars, tmplContent, tmplVars)
+	if err != nil {
+		log.Printf("[ERR] (driver.terraform) %s", err)
+		return err
+	}
+
+	tf.variables = vars
+	tf.templateVariables = tmplVars
+
+	// Local modules are validated before the root module is created. Other
+	// modules are validated once installed by Terraform init.
+	if isLocalModuleSource(task.Source) {
+		dir := task.Source
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(tf.workingDir, dir)
+		}
+		if err := tf.validateModule(dir); err != nil {
+			log.Printf("[ERR] (driver.terraform) %s", err)
+			return err
+		}
+	}
+
 	input := tftmpl.RootModuleInputData{
 		Backend:      tf.backend,
-		Providers:    task.Providers,
+		Providers:    sensitiveProviders(task),
 		ProviderInfo: task.ProviderInfo,
 		Services:     services,
 		Task: tftmpl.Task{
@@ -147,7 +217,11 @@ func (tf *Terraform) InitTask(force bool) error {
 			Source:      task.Source,
 			Version:     task.Version,
 		},
-		Variables: vars,
+		Variables:          vars,
+		TFVarsTemplate:     tmplContent,
+		TemplateVariables:  tmplVars,
+		SensitiveVariables: sensitive,
+		TFVarsFormat:       tf.tfvarsFormat,
 	}
 	input.Init()
 
@@ -158,6 +232,145 @@ func (tf *Terraform) InitTask(force bool) error {
 	return nil
 }
 
+// sensitiveVariables returns the names of the module variables of the task to
+// declare as sensitive. Variables configured as sensitive must be assigned by
+// the variable files or the tfvars template of the task. Variables assigned by
+// a tfvars template that fetches secrets from Vault are also sensitive.
+func sensitiveVariables(task Task, vars hcltmpl.Variables, tmplContent []byte,
+	tmplVars []string) ([]string, error) {
+	assigned := make(map[string]bool, len(vars)+len(tmplVars))
+	for name := range vars {
+		assigned[name] = true
+	}
+	for _, name := range tmplVars {
+		assigned[name] = true
+	}
+
+	supported := supportsSensitiveVariables()
+	sensitive := make(map[string]bool)
+	for _, name := range task.SensitiveVariables {
+		if !assigned[name] {
+			return nil, fmt.Errorf("sensitive variable %q for task '%s' is not "+
+				"assigned by the variable files or the tfvars template",
+				name, task.Name)
+		}
+		if !supported {
+			return nil, fmt.Errorf("sensitive variables for task '%s' require "+
+				"Terraform 0.14 or later, found %s", task.Name, TerraformVersion)
+		}
+		sensitive[name] = true
+	}
+
+	if len(tmplVars) > 0 && hcltmpl.ContainsVaultSecret(string(tmplContent)) {
+		if supported {
+			for _, name := range tmplVars {
+				sensitive[name] = true
+			}
+		} else {
+			log.Printf("[WARN] (driver.terraform) variables from Vault for task "+
+				"'%s' are not declared as sensitive with Terraform %s. Sensitive "+
+				"variables require Terraform 0.14 or later", task.Name,
+				TerraformVersion)
+		}
+	}
+
+	names := make([]string, 0, len(sensitive))
+	for name := range sensitive {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// sensitiveProviders returns the provider blocks of the task. Providers with
+// values from Vault are no longer declared as sensitive when the version of
+// Terraform does not support sensitive variables.
+func sensitiveProviders(task Task) []hcltmpl.NamedBlock {
+	if supportsSensitiveVariables() {
+		return task.Providers
+	}
+
+	providers := make([]hcltmpl.NamedBlock, len(task.Providers))
+	for i, p := range task.Providers {
+		if p.Sensitive {
+			log.Printf("[WARN] (driver.terraform) provider %q for task '%s' "+
+				"with values from Vault is not declared as sensitive with "+
+				"Terraform %s. Sensitive variables require Terraform 0.14 or "+
+				"later", p.Name, task.Name, TerraformVersion)
+			p.Sensitive = false
+		}
+		providers[i] = p
+	}
+	return providers
+}
+
+// supportsSensitiveVariables reports whether the version of Terraform supports
+// declaring variables as sensitive. Unknown versions are assumed to support
+// sensitive variables.
+func supportsSensitiveVariables() bool {
+	if TerraformVersion == "" {
+		return true
+	}
+
+	v, err := goVersion.NewVersion(TerraformVersion)
+	if err != nil {
+		return true
+	}
+
+	segments := v.Segments()
+	return segments[0] > 0 || segments[1] >= 14
+}
+
+// loadTFVarsTemplate reads and validates the user-supplied tfvars template of
+// the task. The variables assigned by the template cannot conflict with the
+// variables generated for the task or loaded from variable files.
+func loadTFVarsTemplate(task Task, vars hcltmpl.Variables) ([]byte, []string, error) {
+	content, err := ioutil.ReadFile(task.TFVarsTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names, err := tftmpl.ParseTFVarsTemplate(content, task.TFVarsTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reserved := map[string]bool{"services": true}
+	for _, p := range task.Providers {
+		reserved[p.Name] = true
+	}
+	for _, name := range names {
+		if reserved[name] {
+			return nil, nil, fmt.Errorf("variable %q assigned by tfvars template "+
+				"%s is reserved for the task", name, task.TFVarsTemplate)
+		}
+		if _, ok := vars[name]; ok {
+			return nil, nil, fmt.Errorf("variable %q assigned by tfvars template "+
+				"%s is also assigned by a variable file", name, task.TFVarsTemplate)
+		}
+	}
+
+	return content, names, nil
+}
+
+// ValidateTask validates the variables of the task against the module of the
+// task. Local modules are validated by InitTask. Other modules are installed
+// by initializing the workspace and then validated, so that an incompatible
+// module fails when the task is initialized instead of on the first plan or
+// apply.
+func (tf *Terraform) ValidateTask(ctx context.Context) error {
+	if isLocalModuleSource(tf.task.Source) {
+		return nil
+	}
+
+	if err := tf.init(ctx); err != nil {
+		log.Printf("[ERR] (driver.terraform) error initializing workspace "+
+			"to validate module for '%s'", tf.task.Name)
+		return err
+	}
+	return nil
+}
+
 // InspectTask inspects for any differences pertaining to the task between
 // the state of Consul and network infrastructure using the Terraform plan command
 func (tf *Terraform) InspectTask(ctx context.Context) error {
@@ -177,30 +390,49 @@ func (tf *Terraform) InspectTask(ctx context.Context) error {
 	return nil
 }
 
-// ApplyTask applies the task changes.
-func (tf *Terraform) ApplyTask(ctx context.Context) error {
+// ApplyTask applies the task changes and returns the results of the handlers
+// executed for the task. If any pre-apply handler returns an error, the apply
+// is cancelled and a *handler.VetoError is returned.
+func (tf *Terraform) ApplyTask(ctx context.Context) ([]event.HandlerResult, error) {
 	taskName := tf.task.Name
 
+	var results []event.HandlerResult
+	if len(tf.preApply) > 0 {
+		log.Printf("[TRACE] (driver.terraform) pre-apply out-of-band actions "+
+			"for '%s'", taskName)
+		preResults, err := runHandlers(ctx, tf.taskInfo(handler.StagePreApply),
+			tf.preApply)
+		results = append(results, preResults...)
+		if err != nil {
+			log.Printf("[WARN] (driver.terraform) apply vetoed for '%s': %s",
+				taskName, err)
+			return results, &handler.VetoError{Err: err}
+		}
+	}
+
 	if err := tf.init(ctx); err != nil {
 		log.Printf("[ERR] (driver.terraform) error initializing workspace, "+
 			"skipping apply for '%s'", taskName)
-		return err
+		return results, err
 	}
 
 	log.Printf("[TRACE] (driver.terraform) apply '%s'", taskName)
 	if err := tf.client.Apply(ctx); err != nil {
-		return errors.Wrap(err, fmt.Sprintf("error tf-apply for '%s'", taskName))
+		return results, errors.Wrap(err, fmt.Sprintf("error tf-apply for '%s'", taskName))
 	}
 
-	if tf.postApply != nil {
+	if len(tf.postApply) > 0 {
 		log.Printf("[TRACE] (driver.terraform) post-apply out-of-band actions "+
 			"for '%s'", taskName)
-		if err := tf.postApply.Do(nil); err != nil {
-			return err
+		postResults, err := runHandlers(ctx, tf.taskInfo(handler.StagePostApply),
+			tf.postApply)
+		results = append(results, postResults...)
+		if err != nil {
+			return results, err
 		}
 	}
 
-	return nil
+	return results, nil
 }
 
 // init initializes the Terraform workspace if needed
@@ -217,18 +449,170 @@ func (tf *Terraform) init(ctx context.Context) error {
 	if err := tf.client.Init(ctx); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("error tf-init for '%s'", taskName))
 	}
+
+	if !isLocalModuleSource(tf.task.Source) {
+		if err := tf.validateInstalledModule(); err != nil {
+			log.Printf("[ERR] (driver.terraform) %s", err)
+			return err
+		}
+	}
+
 	tf.inited = true
 	return nil
 }
 
-// getTerraformHandlers returns the first handler in a chain of handlers
-// for a Terraform driver.
-//
-// Returned handler may be nil even if returned err is nil. This happens when
-// no providers have a handler.
-func getTerraformHandlers(task Task) (handler.Handler, error) {
-	counter := 0
-	var next handler.Handler
+// validateInstalledModule validates the variables of the task against the
+// module of the task installed by Terraform init. Validation is skipped when
+// the modules manifest does not exist, like for clients that do not run
+// Terraform.
+func (tf *Terraform) validateInstalledModule() error {
+	manifestPath := filepath.Join(tf.workingDir, ".terraform", "modules",
+		"modules.json")
+	content, err := ioutil.ReadFile(manifestPath)
+	if os.IsNotExist(err) {
+		log.Printf("[DEBUG] (driver.terraform) modules manifest not found, "+
+			"skipping module validation for '%s'", tf.task.Name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var manifest moduleManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return fmt.Errorf("unable to decode modules manifest %s: %s",
+			manifestPath, err)
+	}
+
+	for _, m := range manifest.Modules {
+		if m.Key != tf.task.Name {
+			continue
+		}
+		dir := m.Dir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(tf.workingDir, dir)
+		}
+		return tf.validateModule(dir)
+	}
+
+	return fmt.Errorf("module for task '%s' not found in modules manifest %s",
+		tf.task.Name, manifestPath)
+}
+
+// validateModule validates the variables of the task against the input
+// variables declared by the module within the directory.
+func (tf *Terraform) validateModule(dir string) error {
+	inputs, err := tftmpl.LoadModuleInputs(dir)
+	if err != nil {
+		return fmt.Errorf("unable to inspect module %q for task '%s': %s",
+			tf.task.Source, tf.task.Name, err)
+	}
+
+	err = tftmpl.ValidateModuleInputs(inputs, tf.variables, tf.templateVariables)
+	if err != nil {
+		return fmt.Errorf("module %q for task '%s': %s", tf.task.Source,
+			tf.task.Name, err)
+	}
+	return nil
+}
+
+// moduleManifest is the manifest of the modules installed by Terraform init.
+// The module directories are relative to the root module.
+type moduleManifest struct {
+	Modules []struct {
+		Key string `json:"Key"`
+		Dir string `json:"Dir"`
+	} `json:"Modules"`
+}
+
+// isLocalModuleSource reports whether the module source is a local path. Local
+// paths must begin with ./ or ../ to be recognized by Terraform.
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// taskHandler is a handler for a task and the name its results are recorded
+// by.
+type taskHandler struct {
+	name    string
+	handler handler.Handler
+}
+
+// taskInfo returns the info of the task for the handlers of the stage.
+func (tf *Terraform) taskInfo(stage string) handler.TaskInfo {
+	return handler.TaskInfo{
+		Name:        tf.task.Name,
+		Description: tf.task.Description,
+		Source:      tf.task.Source,
+		Version:     tf.task.Version,
+		Providers:   tf.task.ProviderNames(),
+		Services:    tf.task.ServiceNames(),
+		Stage:       stage,
+	}
+}
+
+// runHandlers executes the handlers of a stage in order and records the result
+// of each handler. The handlers are given the task info with the context. All
+// handlers are executed regardless of errors, and the returned error is the
+// aggregate of the handler errors.
+func runHandlers(ctx context.Context, info handler.TaskInfo, handlers []taskHandler) ([]event.HandlerResult, error) {
+	ctx = handler.WithTaskInfo(ctx, info)
+
+	var errs error
+	results := make([]event.HandlerResult, len(handlers))
+	for i, th := range handlers {
+		start := time.Now()
+		err := th.handler.Do(ctx, nil)
+		results[i] = event.NewHandlerResult(th.name, info.Stage, time.Since(start), err)
+
+		if err == nil {
+			continue
+		}
+		if errs == nil {
+			errs = err
+		} else {
+			errs = errors.Wrap(errs, err.Error())
+		}
+	}
+	return results, errs
+}
+
+// getTerraformHandlers returns the pre-apply and post-apply handlers for a
+// Terraform driver in the order they execute. The handlers are built from the
+// handlers configured for the task. If the task has no handlers configured,
+// the post-apply handlers are detected by the task's providers.
+func getTerraformHandlers(task Task) ([]taskHandler, []taskHandler, error) {
+	if task.Handlers == nil {
+		postApply, err := getProviderHandlers(task)
+		return nil, postApply, err
+	}
+
+	var preApply, postApply []taskHandler
+	for _, hc := range task.Handlers {
+		h, err := handler.NewHandler(hc.Type, hc.Config)
+		if err != nil {
+			log.Printf(
+				"[ERR] (driver.terraform) could not initialize handler "+
+					"'%s': %s", hc.Type, err)
+			return nil, nil, err
+		}
+		log.Printf("[INFO] (driver.terraform) retrieved handler '%s'", hc.Type)
+
+		th := taskHandler{name: hc.Type, handler: h}
+		if hc.PreApply {
+			preApply = append(preApply, th)
+		} else {
+			postApply = append(postApply, th)
+		}
+	}
+	log.Printf("[INFO] (driver.terraform) retrieved %d configured handlers for task '%s'",
+		len(task.Handlers), task.Name)
+	return preApply, postApply, nil
+}
+
+// getProviderHandlers returns the handlers detected by the providers of the
+// task. Handlers execute in the reverse order of the providers.
+func getProviderHandlers(task Task) ([]taskHandler, error) {
+	var handlers []taskHandler
 	for _, p := range task.Providers {
 		h, err := handler.TerraformProviderHandler(p.Name, p.RawConfig())
 		if err != nil {
@@ -238,14 +622,12 @@ func getTerraformHandlers(task Task) (handler.Handler, error) {
 			return nil, err
 		}
 		if h != nil {
-			counter++
 			log.Printf(
 				"[INFO] (driver.terraform) retrieved handler for provider '%s'", p.Name)
-			h.SetNext(next)
-			next = h
+			handlers = append([]taskHandler{{name: p.Name, handler: h}}, handlers...)
 		}
 	}
 	log.Printf("[INFO] (driver.terraform) retrieved %d Terraform handlers for task '%s'",
-		counter, task.Name)
-	return next, nil
+		len(handlers), task.Name)
+	return handlers, nil
 }
@@ -3,12 +3,20 @@ package driver
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/consul-terraform-sync/handler"
 	mocks "github.com/hashicorp/consul-terraform-sync/mocks/client"
 	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/hashicorp/consul-terraform-sync/templates/tftmpl"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestApplyTask(t *testing.T) {
@@ -17,15 +25,19 @@ func TestApplyTask(t *testing.T) {
 	cases := []struct {
 		name        string
 		expectError bool
+		expectVeto  bool
 		inited      bool
 		initReturn  error
 		applyReturn error
-		postApply   handler.Handler
+		preApply    []taskHandler
+		postApply   []taskHandler
 	}{
 		{
-			"happy path - no post-apply handler",
+			"happy path - no handlers",
 			false,
 			false,
+			false,
+			nil,
 			nil,
 			nil,
 			nil,
@@ -34,41 +46,71 @@ func TestApplyTask(t *testing.T) {
 			"happy path - post-apply handler",
 			false,
 			false,
+			false,
+			nil,
 			nil,
 			nil,
 			testHandler(false),
 		},
+		{
+			"happy path - pre-apply handler",
+			false,
+			false,
+			false,
+			nil,
+			nil,
+			testHandler(false),
+			testHandler(false),
+		},
 		{
 			"already inited",
 			false,
+			false,
 			true,
 			nil,
 			nil,
 			nil,
+			nil,
 		},
 		{
 			"error on init",
 			true,
 			false,
+			false,
 			errors.New("init error"),
 			nil,
 			nil,
+			nil,
 		},
 		{
 			"error on apply",
 			true,
 			false,
+			false,
 			nil,
 			errors.New("apply error"),
 			nil,
+			nil,
 		},
 		{
 			"error on post-apply handler",
 			true,
 			false,
+			false,
+			nil,
+			nil,
+			nil,
+			testHandler(true),
+		},
+		{
+			"veto by pre-apply handler",
+			true,
+			true,
+			false,
 			nil,
 			nil,
 			testHandler(true),
+			testHandler(false),
 		},
 	}
 	ctx := context.Background()
@@ -81,15 +123,23 @@ func TestApplyTask(t *testing.T) {
 			tf := &Terraform{
 				task:      Task{Name: "ApplyTaskTest"},
 				client:    c,
+				preApply:  tc.preApply,
 				postApply: tc.postApply,
 				inited:    tc.inited,
 			}
 
-			err := tf.ApplyTask(ctx)
+			results, err := tf.ApplyTask(ctx)
 			if !tc.expectError {
 				assert.NoError(t, err)
-			} else {
-				assert.Error(t, err)
+				assert.Len(t, results, len(tc.preApply)+len(tc.postApply))
+				return
+			}
+			assert.Error(t, err)
+
+			var vetoErr *handler.VetoError
+			assert.Equal(t, tc.expectVeto, errors.As(err, &vetoErr))
+			if tc.expectVeto {
+				c.AssertNotCalled(t, "Apply", ctx)
 			}
 		})
 	}
@@ -97,21 +147,24 @@ func TestApplyTask(t *testing.T) {
 
 func TestGetTerraformHandlers(t *testing.T) {
 	cases := []struct {
-		name        string
-		expectError bool
-		nilHandler  bool
-		task        Task
+		name         string
+		expectError  bool
+		nilPreApply  bool
+		nilPostApply bool
+		task         Task
 	}{
 		{
 			"no provider",
 			false,
 			true,
+			true,
 			Task{},
 		},
 		{
 			"provider without handler (no error)",
 			true,
 			true,
+			true,
 			Task{
 				Providers: []hcltmpl.NamedBlock{
 					hcltmpl.NewNamedBlock(map[string]interface{}{
@@ -125,6 +178,7 @@ func TestGetTerraformHandlers(t *testing.T) {
 			"provider without handler (no error)",
 			false,
 			true,
+			true,
 			Task{
 				Providers: []hcltmpl.NamedBlock{
 					hcltmpl.NewNamedBlock(map[string]interface{}{
@@ -135,6 +189,7 @@ func TestGetTerraformHandlers(t *testing.T) {
 		{
 			"happy path - provider with handler",
 			false,
+			true,
 			false,
 			Task{
 				Providers: []hcltmpl.NamedBlock{
@@ -145,32 +200,540 @@ func TestGetTerraformHandlers(t *testing.T) {
 					})},
 			},
 		},
+		{
+			"configured handlers override provider handlers",
+			false,
+			true,
+			true,
+			Task{
+				Handlers: []Handler{},
+				Providers: []hcltmpl.NamedBlock{
+					hcltmpl.NewNamedBlock(map[string]interface{}{
+						handler.TerraformProviderFake: map[string]interface{}{
+							"name": "provider",
+						},
+					})},
+			},
+		},
+		{
+			"happy path - configured post-apply handlers",
+			false,
+			true,
+			false,
+			Task{
+				Handlers: []Handler{
+					{
+						Type:   handler.TerraformProviderFake,
+						Config: map[string]interface{}{"name": "first"},
+					}, {
+						Type:   handler.TerraformProviderFake,
+						Config: map[string]interface{}{"name": "second"},
+					},
+				},
+			},
+		},
+		{
+			"happy path - configured pre-apply handler",
+			false,
+			false,
+			true,
+			Task{
+				Handlers: []Handler{
+					{
+						Type:     handler.TerraformProviderFake,
+						Config:   map[string]interface{}{"name": "veto"},
+						PreApply: true,
+					},
+				},
+			},
+		},
+		{
+			"unsupported configured handler",
+			true,
+			true,
+			true,
+			Task{
+				Handlers: []Handler{
+					{Type: "unsupported", Config: map[string]interface{}{}},
+				},
+			},
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			h, err := getTerraformHandlers(tc.task)
+			preApply, postApply, err := getTerraformHandlers(tc.task)
 			if tc.expectError {
 				assert.Error(t, err)
 				return
 			}
 			assert.NoError(t, err)
-			if tc.nilHandler {
-				assert.Nil(t, h)
-				return
-			}
-			assert.NotNil(t, h)
+			assert.Equal(t, tc.nilPreApply, len(preApply) == 0)
+			assert.Equal(t, tc.nilPostApply, len(postApply) == 0)
 		})
 	}
 }
 
+func TestRunHandlers(t *testing.T) {
+	t.Parallel()
+
+	handlers := append(testHandler(false), testHandler(true)...)
+	handlers = append(handlers, testHandler(false)...)
+
+	info := handler.TaskInfo{Name: "task", Stage: handler.StagePostApply}
+	results, err := runHandlers(context.Background(), info, handlers)
+	assert.Error(t, err)
+	assert.Len(t, results, 3)
+	for i, success := range []bool{true, false, true} {
+		assert.Equal(t, handler.StagePostApply, results[i].Stage)
+		assert.Equal(t, success, results[i].Success)
+		assert.Equal(t, success, results[i].Error == nil)
+	}
+}
+
+func TestApplyTask_preApplyTaskInfo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := new(mocks.Client)
+	c.On("Init", ctx).Return(nil).Once()
+	c.On("Apply", ctx).Return(nil).Once()
+
+	// The pre-apply handler vetoes applies of tasks with the web service
+	veto := &taskInfoHandler{veto: "web"}
+	tf := &Terraform{
+		task: Task{
+			Name:      "task",
+			Source:    "namespace/module/provider",
+			Providers: []hcltmpl.NamedBlock{{Name: "local"}},
+			Services:  []Service{{Name: "api"}},
+		},
+		client:   c,
+		preApply: []taskHandler{{name: "veto", handler: veto}},
+	}
+
+	_, err := tf.ApplyTask(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, handler.TaskInfo{
+		Name:      "task",
+		Source:    "namespace/module/provider",
+		Providers: []string{"local"},
+		Services:  []string{"api"},
+		Stage:     handler.StagePreApply,
+	}, veto.info)
+
+	tf.task.Services = append(tf.task.Services, Service{Name: "web"})
+	_, err = tf.ApplyTask(ctx)
+	var vetoErr *handler.VetoError
+	assert.True(t, errors.As(err, &vetoErr))
+	c.AssertExpectations(t)
+}
+
+// taskInfoHandler is a handler that records the task info it is executed
+// with, and returns an error for tasks with the service to veto.
+type taskInfoHandler struct {
+	veto string
+	info handler.TaskInfo
+}
+
+func (h *taskInfoHandler) Do(ctx context.Context, prevErr error) error {
+	h.info, _ = handler.TaskInfoFromContext(ctx)
+	for _, s := range h.info.Services {
+		if s == h.veto {
+			return fmt.Errorf("service %s not allowed", s)
+		}
+	}
+	return prevErr
+}
+
+func (h *taskInfoHandler) SetNext(handler.Handler) {}
+
 // testHandler returns a fake handler that can return an error or not on Do()
-func testHandler(err bool) handler.Handler {
+func testHandler(err bool) []taskHandler {
 	config := map[string]interface{}{
 		"name": "1",
 		"err":  err,
 	}
 
 	h, _ := handler.NewFake(config)
-	return h
+	return []taskHandler{{name: handler.TerraformProviderFake, handler: h}}
+}
+
+func TestLoadTFVarsTemplate(t *testing.T) {
+	cases := []struct {
+		name        string
+		content     string
+		vars        hcltmpl.Variables
+		expected    []string
+		expectError bool
+	}{
+		{
+			"happy path",
+			`ports = [{{ range service "api" }}{{ .Port }},{{ end }}]`,
+			hcltmpl.Variables{"num": cty.NumberIntVal(1)},
+			[]string{"ports"},
+			false,
+		}, {
+			"invalid template",
+			`ports = [{{ range service "api" }}]`,
+			nil,
+			nil,
+			true,
+		}, {
+			"reserved services variable",
+			`services = {}`,
+			nil,
+			nil,
+			true,
+		}, {
+			"reserved provider variable",
+			`providerA = {}`,
+			nil,
+			nil,
+			true,
+		}, {
+			"conflicts with variable files",
+			`num = 2`,
+			hcltmpl.Variables{"num": cty.NumberIntVal(1)},
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "tfvars-template-")
+			require.NoError(t, err)
+			defer os.Remove(f.Name())
+			_, err = f.WriteString(tc.content)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			task := Task{
+				Name:           "task",
+				TFVarsTemplate: f.Name(),
+				Providers: []hcltmpl.NamedBlock{hcltmpl.NewNamedBlockTest(
+					map[string]interface{}{
+						"providerA": map[string]interface{}{},
+					})},
+			}
+			content, names, err := loadTFVarsTemplate(task, tc.vars)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.content, string(content))
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		task := Task{Name: "task", TFVarsTemplate: "does/not/exist.tmpl"}
+		_, _, err := loadTFVarsTemplate(task, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestInitTask_TFVarsFormat(t *testing.T) {
+	// A tfvars template is rendered along with the generated HCL and is not
+	// supported for the JSON tfvars format
+	tf := &Terraform{
+		task: Task{
+			Name:           "task",
+			TFVarsTemplate: "template.tfvars.tmpl",
+		},
+		tfvarsFormat: tftmpl.TFVarsFormatJSON,
+	}
+	err := tf.InitTask(false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported")
+}
+
+func TestValidateInstalledModule(t *testing.T) {
+	module := `variable "services" {}
+variable "count" { type = number }
+`
+	cases := []struct {
+		name        string
+		manifest    string
+		vars        hcltmpl.Variables
+		expectError bool
+	}{
+		{
+			"no manifest",
+			"",
+			nil,
+			false,
+		}, {
+			"valid",
+			`{"Modules":[{"Key":"","Dir":"."},{"Key":"task","Dir":"module"}]}`,
+			hcltmpl.Variables{"count": cty.NumberIntVal(1)},
+			false,
+		}, {
+			"invalid variables",
+			`{"Modules":[{"Key":"task","Dir":"module"}]}`,
+			hcltmpl.Variables{"typo": cty.NumberIntVal(1)},
+			true,
+		}, {
+			"module not in manifest",
+			`{"Modules":[{"Key":"","Dir":"."}]}`,
+			hcltmpl.Variables{"count": cty.NumberIntVal(1)},
+			true,
+		}, {
+			"invalid manifest",
+			`{"Modules":`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "driver-")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			moduleDir := filepath.Join(dir, "module")
+			require.NoError(t, os.Mkdir(moduleDir, 0755))
+			err = ioutil.WriteFile(filepath.Join(moduleDir, "variables.tf"),
+				[]byte(module), 0644)
+			require.NoError(t, err)
+
+			if tc.manifest != "" {
+				manifestDir := filepath.Join(dir, ".terraform", "modules")
+				require.NoError(t, os.MkdirAll(manifestDir, 0755))
+				err = ioutil.WriteFile(filepath.Join(manifestDir, "modules.json"),
+					[]byte(tc.manifest), 0644)
+				require.NoError(t, err)
+			}
+
+			tf := &Terraform{
+				task:       Task{Name: "task", Source: "namespace/module/provider"},
+				variables:  tc.vars,
+				workingDir: dir,
+			}
+			err = tf.validateInstalledModule()
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateTask(t *testing.T) {
+	t.Run("local module", func(t *testing.T) {
+		c := new(mocks.Client)
+		tf := &Terraform{
+			task:   Task{Name: "task", Source: "./module"},
+			client: c,
+		}
+		assert.NoError(t, tf.ValidateTask(context.Background()))
+		c.AssertNotCalled(t, "Init", mock.Anything)
+	})
+
+	t.Run("remote module", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "driver-")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		moduleDir := filepath.Join(dir, ".terraform", "modules", "task")
+		require.NoError(t, os.MkdirAll(moduleDir, 0755))
+		err = ioutil.WriteFile(filepath.Join(moduleDir, "variables.tf"),
+			[]byte(`variable "services" {}`), 0644)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(filepath.Join(dir, ".terraform", "modules", "modules.json"),
+			[]byte(`{"Modules":[{"Key":"task","Dir":".terraform/modules/task"}]}`), 0644)
+		require.NoError(t, err)
+
+		c := new(mocks.Client)
+		c.On("Init", mock.Anything).Return(nil).Once()
+		tf := &Terraform{
+			task:       Task{Name: "task", Source: "namespace/module/provider"},
+			client:     c,
+			workingDir: dir,
+			variables:  hcltmpl.Variables{"count": cty.NumberIntVal(1)},
+		}
+		err = tf.ValidateTask(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `variable "count" is not declared`)
+		c.AssertExpectations(t)
+	})
+
+	t.Run("init error", func(t *testing.T) {
+		c := new(mocks.Client)
+		c.On("Init", mock.Anything).Return(errors.New("init error")).Once()
+		tf := &Terraform{
+			task:   Task{Name: "task", Source: "namespace/module/provider"},
+			client: c,
+		}
+		assert.Error(t, tf.ValidateTask(context.Background()))
+		c.AssertExpectations(t)
+	})
+}
+
+func TestInitTask_localModule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "driver-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	moduleDir := filepath.Join(dir, "module")
+	require.NoError(t, os.Mkdir(moduleDir, 0755))
+	err = ioutil.WriteFile(filepath.Join(moduleDir, "variables.tf"),
+		[]byte(`variable "services" {}`), 0644)
+	require.NoError(t, err)
+
+	varFile := filepath.Join(dir, "task.tfvars")
+	err = ioutil.WriteFile(varFile, []byte(`count = 1`), 0644)
+	require.NoError(t, err)
+
+	workingDir := filepath.Join(dir, "task")
+	require.NoError(t, os.Mkdir(workingDir, 0755))
+
+	t.Run("valid", func(t *testing.T) {
+		tf := &Terraform{
+			task:       Task{Name: "task", Source: "../module"},
+			workingDir: workingDir,
+		}
+		assert.NoError(t, tf.InitTask(true))
+	})
+
+	t.Run("undeclared variable", func(t *testing.T) {
+		tf := &Terraform{
+			task: Task{
+				Name:     "task",
+				Source:   "../module",
+				VarFiles: []string{varFile},
+			},
+			workingDir: workingDir,
+		}
+		err := tf.InitTask(true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `variable "count" is not declared`)
+	})
+}
+
+func TestSensitiveVariables(t *testing.T) {
+	vars := hcltmpl.Variables{
+		"password": cty.StringVal("secret"),
+		"username": cty.StringVal("admin"),
+	}
+	vaultTmpl := []byte(`token = "{{ with secret "secret/token" }}{{ .Data.value }}{{ end }}"`)
+
+	cases := []struct {
+		name        string
+		version     string
+		sensitive   []string
+		tmplContent []byte
+		tmplVars    []string
+		expected    []string
+		expectError bool
+	}{
+		{
+			"none",
+			"",
+			nil,
+			nil,
+			nil,
+			[]string{},
+			false,
+		}, {
+			"configured",
+			"0.14.0",
+			[]string{"password", "token"},
+			[]byte(`token = "abc"`),
+			[]string{"token"},
+			[]string{"password", "token"},
+			false,
+		}, {
+			"vault template",
+			"",
+			[]string{"password"},
+			vaultTmpl,
+			[]string{"token"},
+			[]string{"password", "token"},
+			false,
+		}, {
+			"unassigned",
+			"0.14.0",
+			[]string{"dne"},
+			nil,
+			nil,
+			nil,
+			true,
+		}, {
+			"unsupported version",
+			"0.13.5",
+			[]string{"password"},
+			nil,
+			nil,
+			nil,
+			true,
+		}, {
+			"vault template unsupported version",
+			"0.13.5",
+			nil,
+			vaultTmpl,
+			[]string{"token"},
+			[]string{},
+			false,
+		},
+	}
+
+	defer func(v string) { TerraformVersion = v }(TerraformVersion)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			TerraformVersion = tc.version
+			task := Task{Name: "task", SensitiveVariables: tc.sensitive}
+			actual, err := sensitiveVariables(task, vars, tc.tmplContent, tc.tmplVars)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSensitiveProviders(t *testing.T) {
+	providers := []hcltmpl.NamedBlock{
+		{Name: "vault", Sensitive: true},
+		{Name: "static"},
+	}
+	task := Task{Name: "task", Providers: providers}
+
+	defer func(v string) { TerraformVersion = v }(TerraformVersion)
+
+	TerraformVersion = "0.14.2"
+	assert.Equal(t, providers, sensitiveProviders(task))
+
+	TerraformVersion = "0.13.5"
+	actual := sensitiveProviders(task)
+	assert.False(t, actual[0].Sensitive)
+	assert.True(t, providers[0].Sensitive, "task providers should not change")
+}
+
+func TestSupportsSensitiveVariables(t *testing.T) {
+	cases := []struct {
+		version  string
+		expected bool
+	}{
+		{"", true},
+		{"invalid", true},
+		{"0.13.5", false},
+		{"0.14.0-beta1", true},
+		{"0.14.2", true},
+		{"1.0.0", true},
+	}
+
+	defer func(v string) { TerraformVersion = v }(TerraformVersion)
+	for _, tc := range cases {
+		t.Run(tc.version, func(t *testing.T) {
+			TerraformVersion = tc.version
+			assert.Equal(t, tc.expected, supportsSensitiveVariables())
+		})
+	}
 }
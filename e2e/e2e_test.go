@@ -220,7 +220,7 @@ func TestE2ELocalBackend(t *testing.T) {
 
 func newTestConsulServer(t *testing.T) (*testutil.TestServer, error) {
 	log.SetOutput(ioutil.Discard)
-	srv, err := testutil.NewTestServerConfig(func(c *testutil.TestServerConfig) {
+	srv, err := testutil.NewTestServerConfigT(t, func(c *testutil.TestServerConfig) {
 		c.LogLevel = "warn"
 		c.Stdout = ioutil.Discard
 		c.Stderr = ioutil.Discard
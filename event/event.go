@@ -14,13 +14,58 @@ import (
 // An event should encompass: rendering the task’s templates, creating/updating
 // resources, and executing any handlers.
 type Event struct {
-	ID         string    `json:"id"`
-	Success    bool      `json:"success"`
-	StartTime  time.Time `json:"start_time"`
-	EndTime    time.Time `json:"end_time"`
-	TaskName   string    `json:"task_name"`
-	EventError *Error    `json:"error"`
-	Config     *Config   `json:"config"`
+	ID         string          `json:"id"`
+	Success    bool            `json:"success"`
+	Vetoed     bool            `json:"vetoed"`
+	Outcome    string          `json:"outcome"`
+	StartTime  time.Time       `json:"start_time"`
+	EndTime    time.Time       `json:"end_time"`
+	TaskName   string          `json:"task_name"`
+	EventError *Error          `json:"error"`
+	Config     *Config         `json:"config"`
+	Handlers   []HandlerResult `json:"handlers,omitempty"`
+}
+
+const (
+	// OutcomeSuccess is the outcome of an event that completed successfully.
+	OutcomeSuccess = "success"
+
+	// OutcomeVetoed is the outcome of an event whose changes were cancelled
+	// by a pre-apply handler.
+	OutcomeVetoed = "vetoed"
+
+	// OutcomeHandlerFailed is the outcome of an event where changes were
+	// applied successfully but a post-apply handler failed, e.g. the apply
+	// succeeded but the out-of-band commit failed.
+	OutcomeHandlerFailed = "handler_failed"
+
+	// OutcomeFailed is the outcome of an event that failed to render or apply
+	// changes.
+	OutcomeFailed = "failed"
+)
+
+// HandlerResult captures the result of a handler executed for an event
+type HandlerResult struct {
+	Name     string        `json:"name"`
+	Stage    string        `json:"stage"`
+	Duration time.Duration `json:"duration"`
+	Success  bool          `json:"success"`
+	Error    *Error        `json:"error"`
+}
+
+// NewHandlerResult creates the result of a handler from the error returned by
+// the handler.
+func NewHandlerResult(name, stage string, duration time.Duration, err error) HandlerResult {
+	r := HandlerResult{
+		Name:     name,
+		Stage:    stage,
+		Duration: duration,
+		Success:  err == nil,
+	}
+	if err != nil {
+		r.Error = &Error{Message: err.Error()}
+	}
+	return r
 }
 
 // Error captures an event's error information
@@ -36,6 +81,12 @@ type Config struct {
 	Source    string   `json:"source"`
 }
 
+// vetoer is implemented by errors that report whether they cancelled the
+// changes of an event before they were applied.
+type vetoer interface {
+	Vetoed() bool
+}
+
 // NewEvent configures a new event with a task name and any relevant information
 // that the task is configured with
 func NewEvent(taskName string, config *Config) (*Event, error) {
@@ -63,7 +114,9 @@ func (e *Event) Start() {
 }
 
 // End sets the end time and captures any end results e.g. error, success status.
-// Can only be called once
+// An error that reports it was vetoed marks the event as vetoed. Handler
+// results should be set before End to determine the outcome. Can only be
+// called once
 func (e *Event) End(err error) {
 	if !e.EndTime.IsZero() {
 		log.Printf("[WARN] (event) event already ended. unable to re-end")
@@ -74,13 +127,39 @@ func (e *Event) End(err error) {
 
 	if err == nil {
 		e.Success = true
+		e.Outcome = OutcomeSuccess
 		return
 	}
 
 	e.Success = false
+	var v vetoer
+	if errors.As(err, &v) {
+		e.Vetoed = v.Vetoed()
+	}
 	e.EventError = &Error{
 		Message: err.Error(),
 	}
+
+	switch {
+	case e.Vetoed:
+		e.Outcome = OutcomeVetoed
+	case e.handlerFailed():
+		e.Outcome = OutcomeHandlerFailed
+	default:
+		e.Outcome = OutcomeFailed
+	}
+}
+
+// handlerFailed reports whether any handler of the event failed. Since
+// pre-apply handler failures veto the event, a non-vetoed event with a failed
+// handler has successfully applied changes.
+func (e *Event) handlerFailed() bool {
+	for _, h := range e.Handlers {
+		if !h.Success {
+			return true
+		}
+	}
+	return false
 }
 
 // GoString defines the printable version of this struct.
@@ -93,17 +172,23 @@ func (e *Event) GoString() string {
 		"ID:%s, "+
 		"TaskName:%s, "+
 		"Success:%t, "+
+		"Vetoed:%t, "+
+		"Outcome:%s, "+
 		"StartTime:%s, "+
 		"EndTime:%s, "+
 		"EventError:%s, "+
-		"Config:%s"+
+		"Config:%s, "+
+		"Handlers:%v"+
 		"}",
 		e.ID,
 		e.TaskName,
 		e.Success,
+		e.Vetoed,
+		e.Outcome,
 		e.StartTime,
 		e.EndTime,
 		e.EventError,
 		e.Config,
+		e.Handlers,
 	)
 }
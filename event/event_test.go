@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -144,23 +145,53 @@ func TestEvent_End(t *testing.T) {
 	t.Parallel()
 
 	cases := []struct {
-		name string
-		err  error
+		name     string
+		err      error
+		handlers []HandlerResult
+		vetoed   bool
+		outcome  string
 	}{
 		{
 			"task succeeded",
 			nil,
+			nil,
+			false,
+			OutcomeSuccess,
 		},
 		{
 			"task failed",
 			errors.New("error"),
+			nil,
+			false,
+			OutcomeFailed,
+		},
+		{
+			"task vetoed",
+			fmt.Errorf("wrapped: %w", testVetoError{}),
+			[]HandlerResult{
+				NewHandlerResult("freeze", "pre-apply", time.Second, testVetoError{}),
+			},
+			true,
+			OutcomeVetoed,
+		},
+		{
+			"handler failed",
+			errors.New("commit error"),
+			[]HandlerResult{
+				NewHandlerResult("panos", "post-apply", time.Second,
+					errors.New("commit error")),
+			},
+			false,
+			OutcomeHandlerFailed,
 		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			event := &Event{}
+			event := &Event{Handlers: tc.handlers}
 			event.End(tc.err)
+			assert.Equal(t, tc.vetoed, event.Vetoed)
+			assert.Equal(t, tc.outcome, event.Outcome)
 
 			assert.False(t, event.EndTime.IsZero())
 			if tc.err == nil {
@@ -180,6 +211,11 @@ func TestEvent_End(t *testing.T) {
 	}
 }
 
+type testVetoError struct{}
+
+func (testVetoError) Error() string { return "vetoed" }
+func (testVetoError) Vetoed() bool  { return true }
+
 func businessLogic(expectError bool) (string, error) {
 	if expectError {
 		return "", errors.New("error")
@@ -204,6 +240,7 @@ func TestEvent_GoString(t *testing.T) {
 				ID:       "123",
 				TaskName: "happy",
 				Success:  false,
+				Outcome:  OutcomeFailed,
 				EventError: &Error{
 					Message: "error!",
 				},
@@ -212,11 +249,15 @@ func TestEvent_GoString(t *testing.T) {
 					Services:  []string{"web", "api"},
 					Source:    "/my-module",
 				},
+				Handlers: []HandlerResult{
+					NewHandlerResult("panos", "post-apply", time.Second, nil),
+				},
 			},
-			"&Event{ID:123, TaskName:happy, Success:false, " +
+			"&Event{ID:123, TaskName:happy, Success:false, Vetoed:false, Outcome:failed, " +
 				"StartTime:0001-01-01 00:00:00 +0000 UTC, " +
 				"EndTime:0001-01-01 00:00:00 +0000 UTC, EventError:&{error!}, " +
-				"Config:&{[local] [web api] /my-module}}",
+				"Config:&{[local] [web api] /my-module}, " +
+				"Handlers:[{panos post-apply 1s true <nil>}]}",
 		},
 	}
 
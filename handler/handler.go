@@ -1,11 +1,81 @@
 package handler
 
 import (
+	"context"
 	"fmt"
+	"log"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	// StagePreApply is the stage of handlers executed before changes are
+	// applied. Errors of pre-apply handlers veto the apply.
+	StagePreApply = "pre-apply"
+
+	// StagePostApply is the stage of handlers executed after changes are
+	// successfully applied.
+	StagePostApply = "post-apply"
+)
+
+// VetoError is the error returned when a chain of pre-apply handlers fails,
+// which cancels the apply of a task.
+type VetoError struct {
+	Err error
+}
+
+// Error returns the error message of the veto.
+func (e *VetoError) Error() string {
+	return fmt.Sprintf("apply vetoed by pre-apply handler: %s", e.Err)
+}
+
+// Unwrap returns the error of the pre-apply handlers.
+func (e *VetoError) Unwrap() error {
+	return e.Err
+}
+
+// Vetoed reports that the error vetoed an apply.
+func (e *VetoError) Vetoed() bool {
+	return true
+}
+
+// Retryable reports that a vetoed apply should not be retried, since the
+// pre-apply handlers are expected to veto the retries as well.
+func (e *VetoError) Retryable() bool {
+	return false
+}
+
+// TaskInfo describes the task and the stage that handlers are executed for. It
+// is carried by the context given to the handlers, so that handlers can
+// decide based on the task, like a pre-apply handler that vetoes applies of
+// some tasks.
+type TaskInfo struct {
+	Name        string
+	Description string
+	Source      string
+	Version     string
+	Providers   []string
+	Services    []string
+
+	// Stage is the stage of the handlers, StagePreApply or StagePostApply
+	Stage string
+}
+
+// taskInfoKey is the context key of the task info
+type taskInfoKey struct{}
+
+// WithTaskInfo returns a copy of the context that carries the task info.
+func WithTaskInfo(ctx context.Context, info TaskInfo) context.Context {
+	return context.WithValue(ctx, taskInfoKey{}, info)
+}
+
+// TaskInfoFromContext returns the task info carried by the context and
+// whether the context carries task info.
+func TaskInfoFromContext(ctx context.Context) (TaskInfo, bool) {
+	info, ok := ctx.Value(taskInfoKey{}).(TaskInfo)
+	return info, ok
+}
+
 // Handler handles additional actions that need to be executed. These can
 // be at any level. Handlers can be chained such that they execute and continue
 // to the next handler. A chain of handlers will return an aggregate of any
@@ -13,8 +83,10 @@ import (
 type Handler interface {
 
 	// Do executes the handler. Receives previous error and returns previous
-	// error wrapped in any new errors.
-	Do(error) error
+	// error wrapped in any new errors. The context cancels any long running
+	// actions of the handler, e.g. on shutdown, and carries the TaskInfo of
+	// the task when executed by a driver.
+	Do(context.Context, error) error
 
 	// SetNext sets the next handler that should be called
 	SetNext(Handler)
@@ -32,6 +104,7 @@ func TerraformProviderHandler(providerName string, config interface{}) (Handler,
 			"Unexpected config type. Want map[string]interface{}. Got %T", config)
 	}
 
+	c = TerraformProviderConfig(providerName, c)
 	switch providerName {
 	case TerraformProviderPanos:
 		return NewPanos(c)
@@ -42,12 +115,51 @@ func TerraformProviderHandler(providerName string, config interface{}) (Handler,
 	}
 }
 
+// TerraformProviderConfig returns a copy of the configuration of a provider
+// block without the handler options of the provider's handler. Handler options
+// are only read from a handler block, since they are not arguments of the
+// Terraform provider.
+func TerraformProviderConfig(providerName string, config map[string]interface{}) map[string]interface{} {
+	var options []string
+	switch providerName {
+	case TerraformProviderPanos:
+		options = panosHandlerOptions
+	}
+
+	conf := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		conf[k] = v
+	}
+	for _, o := range options {
+		if _, ok := conf[o]; ok {
+			log.Printf("[WARN] (handler) ignoring %q of the %s provider, which "+
+				"is only read from a handler \"%s\" block", o, providerName, providerName)
+			delete(conf, o)
+		}
+	}
+	return conf
+}
+
+// NewHandler returns a handler of the handler type configured by the task.
+// Unlike TerraformProviderHandler, an error is returned for an unsupported
+// handler type since the handler was explicitly requested.
+func NewHandler(handlerType string, config map[string]interface{}) (Handler, error) {
+	switch handlerType {
+	case TerraformProviderPanos:
+		return NewPanos(config)
+	case TerraformProviderFake:
+		return NewFake(config)
+	default:
+		return nil, fmt.Errorf("unsupported handler type: %s", handlerType)
+	}
+}
+
 // callNext should be called by a handler's Do() to call the next handler
-func callNext(nextH Handler, prevErr, err error) error {
+func callNext(ctx context.Context, nextH Handler, prevErr, err error) error {
 	nextErr := nextError(prevErr, err)
 
 	if nextH != nil {
-		return nextH.Do(nextErr)
+		return nextH.Do(ctx, nextErr)
 	}
 	return nextErr
 }
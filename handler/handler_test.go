@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -74,6 +75,88 @@ func TestTerraformProviderHandler(t *testing.T) {
 	}
 }
 
+func TestTerraformProviderConfig(t *testing.T) {
+	t.Parallel()
+
+	conf := map[string]interface{}{
+		"hostname":       "10.10.10.10",
+		"username":       "user",
+		"panorama":       true,
+		"device_groups":  []string{"dg"},
+		"commit_retries": 3,
+	}
+
+	t.Run("handler options", func(t *testing.T) {
+		actual := TerraformProviderConfig(TerraformProviderPanos, conf)
+		assert.Equal(t, map[string]interface{}{
+			"hostname": "10.10.10.10",
+			"username": "user",
+		}, actual)
+		assert.Len(t, conf, 5, "provider config should not change")
+	})
+
+	t.Run("other provider", func(t *testing.T) {
+		actual := TerraformProviderConfig("other", conf)
+		assert.Equal(t, conf, actual)
+	})
+
+	t.Run("provider handler", func(t *testing.T) {
+		h, err := TerraformProviderHandler(TerraformProviderPanos, conf)
+		assert.NoError(t, err)
+		p, ok := h.(*Panos)
+		assert.True(t, ok)
+		assert.Nil(t, p.panorama)
+		assert.Equal(t, uint(0), p.commitRetries)
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		expectError bool
+		handlerType string
+		config      map[string]interface{}
+	}{
+		{
+			"unsupported handler",
+			true,
+			"no-handler-type",
+			map[string]interface{}{},
+		},
+		{
+			"panos handler",
+			false,
+			TerraformProviderPanos,
+			map[string]interface{}{
+				"hostname": "10.10.10.10",
+				"username": "user",
+			},
+		},
+		{
+			"fake handler",
+			false,
+			TerraformProviderFake,
+			map[string]interface{}{
+				"name": "1",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := NewHandler(tc.handlerType, tc.config)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, h)
+		})
+	}
+}
+
 func ExampleTerraformProviderHandler() {
 	providers := make([]map[string]interface{}, 0)
 
@@ -117,7 +200,7 @@ func ExampleTerraformProviderHandler() {
 			}
 		}
 	}
-	fmt.Println("Handler Errors:", next.Do(nil))
+	fmt.Println("Handler Errors:", next.Do(context.Background(), nil))
 	// Output:
 	// FakeHandler: '1'
 	// FakeHandler: '2'
@@ -152,7 +235,7 @@ func TestCallNext(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			fmt.Println("fake: ", tc.nextH, " equal? ", tc.nextH == nil)
-			err := callNext(tc.nextH, nil, nil)
+			err := callNext(context.Background(), tc.nextH, nil, nil)
 			if tc.nextErr {
 				assert.Error(t, err)
 			} else {
@@ -202,3 +285,17 @@ func TestNextError(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskInfoFromContext(t *testing.T) {
+	_, ok := TaskInfoFromContext(context.Background())
+	assert.False(t, ok)
+
+	info := TaskInfo{
+		Name:     "task",
+		Services: []string{"api"},
+		Stage:    StagePreApply,
+	}
+	actual, ok := TaskInfoFromContext(WithTaskInfo(context.Background(), info))
+	assert.True(t, ok)
+	assert.Equal(t, info, actual)
+}
@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/PaloAltoNetworks/pango"
 	"github.com/PaloAltoNetworks/pango/commit"
+	"github.com/hashicorp/consul-terraform-sync/retry"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -18,11 +22,53 @@ const (
 	// Users with custom roles currently return an error for an empty commit with
 	// this server response prefix. See GH-73 for more details.
 	emptyCommitServerRespPrefix = `<response status="success" code="13">`
+
+	// Push scopes for Panorama commit-all after the Panorama commit
+	pushScopeNone         = "none"
+	pushScopeDeviceGroups = "device_groups"
+	pushScopeTemplates    = "templates"
+	pushScopeAll          = "all"
+
+	commitDescription = "Consul Terraform Sync Commit"
+
+	// defaultCommitBackoff is the default unit of the exponential backoff
+	// between commit retries
+	defaultCommitBackoff = 5 * time.Second
 )
 
+// transientCommitErrors are substrings of known PAN-OS errors that are
+// resolved by retrying the commit later, e.g. when another admin is committing
+// or holds a lock.
+var transientCommitErrors = []string{
+	"commit is in progress",
+	"commit in progress",
+	"another commit",
+	"commit lock",
+	"config lock",
+	"is currently locked",
+	"lock held",
+	"lock is held",
+}
+
+// panosHandlerOptions are the options of the panos handler that are only read
+// from a handler "panos" block and not from the panos provider block.
+var panosHandlerOptions = []string{
+	"panorama",
+	"device_groups",
+	"templates",
+	"template_stacks",
+	"push_scope",
+	"commit_timeout",
+	"commit_retries",
+	"commit_backoff",
+}
+
 //go:generate mockery --name=panosClient  --structname=PanosClient --output=../mocks/handler
 
-var _ panosClient = (*pango.Firewall)(nil)
+var (
+	_ panosClient = (*pango.Firewall)(nil)
+	_ panosClient = (*pango.Panorama)(nil)
+)
 
 type panosClient interface {
 	InitializeUsing(filename string, chkenv bool) error
@@ -35,6 +81,9 @@ var _ Handler = (*Panos)(nil)
 
 // Panos is the post-apply handler for the panos Terraform Provider.
 // It performs the out-of-band Commit API request needed after a Terraform apply.
+// When configured for Panorama in a handler "panos" block, it commits to
+// Panorama and then pushes the changes to the configured device groups and
+// templates with commit-all.
 //
 // See https://registry.terraform.io/providers/PaloAltoNetworks/panos/latest/docs
 // for details on Commit and panos provider (outdated use of SDK at the time).
@@ -45,6 +94,72 @@ type Panos struct {
 	providerConf pango.Client
 	adminUser    string
 	configPath   string
+
+	// panorama is set when changes are committed through Panorama instead of
+	// directly to a firewall
+	panorama *panoramaConfig
+
+	// commit policy for the deadline of a commit including retries, and for
+	// retrying transient commit errors
+	commitTimeout time.Duration
+	commitRetries uint
+	commitBackoff time.Duration
+}
+
+// commitError is an error from committing with PAN-OS. Only transient errors
+// are retried by commitWithRetry.
+type commitError struct {
+	err       error
+	transient bool
+}
+
+func newCommitError(err error, resp []byte) *commitError {
+	msg := strings.ToLower(err.Error() + " " + string(resp))
+	for _, transient := range transientCommitErrors {
+		if strings.Contains(msg, transient) {
+			return &commitError{err: err, transient: true}
+		}
+	}
+	return &commitError{err: err}
+}
+
+// Error returns the error message of the commit error.
+func (e *commitError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *commitError) Unwrap() error {
+	return e.err
+}
+
+// commitAttemptError is the error of a single commit attempt. It classifies
+// the error for the retries within commitWithRetry and is not returned by the
+// handler, so the classification does not apply to retries of the task.
+type commitAttemptError struct {
+	err error
+}
+
+// Error returns the error message of the commit attempt.
+func (e *commitAttemptError) Error() string {
+	return e.err.Error()
+}
+
+// Retryable reports whether the commit attempt failed with a transient commit
+// error. Other errors, such as errors initializing the client or an expired
+// commit timeout, are not retried.
+func (e *commitAttemptError) Retryable() bool {
+	var ce *commitError
+	return errors.As(e.err, &ce) && ce.transient
+}
+
+// panoramaConfig configures the Panorama commit and the commit-all that pushes
+// the committed changes to the managed devices.
+type panoramaConfig struct {
+	deviceGroups   []string
+	templates      []string
+	templateStacks []string
+	pushScope      string
 }
 
 // NewPanos configures and returns a new panos handler
@@ -86,29 +201,211 @@ func NewPanos(c map[string]interface{}) (*Panos, error) {
 			"the panos provider or set the PANOS_USERNAME environment variable.")
 	}
 
-	fw := &pango.Firewall{
-		Client: conf,
+	pano, err := newPanoramaConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := newCommitPolicy(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var client panosClient
+	if pano != nil {
+		log.Printf("[INFO] (handler.panos) committing through Panorama")
+		client = &pango.Panorama{Client: conf}
+	} else {
+		client = &pango.Firewall{Client: conf}
 	}
 
 	return &Panos{
 		next:         nil,
-		client:       fw,
+		client:       client,
 		providerConf: conf,
 		adminUser:    username,
 		configPath:   configPath,
+		panorama:     pano,
+
+		commitTimeout: policy.timeout,
+		commitRetries: policy.retries,
+		commitBackoff: policy.backoff,
 	}, nil
 }
 
+// commitPolicy is the timeout and retry configuration for commits
+type commitPolicy struct {
+	timeout time.Duration
+	retries uint
+	backoff time.Duration
+}
+
+// newCommitPolicy parses the commit timeout and retry options of the handler
+// configuration. By default, commits have no timeout and are not retried.
+//
+//	commit_timeout = "10m"
+//	commit_retries = 3
+//	commit_backoff = "5s"
+func newCommitPolicy(c map[string]interface{}) (commitPolicy, error) {
+	policy := commitPolicy{backoff: defaultCommitBackoff}
+	var err error
+	if policy.timeout, err = durationValue(c, "commit_timeout"); err != nil {
+		return policy, err
+	}
+
+	if val, ok := c["commit_retries"]; ok {
+		var retries int
+		switch v := val.(type) {
+		case int:
+			retries = v
+		case int64:
+			retries = int(v)
+		case float64:
+			retries = int(v)
+		default:
+			return policy, fmt.Errorf("expected integer for panos commit_retries: %v", val)
+		}
+		if retries < 0 {
+			return policy, fmt.Errorf("panos commit_retries cannot be negative: %d", retries)
+		}
+		policy.retries = uint(retries)
+	}
+
+	if _, ok := c["commit_backoff"]; ok {
+		if policy.backoff, err = durationValue(c, "commit_backoff"); err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, nil
+}
+
+// durationValue returns the duration for a key in the configuration
+func durationValue(c map[string]interface{}, key string) (time.Duration, error) {
+	val, ok := c[key]
+	if !ok {
+		return 0, nil
+	}
+
+	s, ok := val.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected duration string for panos %s: %v", key, val)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration for panos %s: %s", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("panos %s cannot be negative: %s", key, s)
+	}
+	return d, nil
+}
+
+// newPanoramaConfig parses the Panorama options of the handler configuration.
+// Returns nil if the handler is not configured for Panorama.
+//
+//	panorama = true
+//	device_groups = ["dg"]
+//	templates = ["template"]
+//	template_stacks = ["stack"]
+//	push_scope = "all"
+func newPanoramaConfig(c map[string]interface{}) (*panoramaConfig, error) {
+	if enabled, ok := c["panorama"].(bool); !ok || !enabled {
+		return nil, nil
+	}
+
+	conf := &panoramaConfig{pushScope: pushScopeAll}
+	var err error
+	if conf.deviceGroups, err = stringList(c, "device_groups"); err != nil {
+		return nil, err
+	}
+	if conf.templates, err = stringList(c, "templates"); err != nil {
+		return nil, err
+	}
+	if conf.templateStacks, err = stringList(c, "template_stacks"); err != nil {
+		return nil, err
+	}
+
+	if val, ok := c["push_scope"]; ok {
+		scope, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string for panos push_scope: %v", val)
+		}
+		switch scope {
+		case pushScopeNone, pushScopeDeviceGroups, pushScopeTemplates, pushScopeAll:
+			conf.pushScope = scope
+		default:
+			return nil, fmt.Errorf("unsupported panos push_scope %q. Expected "+
+				"one of %q, %q, %q, or %q", scope, pushScopeNone,
+				pushScopeDeviceGroups, pushScopeTemplates, pushScopeAll)
+		}
+	}
+
+	return conf, nil
+}
+
+// stringList returns the list of strings for a key in the configuration
+func stringList(c map[string]interface{}, key string) ([]string, error) {
+	val, ok := c[key]
+	if !ok {
+		return nil, nil
+	}
+
+	switch v := val.(type) {
+	case []string:
+		return v, nil
+	case []interface{}:
+		list := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected list of strings for panos %s: %v",
+					key, val)
+			}
+			list[i] = s
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("expected list of strings for panos %s: %v", key, val)
+	}
+}
+
 // Do executes panos' out-of-band Commit API and calls next handler while passing
 // on relevant errors
-func (h *Panos) Do(prevErr error) error {
+func (h *Panos) Do(ctx context.Context, prevErr error) error {
 	log.Printf("[INFO] (handler.panos) commit. host '%s'", h.providerConf.Hostname)
-	err := h.commit()
-	return callNext(h.next, prevErr, err)
+	err := h.commitWithRetry(ctx)
+	return callNext(ctx, h.next, prevErr, err)
+}
+
+// commitWithRetry commits within the configured timeout and retries the commit
+// on transient errors.
+func (h *Panos) commitWithRetry(ctx context.Context) error {
+	if h.commitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.commitTimeout)
+		defer cancel()
+	}
+
+	attempt := func(ctx context.Context) error {
+		if err := h.commit(ctx); err != nil {
+			return &commitAttemptError{err: err}
+		}
+		return nil
+	}
+
+	r := retry.NewRetryBackoff(h.commitRetries, h.commitBackoff, time.Now().UnixNano())
+	err := r.Do(ctx, attempt, "panos commit")
+
+	var attemptErr *commitAttemptError
+	if errors.As(err, &attemptErr) {
+		return attemptErr.err
+	}
+	return err
 }
 
 // commit calls panos' InitializeUsing & Commit SDK
-func (h *Panos) commit() error {
+func (h *Panos) commit(ctx context.Context) error {
 	if err := h.client.InitializeUsing(h.configPath, true); err != nil {
 		// potential optimizations to call Initialize() once / less frequently
 		log.Printf("[ERR] (handler.panos) error initializing panos client: %s", err)
@@ -116,28 +413,116 @@ func (h *Panos) commit() error {
 	}
 	log.Printf("[TRACE] (handler.panos) client config after init: %s", h.client.String())
 
+	if h.panorama != nil {
+		return h.panoramaCommit(ctx)
+	}
+
 	c := commit.FirewallCommit{
 		Admins:      []string{h.adminUser},
-		Description: "Consul Terraform Sync Commit",
+		Description: commitDescription,
+	}
+	if err := h.commitAndWait(ctx, c.Element(), c.Action()); err != nil {
+		return err
 	}
-	job, resp, err := h.client.Commit(c.Element(), "", nil)
+
+	log.Printf("[DEBUG] (handler.panos) commit successful")
+	return nil
+}
+
+// panoramaCommit commits the admin's changes to Panorama and then pushes the
+// changes to the device groups and templates within the push scope.
+func (h *Panos) panoramaCommit(ctx context.Context) error {
+	pano := h.panorama
+	c := commit.PanoramaCommit{
+		Admins:         []string{h.adminUser},
+		DeviceGroups:   pano.deviceGroups,
+		Templates:      pano.templates,
+		TemplateStacks: pano.templateStacks,
+		Description:    commitDescription,
+	}
+	if err := h.commitAndWait(ctx, c.Element(), c.Action()); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] (handler.panos) Panorama commit successful")
+
+	for _, ca := range pano.commitAlls() {
+		log.Printf("[DEBUG] (handler.panos) pushing to %s '%s'", ca.Type, ca.Name)
+		if err := h.commitAndWait(ctx, ca.Element(), ca.Action()); err != nil {
+			return fmt.Errorf("error pushing to %s '%s': %w", ca.Type, ca.Name, err)
+		}
+	}
+
+	log.Printf("[DEBUG] (handler.panos) commit successful")
+	return nil
+}
+
+// commitAndWait requests a commit and waits for the commit job to finish.
+// Commits that are empty are skipped.
+func (h *Panos) commitAndWait(ctx context.Context, cmd interface{}, action string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	job, resp, err := h.client.Commit(cmd, action, nil)
 	if emptyCommit(job, resp, err) {
 		return nil
 	}
 	if err != nil {
 		log.Printf("[ERR] (handler.panos) error committing: %s. Server response: '%s'", err, resp)
-		return err
+		return newCommitError(err, resp)
 	}
 
-	if err := h.client.WaitForJob(job, nil); err != nil {
+	if err := h.waitForJob(ctx, job); err != nil {
 		log.Printf("[ERR] (handler.panos) error waiting for panos commit to finish: %s", err)
 		return err
 	}
-
-	log.Printf("[DEBUG] (handler.panos) commit successful")
 	return nil
 }
 
+// waitForJob waits for the commit job to finish or for the context to be
+// cancelled. The SDK does not support cancelling the wait, so the job is
+// polled in the background until it finishes.
+func (h *Panos) waitForJob(ctx context.Context, job uint) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- h.client.WaitForJob(job, nil)
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return newCommitError(err, nil)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// commitAlls returns the Panorama commit-alls that push changes to the
+// devices for the configured push scope.
+func (c *panoramaConfig) commitAlls() []commit.PanoramaCommitAll {
+	var commitAlls []commit.PanoramaCommitAll
+	add := func(commitType string, names []string) {
+		for _, name := range names {
+			commitAlls = append(commitAlls, commit.PanoramaCommitAll{
+				Type:        commitType,
+				Name:        name,
+				Description: commitDescription,
+			})
+		}
+	}
+
+	if c.pushScope == pushScopeDeviceGroups || c.pushScope == pushScopeAll {
+		add(commit.TypeDeviceGroup, c.deviceGroups)
+	}
+	if c.pushScope == pushScopeTemplates || c.pushScope == pushScopeAll {
+		add(commit.TypeTemplate, c.templates)
+		add(commit.TypeTemplateStack, c.templateStacks)
+	}
+	return commitAlls
+}
+
 // SetNext sets the next handler that should be called.
 func (h *Panos) SetNext(next Handler) {
 	h.next = next
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/PaloAltoNetworks/pango"
 	mocks "github.com/hashicorp/consul-terraform-sync/mocks/handler"
@@ -124,7 +126,7 @@ func TestPanosDo(t *testing.T) {
 				h.SetNext(next)
 			}
 
-			assert.NoError(t, h.Do(nil))
+			assert.NoError(t, h.Do(context.Background(), nil))
 		})
 	}
 }
@@ -198,7 +200,7 @@ func TestPanosCommit(t *testing.T) {
 			m.On("String").Return("client string").Once()
 
 			h := &Panos{client: m}
-			err := h.commit()
+			err := h.commit(context.Background())
 			if tc.expectErr {
 				assert.Error(t, err)
 			} else {
@@ -208,6 +210,345 @@ func TestPanosCommit(t *testing.T) {
 	}
 }
 
+func TestNewPanos_Panorama(t *testing.T) {
+	cases := []struct {
+		name        string
+		expectError bool
+		config      map[string]interface{}
+		expected    *panoramaConfig
+	}{
+		{
+			"firewall",
+			false,
+			map[string]interface{}{},
+			nil,
+		}, {
+			"panorama disabled",
+			false,
+			map[string]interface{}{"panorama": false},
+			nil,
+		}, {
+			"panorama defaults",
+			false,
+			map[string]interface{}{"panorama": true},
+			&panoramaConfig{pushScope: pushScopeAll},
+		}, {
+			"panorama",
+			false,
+			map[string]interface{}{
+				"panorama":        true,
+				"device_groups":   []interface{}{"dg1", "dg2"},
+				"templates":       []string{"template"},
+				"template_stacks": []interface{}{"stack"},
+				"push_scope":      "device_groups",
+			},
+			&panoramaConfig{
+				deviceGroups:   []string{"dg1", "dg2"},
+				templates:      []string{"template"},
+				templateStacks: []string{"stack"},
+				pushScope:      pushScopeDeviceGroups,
+			},
+		}, {
+			"invalid device groups",
+			true,
+			map[string]interface{}{
+				"panorama":      true,
+				"device_groups": "dg1",
+			},
+			nil,
+		}, {
+			"invalid push scope",
+			true,
+			map[string]interface{}{
+				"panorama":   true,
+				"push_scope": "everything",
+			},
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config["hostname"] = "10.10.10.10"
+			tc.config["username"] = "user"
+
+			h, err := NewPanos(tc.config)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, h.panorama)
+			if tc.expected != nil {
+				assert.IsType(t, &pango.Panorama{}, h.client)
+			} else {
+				assert.IsType(t, &pango.Firewall{}, h.client)
+			}
+		})
+	}
+}
+
+func TestPanosCommit_Panorama(t *testing.T) {
+	cases := []struct {
+		name            string
+		pushScope       string
+		expectCommitAll []string
+	}{
+		{
+			"push none",
+			pushScopeNone,
+			nil,
+		},
+		{
+			"push device groups",
+			pushScopeDeviceGroups,
+			[]string{"dg1", "dg2"},
+		},
+		{
+			"push templates",
+			pushScopeTemplates,
+			[]string{"template", "stack"},
+		},
+		{
+			"push all",
+			pushScopeAll,
+			[]string{"dg1", "dg2", "template", "stack"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pano := &panoramaConfig{
+				deviceGroups:   []string{"dg1", "dg2"},
+				templates:      []string{"template"},
+				templateStacks: []string{"stack"},
+				pushScope:      tc.pushScope,
+			}
+
+			var names []string
+			for _, ca := range pano.commitAlls() {
+				names = append(names, ca.Name)
+			}
+			assert.Equal(t, tc.expectCommitAll, names)
+
+			m := new(mocks.PanosClient)
+			m.On("InitializeUsing", mock.Anything, mock.Anything, mock.Anything).
+				Return(nil).Once()
+			m.On("Commit", mock.Anything, "", mock.Anything).
+				Return(uint(1), []byte("ok"), nil).Once()
+			if len(tc.expectCommitAll) > 0 {
+				m.On("Commit", mock.Anything, "all", mock.Anything).
+					Return(uint(2), []byte("ok"), nil).Times(len(tc.expectCommitAll))
+			}
+			m.On("WaitForJob", mock.Anything, mock.Anything).Return(nil)
+			m.On("String").Return("client string").Once()
+
+			h := &Panos{client: m, adminUser: "admin", panorama: pano}
+			assert.NoError(t, h.commit(context.Background()))
+			m.AssertExpectations(t)
+		})
+	}
+
+	t.Run("error on push", func(t *testing.T) {
+		m := new(mocks.PanosClient)
+		m.On("InitializeUsing", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil).Once()
+		m.On("Commit", mock.Anything, "", mock.Anything).
+			Return(uint(1), []byte("ok"), nil).Once()
+		m.On("Commit", mock.Anything, "all", mock.Anything).
+			Return(uint(0), []byte("failure"), errors.New("commit-all error")).Once()
+		m.On("WaitForJob", mock.Anything, mock.Anything).Return(nil).Once()
+		m.On("String").Return("client string").Once()
+
+		h := &Panos{
+			client: m,
+			panorama: &panoramaConfig{
+				deviceGroups: []string{"dg1"},
+				pushScope:    pushScopeAll,
+			},
+		}
+		err := h.commit(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "dg1")
+	})
+}
+
+func TestNewPanos_CommitPolicy(t *testing.T) {
+	cases := []struct {
+		name        string
+		expectError bool
+		config      map[string]interface{}
+		expected    commitPolicy
+	}{
+		{
+			"defaults",
+			false,
+			map[string]interface{}{},
+			commitPolicy{backoff: defaultCommitBackoff},
+		}, {
+			"configured",
+			false,
+			map[string]interface{}{
+				"commit_timeout": "10m",
+				"commit_retries": 3,
+				"commit_backoff": "2s",
+			},
+			commitPolicy{
+				timeout: 10 * time.Minute,
+				retries: 3,
+				backoff: 2 * time.Second,
+			},
+		}, {
+			"invalid timeout",
+			true,
+			map[string]interface{}{"commit_timeout": "10 minutes"},
+			commitPolicy{},
+		}, {
+			"timeout not a string",
+			true,
+			map[string]interface{}{"commit_timeout": 10},
+			commitPolicy{},
+		}, {
+			"negative retries",
+			true,
+			map[string]interface{}{"commit_retries": -1},
+			commitPolicy{},
+		}, {
+			"retries not an integer",
+			true,
+			map[string]interface{}{"commit_retries": "3"},
+			commitPolicy{},
+		}, {
+			"negative backoff",
+			true,
+			map[string]interface{}{"commit_backoff": "-1s"},
+			commitPolicy{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config["hostname"] = "10.10.10.10"
+			tc.config["username"] = "user"
+			h, err := NewPanos(tc.config)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected.timeout, h.commitTimeout)
+			assert.Equal(t, tc.expected.retries, h.commitRetries)
+			assert.Equal(t, tc.expected.backoff, h.commitBackoff)
+		})
+	}
+}
+
+func TestPanosCommitWithRetry(t *testing.T) {
+	cases := []struct {
+		name          string
+		commitResp    []byte
+		commitErr     error
+		expectCommits int
+	}{
+		{
+			"commit in progress is retried",
+			[]byte("<response>Another commit is in progress</response>"),
+			errors.New("commit error"),
+			3,
+		}, {
+			"lock held is retried",
+			[]byte("failure"),
+			errors.New("Config for scope shared is currently locked by admin"),
+			3,
+		}, {
+			"other errors are not retried",
+			[]byte("failure"),
+			errors.New("validation error"),
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mocks.PanosClient)
+			m.On("InitializeUsing", mock.Anything, mock.Anything, mock.Anything).
+				Return(nil)
+			m.On("Commit", mock.Anything, mock.Anything, mock.Anything).
+				Return(uint(0), tc.commitResp, tc.commitErr).Times(tc.expectCommits)
+			m.On("String").Return("client string")
+
+			h := &Panos{
+				client:        m,
+				commitRetries: 2,
+				commitBackoff: time.Millisecond,
+			}
+			err := h.commitWithRetry(context.Background())
+			assert.Error(t, err)
+			m.AssertExpectations(t)
+
+			// The retry classification of the commit does not apply to
+			// retries of the task
+			var re interface{ Retryable() bool }
+			assert.False(t, errors.As(err, &re))
+		})
+	}
+
+	t.Run("initialize errors are not retried", func(t *testing.T) {
+		m := new(mocks.PanosClient)
+		m.On("InitializeUsing", mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("connection refused")).Once()
+
+		h := &Panos{
+			client:        m,
+			commitRetries: 2,
+			commitBackoff: time.Millisecond,
+		}
+		err := h.commitWithRetry(context.Background())
+		assert.EqualError(t, err, "connection refused")
+		m.AssertExpectations(t)
+	})
+
+	t.Run("succeeds after transient error", func(t *testing.T) {
+		m := new(mocks.PanosClient)
+		m.On("InitializeUsing", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		m.On("Commit", mock.Anything, mock.Anything, mock.Anything).
+			Return(uint(0), []byte("commit is in progress"), errors.New("commit error")).Once()
+		m.On("Commit", mock.Anything, mock.Anything, mock.Anything).
+			Return(uint(1), []byte("ok"), nil).Once()
+		m.On("WaitForJob", mock.Anything, mock.Anything).Return(nil).Once()
+		m.On("String").Return("client string")
+
+		h := &Panos{
+			client:        m,
+			commitRetries: 2,
+			commitBackoff: time.Millisecond,
+		}
+		assert.NoError(t, h.commitWithRetry(context.Background()))
+		m.AssertExpectations(t)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		m := new(mocks.PanosClient)
+		m.On("InitializeUsing", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil)
+		m.On("Commit", mock.Anything, mock.Anything, mock.Anything).
+			Return(uint(1), []byte("ok"), nil).Once()
+		m.On("WaitForJob", mock.Anything, mock.Anything).
+			After(time.Second).Return(nil).Once()
+		m.On("String").Return("client string")
+
+		h := &Panos{
+			client:        m,
+			commitTimeout: 10 * time.Millisecond,
+			commitRetries: 2,
+			commitBackoff: time.Millisecond,
+		}
+		err := h.commitWithRetry(context.Background())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
 func TestPanosSetNext(t *testing.T) {
 	cases := []struct {
 		name string
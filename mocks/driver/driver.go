@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 
+	event "github.com/hashicorp/consul-terraform-sync/event"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -14,17 +15,26 @@ type Driver struct {
 }
 
 // ApplyTask provides a mock function with given fields: ctx
-func (_m *Driver) ApplyTask(ctx context.Context) error {
+func (_m *Driver) ApplyTask(ctx context.Context) ([]event.HandlerResult, error) {
 	ret := _m.Called(ctx)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+	var r0 []event.HandlerResult
+	if rf, ok := ret.Get(0).(func(context.Context) []event.HandlerResult); ok {
 		r0 = rf(ctx)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]event.HandlerResult)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // InitTask provides a mock function with given fields: force
@@ -55,6 +65,20 @@ func (_m *Driver) InspectTask(ctx context.Context) error {
 	return r0
 }
 
+// ValidateTask provides a mock function with given fields: ctx
+func (_m *Driver) ValidateTask(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Version provides a mock function with given fields:
 func (_m *Driver) Version() string {
 	ret := _m.Called()
@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	dep "github.com/hashicorp/hcat/dep"
+
 	hcat "github.com/hashicorp/hcat"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -49,6 +52,11 @@ func (_m *Template) ID() string {
 	return r0
 }
 
+// Notify provides a mock function with given fields: _a0
+func (_m *Template) Notify(_a0 dep.Dependency) {
+	_m.Called(_a0)
+}
+
 // Render provides a mock function with given fields: content
 func (_m *Template) Render(content []byte) (hcat.RenderResult, error) {
 	ret := _m.Called(content)
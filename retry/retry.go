@@ -2,34 +2,58 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"time"
 
-	"github.com/pkg/errors"
+	pkgErrors "github.com/pkg/errors"
 )
 
+// defaultBackoff is the default unit of the exponential backoff between
+// retries
+const defaultBackoff = time.Second
+
 // Retry handles executing and retrying a function
 type Retry struct {
 	maxRetry uint
+	backoff  time.Duration
 	random   *rand.Rand
 }
 
 // NewRetry initializes a retry handler
 func NewRetry(maxRetry uint, seed int64) Retry {
+	return NewRetryBackoff(maxRetry, defaultBackoff, seed)
+}
+
+// NewRetryBackoff initializes a retry handler with the unit of the exponential
+// backoff between retries. The wait time for attempt n is between n^2 and
+// (n+1)^2 units of backoff.
+func NewRetryBackoff(maxRetry uint, backoff time.Duration, seed int64) Retry {
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
 	return Retry{
 		maxRetry: maxRetry,
+		backoff:  backoff,
 		random:   rand.New(rand.NewSource(seed)),
 	}
 }
 
-// Do calls a function with exponential retry with a random delay.
+// retryableError is implemented by errors that can determine whether the
+// failed function should be retried.
+type retryableError interface {
+	Retryable() bool
+}
+
+// Do calls a function with exponential retry with a random delay. Errors that
+// report they are not retryable stop retries and are returned as is.
 func (r *Retry) Do(ctx context.Context, f func(context.Context) error, desc string) error {
 	var errs error
 
 	err := f(ctx)
-	if err == nil || r.maxRetry == 0 {
+	if err == nil || r.maxRetry == 0 || !retryable(err) {
 		return err
 	}
 
@@ -52,13 +76,17 @@ func (r *Retry) Do(ctx context.Context, f func(context.Context) error, desc stri
 			if err == nil {
 				return nil
 			}
+			if !retryable(err) {
+				log.Printf("[WARN]: (task) not retrying '%s': %s", desc, err)
+				return err
+			}
 
 			err = fmt.Errorf("retry attempt #%d failed '%s'", attempt, err)
 
 			if errs == nil {
 				errs = err
 			} else {
-				errs = errors.Wrap(errs, err.Error())
+				errs = pkgErrors.Wrap(errs, err.Error())
 			}
 
 			wait := r.waitTime(attempt)
@@ -70,14 +98,23 @@ func (r *Retry) Do(ctx context.Context, f func(context.Context) error, desc stri
 	}
 }
 
+// retryable determines whether a function that returned err should be retried.
+func retryable(err error) bool {
+	var re retryableError
+	if errors.As(err, &re) {
+		return re.Retryable()
+	}
+	return true
+}
+
 // waitTime calculates the wait time based off the attempt number based off
-// exponential backoff with a random delay.
+// exponential backoff with a random delay in units of the backoff.
 func (r *Retry) waitTime(attempt uint) int {
 	a := float64(attempt)
-	baseTimeSeconds := a * a
-	nextTimeSeconds := (a + 1) * (a + 1)
-	delayRange := (nextTimeSeconds - baseTimeSeconds) / 2
+	baseTime := a * a
+	nextTime := (a + 1) * (a + 1)
+	delayRange := (nextTime - baseTime) / 2
 	delay := r.random.Float64() * delayRange
-	total := (baseTimeSeconds + delay) * float64(time.Second)
+	total := (baseTime + delay) * float64(r.backoff)
 	return int(total)
 }
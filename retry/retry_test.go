@@ -99,6 +99,52 @@ func TestWithRetry(t *testing.T) {
 	}
 }
 
+func TestWithRetry_notRetryable(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		notRetryOn  int
+		expectCount int
+	}{
+		{
+			"first attempt",
+			1,
+			1,
+		},
+		{
+			"retry attempt",
+			2,
+			2,
+		},
+	}
+
+	ctx := context.Background()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			count := 0
+			notRetryable := testNotRetryableError{}
+			fxn := func(context.Context) error {
+				count++
+				if count == tc.notRetryOn {
+					return fmt.Errorf("wrapped: %w", notRetryable)
+				}
+				return fmt.Errorf("error on %d", count)
+			}
+
+			r := NewRetry(5, 1)
+			err := r.Do(ctx, fxn, "test fxn")
+			assert.True(t, errors.Is(err, notRetryable))
+			assert.Equal(t, tc.expectCount, count)
+		})
+	}
+}
+
+type testNotRetryableError struct{}
+
+func (testNotRetryableError) Error() string   { return "not retryable" }
+func (testNotRetryableError) Retryable() bool { return false }
+
 func TestWithRetry_client(t *testing.T) {
 	t.Parallel()
 
@@ -172,5 +218,15 @@ func TestWaitTime(t *testing.T) {
 			assert.GreaterOrEqual(t, actual, tc.minReturn)
 			assert.LessOrEqual(t, actual, tc.maxReturn)
 		})
+
+		t.Run(tc.name+" with backoff", func(t *testing.T) {
+			backoff := 10 * time.Millisecond
+			r := NewRetryBackoff(1, backoff, 1)
+			a := r.waitTime(tc.attempt)
+
+			actual := float64(a) / float64(backoff)
+			assert.GreaterOrEqual(t, actual, tc.minReturn)
+			assert.LessOrEqual(t, actual, tc.maxReturn)
+		})
 	}
 }
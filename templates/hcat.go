@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/hcat"
+	"github.com/hashicorp/hcat/dep"
 )
 
 //go:generate mockery --name=Template  --filename=template.go --output=../mocks/templates
@@ -26,6 +27,7 @@ type Template interface {
 	Render(content []byte) (hcat.RenderResult, error)
 	Execute(hcat.Watcherer) ([]byte, error)
 	ID() string
+	Notify(dep.Dependency)
 }
 
 // Resolver describes the interface for hashicat's Resolver structure
@@ -1,6 +1,7 @@
 package hcltmpl
 
 import (
+	"fmt"
 	"sort"
 
 	"github.com/hashicorp/terraform/configs/hcl2shim"
@@ -20,6 +21,11 @@ type NamedBlock struct {
 	Name      string
 	Variables Variables
 
+	// Sensitive is true for blocks with values fetched from Vault. The
+	// variable for a sensitive block is declared as sensitive so its values
+	// are redacted from Terraform output.
+	Sensitive bool
+
 	blockKeysCache   []string
 	objectTypeCache  *cty.Type
 	objectValueCache *cty.Value
@@ -125,3 +131,26 @@ func NewNamedBlockTest(b map[string]interface{}) NamedBlock {
 	block.rawConfig = nil
 	return block
 }
+
+// GoString defines the printable version of this struct. The values of
+// sensitive blocks are redacted.
+func (b *NamedBlock) GoString() string {
+	if b == nil {
+		return "(*NamedBlock)(nil)"
+	}
+
+	vars := fmt.Sprintf("%#v", b.Variables)
+	if b.Sensitive {
+		vars = "(redacted)"
+	}
+
+	return fmt.Sprintf("&NamedBlock{"+
+		"Name:%s, "+
+		"Variables:%s, "+
+		"Sensitive:%t"+
+		"}",
+		b.Name,
+		vars,
+		b.Sensitive,
+	)
+}
@@ -0,0 +1,52 @@
+package hcltmpl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestNamedBlock_GoString(t *testing.T) {
+	testCases := []struct {
+		name     string
+		block    *NamedBlock
+		contains string
+		excludes string
+	}{
+		{
+			"nil",
+			nil,
+			"(*NamedBlock)(nil)",
+			"",
+		}, {
+			"not sensitive",
+			&NamedBlock{
+				Name:      "foo",
+				Variables: Variables{"attr": cty.StringVal("value")},
+			},
+			`"value"`,
+			"(redacted)",
+		}, {
+			"sensitive",
+			&NamedBlock{
+				Name:      "foo",
+				Variables: Variables{"token": cty.StringVal("secret")},
+				Sensitive: true,
+			},
+			"Variables:(redacted)",
+			"secret",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := fmt.Sprintf("%#v", tc.block)
+			assert.Contains(t, actual, tc.contains)
+			if tc.excludes != "" {
+				assert.NotContains(t, actual, tc.excludes)
+			}
+		})
+	}
+}
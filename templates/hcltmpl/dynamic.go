@@ -2,6 +2,7 @@ package hcltmpl
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
@@ -15,8 +16,15 @@ import (
 var (
 	dynamicTmplRegexp = regexp.MustCompile(`\{\{\s*(env|key|with secret)\s+\\?\".+\\?\"\s*\}\}`)
 	vaultTmplRegexp   = regexp.MustCompile(`\{\{\s*with secret\s+\\?\".+\\?\"\s*\}\}`)
+
+	// errIncomplete is returned when rendering a dynamic value requires
+	// values that the watcher has not fetched yet.
+	errIncomplete = errors.New("dynamic values are not fetched yet")
 )
 
+// renderFunc renders the template of a dynamic value.
+type renderFunc func(tmpl tmpls.Template) (string, error)
+
 // ContainsDynamicTemplate reports whether the template syntax supported by CTS
 // to load from env, Consul KV, and Vault is within s.
 func ContainsDynamicTemplate(s string) bool {
@@ -34,6 +42,35 @@ func ContainsVaultSecret(s string) bool {
 // configuration.
 func LoadDynamicConfig(ctx context.Context, w tmpls.Watcher, r tmpls.Resolver,
 	config map[string]interface{}) (NamedBlock, error) {
+	return loadDynamicConfig(config, func(tmpl tmpls.Template) (string, error) {
+		return renderDynamicValue(ctx, w, r, tmpl)
+	})
+}
+
+// RenderDynamicConfig renders the dynamic values of a block once with the
+// values the watcher has already fetched, without waiting for values that are
+// not fetched yet. It reports whether all of the values were available. The
+// watcher continues to watch the dependencies of the values, so rendering
+// again after the watcher has new values returns the updated block.
+func RenderDynamicConfig(w tmpls.Watcher, r tmpls.Resolver,
+	config map[string]interface{}) (NamedBlock, bool, error) {
+	block, err := loadDynamicConfig(config, func(tmpl tmpls.Template) (string, error) {
+		re, err := r.Run(tmpl, w)
+		if err != nil {
+			return "", err
+		}
+		if !re.Complete {
+			return "", errIncomplete
+		}
+		return string(re.Contents), nil
+	})
+	if err == errIncomplete {
+		return block, false, nil
+	}
+	return block, err == nil, err
+}
+
+func loadDynamicConfig(config map[string]interface{}, render renderFunc) (NamedBlock, error) {
 	block := NewNamedBlock(config)
 
 	// First pass, check if the block has any templated variables before continuing
@@ -43,10 +80,11 @@ func LoadDynamicConfig(ctx context.Context, w tmpls.Watcher, r tmpls.Resolver,
 	}
 
 	log.Printf("[INFO] (templates.hcltmpl) evaluating dynamic configuration for %q", block.Name)
+	block.Sensitive = ContainsVaultSecret(fmt.Sprint(config))
 
 	// Traverse all variables and nested variables to evaluate any dynamic values
 	for attrName, v := range block.Variables {
-		value, err := dynamicValue(ctx, w, r, v)
+		value, err := dynamicValue(v, render)
 		if err != nil {
 			return block, err
 		}
@@ -56,13 +94,7 @@ func LoadDynamicConfig(ctx context.Context, w tmpls.Watcher, r tmpls.Resolver,
 	return block, nil
 }
 
-func dynamicValue(ctx context.Context, w tmpls.Watcher, r tmpls.Resolver, v cty.Value) (cty.Value, error) {
-	select {
-	case <-ctx.Done():
-		return cty.Value{}, ctx.Err()
-	default:
-	}
-
+func dynamicValue(v cty.Value, render renderFunc) (cty.Value, error) {
 	// Match regex {{ [env|key|secret] ".*" }} to check whether the value
 	// contains template syntax to be evaluated.
 	if !ContainsDynamicTemplate(v.GoString()) {
@@ -83,7 +115,7 @@ func dynamicValue(ctx context.Context, w tmpls.Watcher, r tmpls.Resolver, v cty.
 			Contents:     v.AsString(),
 			FuncMapMerge: tfunc.Env(),
 		})
-		rendered, err := renderDynamicValue(ctx, w, r, tmpl)
+		rendered, err := render(tmpl)
 		if err != nil {
 			return cty.Value{}, err
 		}
@@ -92,7 +124,7 @@ func dynamicValue(ctx context.Context, w tmpls.Watcher, r tmpls.Resolver, v cty.
 	case t.IsListType(), t.IsTupleType():
 		values := v.AsValueSlice()
 		for i, value := range values {
-			dValue, err := dynamicValue(ctx, w, r, value)
+			dValue, err := dynamicValue(value, render)
 			if err != nil {
 				return cty.Value{}, err
 			}
@@ -103,7 +135,7 @@ func dynamicValue(ctx context.Context, w tmpls.Watcher, r tmpls.Resolver, v cty.
 	case t.IsMapType(), t.IsObjectType():
 		values := v.AsValueMap()
 		for attrName, value := range values {
-			dValue, err := dynamicValue(ctx, w, r, value)
+			dValue, err := dynamicValue(value, render)
 			if err != nil {
 				return cty.Value{}, err
 			}
@@ -121,6 +153,12 @@ func dynamicValue(ctx context.Context, w tmpls.Watcher, r tmpls.Resolver, v cty.
 
 func renderDynamicValue(ctx context.Context, w tmpls.Watcher,
 	r tmpls.Resolver, tmpl tmpls.Template) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
 	for {
 		re, err := r.Run(tmpl, w)
 		if err != nil {
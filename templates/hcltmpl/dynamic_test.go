@@ -2,6 +2,7 @@ package hcltmpl
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -152,6 +153,22 @@ func TestLoadDynamicConfig(t *testing.T) {
 					"list": cty.TupleVal([]cty.Value{tmplVal, cty.StringVal("item")}),
 				},
 			},
+		}, {
+			"vault",
+			map[string]interface{}{
+				"foo": map[string]interface{}{
+					"attr":  "value",
+					"token": "{{ with secret \"secret/my/path\" }}{{ .Data.data.foo }}{{ end }}",
+				},
+			},
+			NamedBlock{
+				Name: "foo",
+				Variables: map[string]cty.Value{
+					"attr":  cty.StringVal("value"),
+					"token": tmplVal,
+				},
+				Sensitive: true,
+			},
 		},
 	}
 
@@ -163,6 +180,7 @@ func TestLoadDynamicConfig(t *testing.T) {
 
 			block, err := LoadDynamicConfig(ctxTimeout, w, r, tc.config)
 			assert.NoError(t, err)
+			assert.Equal(t, tc.block.Sensitive, block.Sensitive)
 			assert.Len(t, block.Variables, len(tc.block.Variables))
 			for k, v := range block.Variables {
 				actual := block.Variables[k]
@@ -172,3 +190,62 @@ func TestLoadDynamicConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderDynamicConfig(t *testing.T) {
+	config := map[string]interface{}{
+		"foo": map[string]interface{}{
+			"attr":    "value",
+			"dynamic": "{{ key \"mykey\" }}",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		event    hcat.ResolveEvent
+		err      error
+		complete bool
+		expected cty.Value
+	}{
+		{
+			"complete",
+			hcat.ResolveEvent{Complete: true, Contents: []byte("rendered")},
+			nil,
+			true,
+			cty.StringVal("rendered"),
+		}, {
+			"incomplete",
+			hcat.ResolveEvent{Complete: false},
+			nil,
+			false,
+			cty.StringVal("{{ key \"mykey\" }}"),
+		}, {
+			"error",
+			hcat.ResolveEvent{},
+			errors.New("error"),
+			false,
+			cty.StringVal("{{ key \"mykey\" }}"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := new(mocks.Resolver)
+			r.On("Run", mock.Anything, mock.Anything).Return(tc.event, tc.err)
+
+			// RenderDynamicConfig does not wait on the watcher
+			w := new(mocks.Watcher)
+
+			block, complete, err := RenderDynamicConfig(w, r, config)
+			if tc.err != nil {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.complete, complete)
+			assert.Equal(t, "foo", block.Name)
+			assert.True(t, block.Variables["attr"].Equals(cty.StringVal("value")).True())
+			assert.True(t, block.Variables["dynamic"].Equals(tc.expected).True())
+			w.AssertNotCalled(t, "WaitCh", mock.Anything, mock.Anything)
+		})
+	}
+}
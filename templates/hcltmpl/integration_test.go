@@ -84,7 +84,7 @@ func TestLoadDynamicConfig_Env(t *testing.T) {
 
 func TestLoadDynamicConfig_ConsulKV(t *testing.T) {
 	// Setup Consul server and write to KV
-	srv, err := testutil.NewTestServerConfig(func(c *testutil.TestServerConfig) {
+	srv, err := testutil.NewTestServerConfigT(t, func(c *testutil.TestServerConfig) {
 		c.LogLevel = "warn"
 		c.Stdout = ioutil.Discard
 		c.Stderr = ioutil.Discard
@@ -0,0 +1,104 @@
+package hcltmpl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// interpolationRegexp matches the ${env:NAME} and ${file:PATH} sequences. A
+// sequence escaped with an additional $, like $${env:NAME}, is matched to
+// leave it uninterpolated.
+var interpolationRegexp = regexp.MustCompile(`\$?\$\{(env|file):([^}]*)\}`)
+
+// ContainsInterpolation reports whether the ${env:NAME} or ${file:PATH}
+// sequences are within s.
+func ContainsInterpolation(s string) bool {
+	for _, m := range interpolationRegexp.FindAllString(s, -1) {
+		if !strings.HasPrefix(m, "$$") {
+			return true
+		}
+	}
+	return false
+}
+
+// Interpolate replaces the ${env:NAME} sequences within s with the value of
+// the environment variable and the ${file:PATH} sequences with the content of
+// the file, without trailing newlines. Escaped sequences, like
+// $${env:NAME}, are replaced with the literal sequence. It is an error for
+// the environment variable to be unset or the file to be unreadable.
+func Interpolate(s string) (string, error) {
+	return interpolate(s, false)
+}
+
+// InterpolateHCL replaces the ${env:NAME} and ${file:PATH} sequences within
+// the content of a file in HCL native syntax, like a Terraform variable file.
+// The values are escaped to be literal within quoted strings, and escaped
+// sequences are left for the HCL parser to unescape.
+func InterpolateHCL(content []byte) ([]byte, error) {
+	s, err := interpolate(string(content), true)
+	return []byte(s), err
+}
+
+func interpolate(s string, hcl bool) (string, error) {
+	var err error
+	result := interpolationRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.HasPrefix(m, "$$") {
+			if hcl {
+				return m
+			}
+			return m[1:]
+		}
+		if err != nil {
+			return m
+		}
+
+		parts := interpolationRegexp.FindStringSubmatch(m)
+		var value string
+		value, err = interpolationValue(parts[1], parts[2])
+		if hcl {
+			value = escapeHCLString(value)
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// interpolationValue returns the value of an interpolation sequence.
+func interpolationValue(source, name string) (string, error) {
+	switch source {
+	case "env":
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %q is not set", name)
+		}
+		return value, nil
+
+	case "file":
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("unable to read file for ${file:%s}: %s", name, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return "", fmt.Errorf("unsupported interpolation source %q", source)
+}
+
+// escapeHCLString escapes a value to be literal within a quoted string of HCL
+// native syntax.
+func escapeHCLString(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	).Replace(s)
+}
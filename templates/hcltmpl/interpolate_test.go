@@ -0,0 +1,136 @@
+package hcltmpl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hcltmpl-interpolate-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	err = ioutil.WriteFile(tokenFile, []byte("s3cr3t\n"), 0600)
+	require.NoError(t, err)
+
+	os.Setenv("CTS_INTERPOLATE_TEST", "value")
+	defer os.Unsetenv("CTS_INTERPOLATE_TEST")
+	os.Setenv("CTS_INTERPOLATE_QUOTED", `a "quoted" ${value}`)
+	defer os.Unsetenv("CTS_INTERPOLATE_QUOTED")
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+		hcl      string
+		err      bool
+	}{
+		{
+			"no interpolation",
+			"value",
+			"value",
+			"value",
+			false,
+		}, {
+			"env",
+			"${env:CTS_INTERPOLATE_TEST}",
+			"value",
+			"value",
+			false,
+		}, {
+			"embedded env",
+			"https://${env:CTS_INTERPOLATE_TEST}:8500",
+			"https://value:8500",
+			"https://value:8500",
+			false,
+		}, {
+			"file",
+			"${file:" + tokenFile + "}",
+			"s3cr3t",
+			"s3cr3t",
+			false,
+		}, {
+			"multiple",
+			"${env:CTS_INTERPOLATE_TEST}/${file:" + tokenFile + "}",
+			"value/s3cr3t",
+			"value/s3cr3t",
+			false,
+		}, {
+			"escaped",
+			"$${env:CTS_INTERPOLATE_TEST}",
+			"${env:CTS_INTERPOLATE_TEST}",
+			"$${env:CTS_INTERPOLATE_TEST}",
+			false,
+		}, {
+			"other sequences",
+			"${var.x} {{ env \"X\" }}",
+			"${var.x} {{ env \"X\" }}",
+			"${var.x} {{ env \"X\" }}",
+			false,
+		}, {
+			"special characters",
+			"${env:CTS_INTERPOLATE_QUOTED}",
+			`a "quoted" ${value}`,
+			`a \"quoted\" $${value}`,
+			false,
+		}, {
+			"unset env",
+			"${env:CTS_INTERPOLATE_DNE}",
+			"",
+			"",
+			true,
+		}, {
+			"missing file",
+			"${file:" + filepath.Join(dir, "dne") + "}",
+			"",
+			"",
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Interpolate(tc.value)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+
+			actualHCL, err := InterpolateHCL([]byte(tc.value))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.hcl, string(actualHCL))
+		})
+	}
+}
+
+func TestContainsInterpolation(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected bool
+	}{
+		{"value", false},
+		{"${env:NAME}", true},
+		{"prefix ${file:/path/to/file} suffix", true},
+		{"$${env:NAME}", false},
+		{"$${env:NAME} ${env:OTHER}", true},
+		{"${var.name}", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ContainsInterpolation(tc.value))
+		})
+	}
+}
@@ -100,6 +100,116 @@ func TestNewFiles(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			Name:   "terraform.tfvars.json.tmpl",
+			Func:   NewTFVarsJSONTmpl,
+			Golden: "testdata/terraform.tfvars.json.tmpl",
+			Input: RootModuleInputData{
+				Providers: []hcltmpl.NamedBlock{hcltmpl.NewNamedBlock(
+					map[string]interface{}{
+						"testProvider": map[string]interface{}{
+							"alias": "tp",
+							"attr":  "value with \"quotes\", ${interpolation}, and {{ delims }}",
+							"count": 10,
+						},
+					})},
+				Services: []Service{
+					{
+						Name:        "web",
+						Namespace:   "ns",
+						Datacenter:  "dc1",
+						Description: "web service",
+					}, {
+						Name:        "api",
+						Namespace:   "",
+						Datacenter:  "dc1",
+						Description: "api service for web",
+						Tag:         "tag",
+					},
+				},
+			},
+		}, {
+			Name:   "connect.tfvars.tmpl",
+			Func:   NewTFVarsTmpl,
+			Golden: "testdata/connect.tfvars.tmpl",
+			Input: RootModuleInputData{
+				Services: []Service{
+					{
+						Name:    "web",
+						Connect: true,
+					}, {
+						Name:    "api",
+						Tag:     "tag",
+						Connect: true,
+					},
+				},
+			},
+		}, {
+			Name:   "filter.tfvars.tmpl",
+			Func:   NewTFVarsTmpl,
+			Golden: "testdata/filter.tfvars.tmpl",
+			Input: RootModuleInputData{
+				Services: []Service{
+					{
+						Name:         "web",
+						Tags:         []string{"canary", "v2"},
+						NodeMeta:     map[string]string{"rack": "rack-1", "env": "prod"},
+						HealthStatus: []string{"passing", "warning"},
+					}, {
+						Name:       "api",
+						Datacenter: "dc1",
+						NodeMeta:   map[string]string{"rack": "rack-1"},
+						Filter:     `Service.Meta.version == "v2"`,
+					},
+				},
+			},
+		}, {
+			Name:   "tfvars_template.tfvars.tmpl",
+			Func:   NewTFVarsTmpl,
+			Golden: "testdata/tfvars_template.tfvars.tmpl",
+			Input: RootModuleInputData{
+				Services: []Service{{Name: "api"}},
+				TFVarsTemplate: []byte(`
+ports = [
+{{- range $i, $s := service "api" }}{{ if $i }},{{ end }}{{ $s.Port }}{{ end -}}
+]
+`),
+				TemplateVariables: []string{"ports"},
+			},
+		}, {
+			Name:   "variables.module.tf (tfvars template)",
+			Func:   NewModuleVariablesTF,
+			Golden: "testdata/tfvars_template.variables.module.tf",
+			Input: RootModuleInputData{
+				Variables: hcltmpl.Variables{
+					"num": cty.NumberIntVal(10),
+				},
+				TemplateVariables: []string{"nodes", "ports"},
+			},
+		}, {
+			Name:   "variables.module.tf (sensitive)",
+			Func:   NewModuleVariablesTF,
+			Golden: "testdata/sensitive.variables.module.tf",
+			Input: RootModuleInputData{
+				Variables: hcltmpl.Variables{
+					"password": cty.StringVal("secret"),
+					"username": cty.StringVal("admin"),
+				},
+				TemplateVariables:  []string{"token"},
+				SensitiveVariables: []string{"password", "token"},
+			},
+		}, {
+			Name:   "variables.tf (sensitive)",
+			Func:   NewVariablesTF,
+			Golden: "testdata/sensitive.variables.tf",
+			Input: RootModuleInputData{
+				Providers: []hcltmpl.NamedBlock{sensitiveBlock(hcltmpl.NewNamedBlock(
+					map[string]interface{}{
+						"testProvider": map[string]interface{}{
+							"token": "secret",
+						},
+					}))},
+			},
 		}, {
 			Name:   "variables.module.tf",
 			Func:   NewModuleVariablesTF,
@@ -145,6 +255,12 @@ func TestNewFiles(t *testing.T) {
 	}
 }
 
+// sensitiveBlock marks the block as sensitive, as if it had values from Vault.
+func sensitiveBlock(b hcltmpl.NamedBlock) hcltmpl.NamedBlock {
+	b.Sensitive = true
+	return b
+}
+
 func checkGoldenFile(t *testing.T, goldenFile string, actual []byte) {
 	// update golden files if necessary
 	if *update {
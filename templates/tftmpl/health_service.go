@@ -0,0 +1,387 @@
+package tftmpl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul-terraform-sync/retry"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat"
+	"github.com/hashicorp/hcat/dep"
+)
+
+const (
+	// healthServiceWaitTime is the maximum time of a blocking query for the
+	// instances of a service.
+	healthServiceWaitTime = time.Minute
+
+	// healthServiceRetry is the number of times to retry querying the
+	// instances of a service before the error is returned to the watcher.
+	healthServiceRetry uint = 5
+
+	healthPassing = "passing"
+	healthAny     = "any"
+)
+
+// Parameters of the health service query
+const (
+	queryParamDatacenter = "dc"
+	queryParamNamespace  = "ns"
+	queryParamPartition  = "partition"
+	queryParamTag        = "tag"
+	queryParamNodeMeta   = "node-meta"
+	queryParamFilter     = "filter"
+	queryParamStatus     = "status"
+	queryParamConnect    = "connect"
+)
+
+// queryParams are the supported parameters of the health service query in
+// the order they are encoded.
+var queryParams = []string{
+	queryParamDatacenter,
+	queryParamNamespace,
+	queryParamPartition,
+	queryParamTag,
+	queryParamNodeMeta,
+	queryParamFilter,
+	queryParamStatus,
+	queryParamConnect,
+}
+
+// serviceInstance is a service instance returned by the health service query.
+// It extends the hcat health service with the values that hcat does not
+// return.
+type serviceInstance struct {
+	dep.HealthService
+
+	Partition       string
+	Kind            string
+	TaggedAddresses map[string]api.ServiceAddress
+
+	// Proxy is the configuration of a Connect proxy instance, which is nil for
+	// instances that are not proxies.
+	Proxy *api.AgentServiceConnectProxyConfig
+}
+
+// healthServiceQuery is a dependency for the instances of a service from the
+// Consul health API. Unlike the hcat `service` query, the namespace and admin
+// partition are sent to Consul, so instances of services with the same name in
+// different namespaces and partitions are queried separately. Tags, node
+// metadata, and the filter expression are also evaluated by Consul.
+//
+// The query is a service name with URL encoded parameters:
+//
+//	web?dc=dc1&ns=team-a&partition=infra&tag=canary&tag=v2&node-meta=rack:rack-1&status=passing,warning&connect=true
+type healthServiceQuery struct {
+	name      string
+	dc        string
+	ns        string
+	partition string
+	tags      []string
+	nodeMeta  map[string]string
+	filter    string
+	status    []string
+	connect   bool
+
+	// lastIndex is the Consul index of the last response for blocking
+	// queries. hcat only passes the index to its own dependencies, so the
+	// query tracks the index itself. hcat fetches a dependency from one
+	// goroutine at a time.
+	lastIndex uint64
+	retry     retry.Retry
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newHealthServiceQuery parses the query for the instances of a service.
+func newHealthServiceQuery(s string) (*healthServiceQuery, error) {
+	name, rawParams := s, ""
+	if i := strings.Index(s, "?"); i >= 0 {
+		name, rawParams = s[:i], s[i+1:]
+	}
+	if name == "" {
+		return nil, fmt.Errorf("health.service: missing service name in %q", s)
+	}
+
+	params, err := url.ParseQuery(rawParams)
+	if err != nil {
+		return nil, fmt.Errorf("health.service: invalid parameters in %q: %s", s, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &healthServiceQuery{
+		name:      name,
+		dc:        params.Get(queryParamDatacenter),
+		ns:        params.Get(queryParamNamespace),
+		partition: params.Get(queryParamPartition),
+		tags:      params[queryParamTag],
+		filter:    params.Get(queryParamFilter),
+		retry:     retry.NewRetry(healthServiceRetry, time.Now().UnixNano()),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	for key := range params {
+		if !supportedQueryParam(key) {
+			cancel()
+			return nil, fmt.Errorf("health.service: unsupported parameter %q in %q",
+				key, s)
+		}
+	}
+
+	for _, meta := range params[queryParamNodeMeta] {
+		split := strings.SplitN(meta, ":", 2)
+		if len(split) != 2 {
+			cancel()
+			return nil, fmt.Errorf("health.service: invalid node-meta %q in %q, "+
+				"expected key:value", meta, s)
+		}
+		if q.nodeMeta == nil {
+			q.nodeMeta = make(map[string]string)
+		}
+		q.nodeMeta[split[0]] = split[1]
+	}
+
+	if status := params.Get(queryParamStatus); status != "" {
+		q.status = strings.Split(status, ",")
+		sort.Strings(q.status)
+	} else {
+		q.status = []string{healthPassing}
+	}
+
+	if connect := params.Get(queryParamConnect); connect != "" {
+		q.connect = connect == "true"
+	}
+
+	return q, nil
+}
+
+// supportedQueryParam returns whether the parameter is supported by the
+// health service query.
+func supportedQueryParam(key string) bool {
+	for _, p := range queryParams {
+		if key == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch queries the instances of the service from Consul. Requests are
+// blocking queries from the index of the last response. Failed requests are
+// retried a limited number of times before the error is returned, which the
+// hcat view retries again before sending the error to the watcher.
+func (d *healthServiceQuery) Fetch(clients dep.Clients) (interface{}, *dep.ResponseMetadata, error) {
+	opts := (&api.QueryOptions{
+		Datacenter: d.dc,
+		Namespace:  d.ns,
+		Partition:  d.partition,
+		NodeMeta:   d.nodeMeta,
+		Filter:     d.filter,
+		WaitIndex:  d.lastIndex,
+		WaitTime:   healthServiceWaitTime,
+	}).WithContext(d.ctx)
+
+	// Only passing instances are filtered by Consul. Other statuses are
+	// filtered by the aggregated status of the checks of each instance.
+	passingOnly := len(d.status) == 1 && d.status[0] == healthPassing
+
+	var entries []*api.ServiceEntry
+	var qm *api.QueryMeta
+	fetch := func(context.Context) error {
+		health := clients.Consul().Health()
+		query := health.ServiceMultipleTags
+		if d.connect {
+			query = health.ConnectMultipleTags
+		}
+
+		var err error
+		entries, qm, err = query(d.name, d.tags, passingOnly, opts)
+		return err
+	}
+
+	if err := d.retry.Do(d.ctx, fetch, d.String()); err != nil {
+		if d.ctx.Err() != nil {
+			return nil, nil, dep.ErrStopped
+		}
+		return nil, nil, fmt.Errorf("error querying %s: %s", d, err)
+	}
+
+	// Reset the index if it goes backwards, as recommended for blocking
+	// queries
+	if qm.LastIndex < d.lastIndex {
+		d.lastIndex = 0
+	} else {
+		d.lastIndex = qm.LastIndex
+	}
+
+	instances := make([]*serviceInstance, 0, len(entries))
+	for _, entry := range entries {
+		status := entry.Checks.AggregatedStatus()
+		if !acceptStatus(d.status, status) {
+			continue
+		}
+		instances = append(instances, newServiceInstance(entry, status))
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		if instances[i].Node == instances[j].Node {
+			return instances[i].ID < instances[j].ID
+		}
+		return instances[i].Node < instances[j].Node
+	})
+
+	return instances, &dep.ResponseMetadata{
+		LastIndex:   qm.LastIndex,
+		LastContact: qm.LastContact,
+	}, nil
+}
+
+// newServiceInstance converts a service entry from the Consul health API.
+func newServiceInstance(entry *api.ServiceEntry, status string) *serviceInstance {
+	// The address of the service defaults to the address of the node
+	address := entry.Service.Address
+	if address == "" {
+		address = entry.Node.Address
+	}
+
+	tags := make([]string, len(entry.Service.Tags))
+	copy(tags, entry.Service.Tags)
+	sort.Strings(tags)
+
+	return &serviceInstance{
+		HealthService: dep.HealthService{
+			Node:                entry.Node.Node,
+			NodeID:              entry.Node.ID,
+			NodeAddress:         entry.Node.Address,
+			NodeDatacenter:      entry.Node.Datacenter,
+			NodeTaggedAddresses: entry.Node.TaggedAddresses,
+			NodeMeta:            entry.Node.Meta,
+			ServiceMeta:         entry.Service.Meta,
+			Address:             address,
+			ID:                  entry.Service.ID,
+			Name:                entry.Service.Service,
+			Tags:                dep.ServiceTags(tags),
+			Status:              status,
+			Checks:              entry.Checks,
+			Port:                entry.Service.Port,
+			Weights:             entry.Service.Weights,
+			Namespace:           entry.Service.Namespace,
+		},
+		Partition:       entry.Service.Partition,
+		Kind:            string(entry.Service.Kind),
+		TaggedAddresses: entry.Service.TaggedAddresses,
+		Proxy:           entry.Service.Proxy,
+	}
+}
+
+// acceptStatus returns whether the aggregated status of an instance is one of
+// the statuses to filter instances by.
+func acceptStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status || s == healthAny {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop stops the query, including a blocking request in progress.
+func (d *healthServiceQuery) Stop() {
+	d.cancel()
+}
+
+// String returns the ID of the query. The parameters are encoded in a fixed
+// order so equal queries have the same ID and are watched once.
+func (d *healthServiceQuery) String() string {
+	return fmt.Sprintf("health.service(%s)", d.query())
+}
+
+// query encodes the query for the instances of the service.
+func (d *healthServiceQuery) query() string {
+	params := make(url.Values)
+	if d.dc != "" {
+		params.Set(queryParamDatacenter, d.dc)
+	}
+	if d.ns != "" {
+		params.Set(queryParamNamespace, d.ns)
+	}
+	if d.partition != "" {
+		params.Set(queryParamPartition, d.partition)
+	}
+	params[queryParamTag] = d.tags
+
+	keys := make([]string, 0, len(d.nodeMeta))
+	for k := range d.nodeMeta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		params.Add(queryParamNodeMeta, k+":"+d.nodeMeta[k])
+	}
+
+	if d.filter != "" {
+		params.Set(queryParamFilter, d.filter)
+	}
+
+	params.Set(queryParamStatus, strings.Join(d.status, ","))
+	if d.connect {
+		params.Set(queryParamConnect, "true")
+	}
+
+	return encodeQuery(d.name, params)
+}
+
+// encodeQuery encodes the service name and the parameters in the order of
+// queryParams. Values are escaped only where needed to be parsed as URL
+// query parameters, which keeps queries readable in generated templates.
+func encodeQuery(name string, params url.Values) string {
+	var parts []string
+	for _, key := range queryParams {
+		for _, v := range params[key] {
+			parts = append(parts, key+"="+escapeQueryValue(v))
+		}
+	}
+	if len(parts) == 0 {
+		return name
+	}
+	return name + "?" + strings.Join(parts, "&")
+}
+
+// escapeQueryValue percent-encodes the characters of a query parameter value
+// that would otherwise change how the value is parsed.
+func escapeQueryValue(v string) string {
+	var sb strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c < 0x21 || c > 0x7e || strings.IndexByte("%&+;=#?", c) >= 0 {
+			fmt.Fprintf(&sb, "%%%02X", c)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// serviceInstancesFunc returns the template function to query the instances
+// of a service with the health service query.
+//
+//	{{ serviceInstances "web?ns=team-a&partition=infra" }}
+func serviceInstancesFunc(recall hcat.Recaller) interface{} {
+	return func(s string) ([]*serviceInstance, error) {
+		d, err := newHealthServiceQuery(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if value, ok := recall(d); ok {
+			return value.([]*serviceInstance), nil
+		}
+		return []*serviceInstance{}, nil
+	}
+}
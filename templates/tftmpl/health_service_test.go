@@ -0,0 +1,236 @@
+package tftmpl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-terraform-sync/retry"
+	consulapi "github.com/hashicorp/consul/api"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHealthServiceQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			"name",
+			"api",
+			"health.service(api?status=passing)",
+		}, {
+			"all parameters",
+			"api?connect=true&status=warning,passing&filter=Service.Port%20%3D%3D%2080&node-meta=rack:rack-1&node-meta=env:prod&tag=v2&partition=infra&ns=team-a&dc=dc1",
+			"health.service(api?dc=dc1&ns=team-a&partition=infra&tag=v2&node-meta=env:prod&node-meta=rack:rack-1&filter=Service.Port%20%3D%3D%2080&status=passing,warning&connect=true)",
+		}, {
+			"escaped values",
+			"api?tag=a%26b%3Dc&node-meta=key:a%20b",
+			"health.service(api?tag=a%26b%3Dc&node-meta=key:a%20b&status=passing)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := newHealthServiceQuery(tc.query)
+			require.NoError(t, err)
+			defer q.Stop()
+			assert.Equal(t, tc.expected, q.String())
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		for _, query := range []string{
+			"",
+			"?dc=dc1",
+			"api?unknown=value",
+			"api?node-meta=rack",
+			"api?tag=%zz",
+		} {
+			_, err := newHealthServiceQuery(query)
+			assert.Error(t, err, query)
+		}
+	})
+}
+
+func TestService_TemplateServiceID(t *testing.T) {
+	s := Service{
+		Name:         "api",
+		Datacenter:   "dc1",
+		Namespace:    "team-a",
+		Partition:    "infra",
+		Tags:         []string{"v2"},
+		NodeMeta:     map[string]string{"rack": "rack-1"},
+		HealthStatus: []string{"warning", "passing"},
+		Connect:      true,
+	}
+
+	// The ID of the service matches the ID of the dependency queried by the
+	// template, which is how the watcher recalls the instances
+	q, err := newHealthServiceQuery(s.TemplateServiceID())
+	require.NoError(t, err)
+	defer q.Stop()
+	assert.Equal(t, "health.service("+s.TemplateServiceID()+")", q.String())
+	assert.Equal(t, "api?dc=dc1&ns=team-a&partition=infra&tag=v2&node-meta=rack:rack-1&status=passing,warning&connect=true",
+		s.TemplateServiceID())
+}
+
+func TestHealthServiceQuery_Fetch(t *testing.T) {
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		w.Header().Set("X-Consul-Index", "10")
+		json.NewEncoder(w).Encode([]*consulapi.ServiceEntry{
+			{
+				Node: &consulapi.Node{Node: "node-b", Address: "10.0.0.2"},
+				Service: &consulapi.AgentService{
+					ID: "api-2", Service: "api", Namespace: "team-a",
+					Partition: "infra", Tags: []string{"v2", "a"},
+				},
+				Checks: consulapi.HealthChecks{{Status: "warning"}},
+			},
+			{
+				Node: &consulapi.Node{Node: "node-a", Address: "10.0.0.1"},
+				Service: &consulapi.AgentService{
+					ID: "api-1", Service: "api", Namespace: "team-a",
+					Partition: "infra", Address: "10.1.0.1",
+					TaggedAddresses: map[string]consulapi.ServiceAddress{
+						"virtual": {Address: "240.0.0.1", Port: 80},
+					},
+				},
+				Checks: consulapi.HealthChecks{{Status: "passing"}},
+			},
+			{
+				Node:    &consulapi.Node{Node: "node-c"},
+				Service: &consulapi.AgentService{ID: "api-3", Service: "api"},
+				Checks:  consulapi.HealthChecks{{Status: "critical"}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	client, err := consulapi.NewClient(&consulapi.Config{Address: srv.URL})
+	require.NoError(t, err)
+
+	q, err := newHealthServiceQuery(
+		"api?ns=team-a&partition=infra&tag=v2&node-meta=rack:rack-1&filter=Service.Meta.version%20%3D%3D%20%22v2%22&status=passing,warning")
+	require.NoError(t, err)
+	defer q.Stop()
+
+	data, rm, err := q.Fetch(testClients{client})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), rm.LastIndex)
+
+	instances := data.([]*serviceInstance)
+	require.Len(t, instances, 2)
+	assert.Equal(t, "api-1", instances[0].ID)
+	assert.Equal(t, "10.1.0.1", instances[0].Address)
+	assert.Equal(t, "passing", instances[0].Status)
+	assert.Equal(t, "infra", instances[0].Partition)
+	assert.Equal(t, "team-a", instances[0].Namespace)
+	assert.Equal(t, map[string]consulapi.ServiceAddress{
+		"virtual": {Address: "240.0.0.1", Port: 80},
+	}, instances[0].TaggedAddresses)
+	assert.Equal(t, "api-2", instances[1].ID)
+	assert.Equal(t, "10.0.0.2", instances[1].Address)
+	assert.Equal(t, []string{"a", "v2"}, []string(instances[1].Tags))
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/v1/health/service/api", requests[0].URL.Path)
+	assert.Equal(t, url.Values{
+		"ns":        {"team-a"},
+		"partition": {"infra"},
+		"tag":       {"v2"},
+		"node-meta": {"rack:rack-1"},
+		"filter":    {`Service.Meta.version == "v2"`},
+		"wait":      {"60000ms"},
+	}, requests[0].URL.Query())
+
+	// The next request blocks from the index of the last response
+	_, _, err = q.Fetch(testClients{client})
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.Equal(t, "10", requests[1].URL.Query().Get("index"))
+	assert.Equal(t, "/v1/health/service/api", requests[1].URL.Path)
+}
+
+func TestHealthServiceQuery_Fetch_connect(t *testing.T) {
+	var path string
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		w.Header().Set("X-Consul-Index", "1")
+		json.NewEncoder(w).Encode([]*consulapi.ServiceEntry{{
+			Node: &consulapi.Node{Node: "node"},
+			Service: &consulapi.AgentService{
+				Kind:    consulapi.ServiceKindConnectProxy,
+				ID:      "api-sidecar-proxy",
+				Service: "api-sidecar-proxy",
+				Port:    21000,
+				Proxy: &consulapi.AgentServiceConnectProxyConfig{
+					DestinationServiceName: "api",
+					LocalServicePort:       8080,
+				},
+			},
+			Checks: consulapi.HealthChecks{{Status: "passing"}},
+		}})
+	}))
+	defer srv.Close()
+
+	client, err := consulapi.NewClient(&consulapi.Config{Address: srv.URL})
+	require.NoError(t, err)
+
+	q, err := newHealthServiceQuery("api?dc=dc2&connect=true")
+	require.NoError(t, err)
+	defer q.Stop()
+
+	data, _, err := q.Fetch(testClients{client})
+	require.NoError(t, err)
+	instances := data.([]*serviceInstance)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "connect-proxy", instances[0].Kind)
+	assert.Equal(t, 21000, instances[0].Port)
+	require.NotNil(t, instances[0].Proxy)
+	assert.Equal(t, "api", instances[0].Proxy.DestinationServiceName)
+	assert.Equal(t, 8080, instances[0].Proxy.LocalServicePort)
+	assert.Equal(t, "/v1/health/connect/api", path)
+	assert.Equal(t, "dc2", query.Get("dc"))
+	assert.Equal(t, "1", query.Get("passing"))
+}
+
+func TestHealthServiceQuery_Fetch_error(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client, err := consulapi.NewClient(&consulapi.Config{Address: srv.URL})
+	require.NoError(t, err)
+
+	q, err := newHealthServiceQuery("api")
+	require.NoError(t, err)
+	defer q.Stop()
+	q.retry = retry.NewRetryBackoff(2, time.Millisecond, 0)
+
+	// The error is returned to the watcher after the retries are exhausted
+	_, _, err = q.Fetch(testClients{client})
+	assert.Error(t, err)
+	assert.Equal(t, 3, requests)
+}
+
+// testClients are the clients of a dependency with a Consul client.
+type testClients struct {
+	consul *consulapi.Client
+}
+
+func (c testClients) Consul() *consulapi.Client { return c.consul }
+
+func (c testClients) Vault() *vaultapi.Client { return nil }
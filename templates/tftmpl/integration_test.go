@@ -143,7 +143,7 @@ func TestRenderTFVarsTmpl(t *testing.T) {
 
 			// Setup Consul server
 			log.SetOutput(ioutil.Discard)
-			srv, err := testutil.NewTestServerConfig(func(c *testutil.TestServerConfig) {
+			srv, err := testutil.NewTestServerConfigT(t, func(c *testutil.TestServerConfig) {
 				c.LogLevel = "warn"
 				c.Stdout = ioutil.Discard
 				c.Stderr = ioutil.Discard
@@ -0,0 +1,214 @@
+package tftmpl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ModuleVariable is an input variable declared by a Terraform module.
+type ModuleVariable struct {
+	Name string
+
+	// Type is the type constraint of the variable. Variables without a type
+	// constraint have the type cty.DynamicPseudoType.
+	Type cty.Type
+
+	// Required is true for variables without a default value.
+	Required bool
+}
+
+var (
+	moduleSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "variable", LabelNames: []string{"name"}},
+		},
+	}
+
+	variableSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "type"},
+			{Name: "default"},
+		},
+	}
+)
+
+// LoadModuleInputs inspects the Terraform configuration files within a module
+// directory and returns the input variables declared by the module. Override
+// files are not merged with the module configuration.
+func LoadModuleInputs(dir string) (map[string]ModuleVariable, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := hclparse.NewParser()
+	inputs := make(map[string]ModuleVariable)
+	var diags hcl.Diagnostics
+	for _, info := range files {
+		name := info.Name()
+		if info.IsDir() || isOverrideFile(name) {
+			continue
+		}
+
+		var f *hcl.File
+		var diag hcl.Diagnostics
+		path := filepath.Join(dir, name)
+		switch {
+		case strings.HasSuffix(name, ".tf"):
+			f, diag = p.ParseHCLFile(path)
+		case strings.HasSuffix(name, ".tf.json"):
+			f, diag = p.ParseJSONFile(path)
+		default:
+			continue
+		}
+		diags = diags.Extend(diag)
+		if diag.HasErrors() {
+			continue
+		}
+
+		diags = diags.Extend(decodeModuleInputs(f.Body, inputs))
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return inputs, nil
+}
+
+// ValidateModuleInputs checks the variables assigned to a module by a task
+// against the input variables declared by the module. The services variable
+// and the variables from variable files must be declared with compatible
+// types, and the required variables of the module must be assigned. The
+// values of the variables assigned by the tfvars template are not known
+// until rendered, so they are only checked to be declared.
+func ValidateModuleInputs(inputs map[string]ModuleVariable, vars hcltmpl.Variables,
+	templateVars []string) error {
+	var problems []string
+
+	services, ok := inputs["services"]
+	switch {
+	case !ok:
+		problems = append(problems, `module does not declare the "services" `+
+			`variable for the Consul services of the task`)
+	case convert.GetConversionUnsafe(servicesType(), services.Type) == nil:
+		problems = append(problems, fmt.Sprintf(`type of the "services" `+
+			`variable %s is not compatible with service definition protocol v1`,
+			services.Type.FriendlyName()))
+	}
+
+	for _, name := range vars.Keys() {
+		input, ok := inputs[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("variable %q is not "+
+				"declared by the module", name))
+			continue
+		}
+		if _, err := convert.Convert(vars[name], input.Type); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid value for "+
+				"variable %q: %s", name, err))
+		}
+	}
+
+	assigned := map[string]bool{"services": true}
+	for name := range vars {
+		assigned[name] = true
+	}
+	for _, name := range templateVars {
+		assigned[name] = true
+		if _, ok := inputs[name]; !ok {
+			problems = append(problems, fmt.Sprintf("variable %q assigned by "+
+				"the tfvars template is not declared by the module", name))
+		}
+	}
+
+	required := make([]string, 0, len(inputs))
+	for name, input := range inputs {
+		if input.Required && !assigned[name] {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	for _, name := range required {
+		problems = append(problems, fmt.Sprintf("required variable %q of the "+
+			"module is not assigned", name))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid variables for the module:\n  * %s",
+		strings.Join(problems, "\n  * "))
+}
+
+// decodeModuleInputs decodes the variable blocks of a module body into the
+// inputs.
+func decodeModuleInputs(body hcl.Body, inputs map[string]ModuleVariable) hcl.Diagnostics {
+	content, _, diags := body.PartialContent(moduleSchema)
+	for _, block := range content.Blocks {
+		attrs, _, diag := block.Body.PartialContent(variableSchema)
+		diags = diags.Extend(diag)
+
+		input := ModuleVariable{
+			Name:     block.Labels[0],
+			Type:     cty.DynamicPseudoType,
+			Required: attrs.Attributes["default"] == nil,
+		}
+		if attr, ok := attrs.Attributes["type"]; ok {
+			ty, diag := variableTypeConstraint(attr.Expr)
+			diags = diags.Extend(diag)
+			input.Type = ty
+		}
+		inputs[input.Name] = input
+	}
+	return diags
+}
+
+// variableTypeConstraint decodes the type constraint of a variable, including
+// the quoted type constraints supported by Terraform for compatibility with
+// Terraform 0.11.
+func variableTypeConstraint(expr hcl.Expression) (cty.Type, hcl.Diagnostics) {
+	val, diags := expr.Value(nil)
+	if !diags.HasErrors() && val.Type() == cty.String && val.IsKnown() &&
+		!val.IsNull() {
+		switch val.AsString() {
+		case "string":
+			return cty.String, nil
+		case "list":
+			return cty.List(cty.DynamicPseudoType), nil
+		case "map":
+			return cty.Map(cty.DynamicPseudoType), nil
+		}
+	}
+	return typeexpr.TypeConstraint(expr)
+}
+
+// servicesType returns the type of the services variable for the service
+// definition protocol declared by VariableServices.
+func servicesType() cty.Type {
+	f, diags := hclparse.NewParser().ParseHCL(VariableServices, VarsFilename)
+	if diags.HasErrors() {
+		panic(diags)
+	}
+
+	inputs := make(map[string]ModuleVariable)
+	if diags := decodeModuleInputs(f.Body, inputs); diags.HasErrors() {
+		panic(diags)
+	}
+	return inputs["services"].Type
+}
+
+// isOverrideFile reports whether the file is a Terraform override file.
+func isOverrideFile(name string) bool {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".json"), ".tf")
+	return name == "override" || strings.HasSuffix(name, "_override")
+}
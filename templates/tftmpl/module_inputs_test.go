@@ -0,0 +1,224 @@
+package tftmpl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestLoadModuleInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tftmpl-module-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"main.tf": `
+resource "null_resource" "services" {
+  for_each = var.services
+}`,
+		"variables.tf": `
+variable "services" {
+  type = map(object({
+    id      = string
+    address = string
+    port    = number
+  }))
+}
+
+variable "any" {
+  default = null
+}
+
+variable "legacy" {
+  type    = "string"
+  default = "value"
+}`,
+		"variables.tf.json": `{
+  "variable": {
+    "tags": {
+      "type": "list(string)"
+    }
+  }
+}`,
+		"override.tf":           `variable "override" {}`,
+		"variables_override.tf": `variable "override" {}`,
+		"README.md":             `variable "readme" {}`,
+	}
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "modules"), 0755))
+
+	inputs, err := LoadModuleInputs(dir)
+	require.NoError(t, err)
+
+	expected := map[string]ModuleVariable{
+		"services": {
+			Name: "services",
+			Type: cty.Map(cty.Object(map[string]cty.Type{
+				"id":      cty.String,
+				"address": cty.String,
+				"port":    cty.Number,
+			})),
+			Required: true,
+		},
+		"any": {
+			Name: "any",
+			Type: cty.DynamicPseudoType,
+		},
+		"legacy": {
+			Name: "legacy",
+			Type: cty.String,
+		},
+		"tags": {
+			Name:     "tags",
+			Type:     cty.List(cty.String),
+			Required: true,
+		},
+	}
+	assert.Equal(t, expected, inputs)
+
+	t.Run("invalid", func(t *testing.T) {
+		err := ioutil.WriteFile(filepath.Join(dir, "invalid.tf"),
+			[]byte(`variable "invalid" { type = strin }`), 0644)
+		require.NoError(t, err)
+
+		_, err = LoadModuleInputs(dir)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing dir", func(t *testing.T) {
+		_, err := LoadModuleInputs(filepath.Join(dir, "dne"))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateModuleInputs(t *testing.T) {
+	services := ModuleVariable{
+		Name: "services",
+		Type: cty.Map(cty.Object(map[string]cty.Type{
+			"id":      cty.String,
+			"address": cty.String,
+			"port":    cty.Number,
+			"meta":    cty.Map(cty.String),
+		})),
+		Required: true,
+	}
+
+	testCases := []struct {
+		name         string
+		inputs       map[string]ModuleVariable
+		vars         hcltmpl.Variables
+		templateVars []string
+		errs         []string
+	}{
+		{
+			"services only",
+			map[string]ModuleVariable{"services": services},
+			nil,
+			nil,
+			nil,
+		}, {
+			"services any type",
+			map[string]ModuleVariable{"services": {
+				Name: "services",
+				Type: cty.DynamicPseudoType,
+			}},
+			nil,
+			nil,
+			nil,
+		}, {
+			"variables",
+			map[string]ModuleVariable{
+				"services": services,
+				"count":    {Name: "count", Type: cty.Number, Required: true},
+				"tags":     {Name: "tags", Type: cty.List(cty.String)},
+				"ports":    {Name: "ports", Type: cty.DynamicPseudoType, Required: true},
+				"optional": {Name: "optional", Type: cty.String},
+			},
+			hcltmpl.Variables{
+				"count": cty.StringVal("3"),
+				"tags":  cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+			},
+			[]string{"ports"},
+			nil,
+		}, {
+			"missing services",
+			map[string]ModuleVariable{},
+			nil,
+			nil,
+			[]string{`module does not declare the "services" variable`},
+		}, {
+			"incompatible services",
+			map[string]ModuleVariable{"services": {
+				Name: "services",
+				Type: cty.Map(cty.Object(map[string]cty.Type{
+					"service_name": cty.String,
+				})),
+			}},
+			nil,
+			nil,
+			[]string{`type of the "services" variable map of object is not compatible`},
+		}, {
+			"undeclared variables",
+			map[string]ModuleVariable{"services": services},
+			hcltmpl.Variables{"typo": cty.NumberIntVal(1)},
+			[]string{"ports"},
+			[]string{
+				`variable "typo" is not declared by the module`,
+				`variable "ports" assigned by the tfvars template is not declared`,
+			},
+		}, {
+			"invalid type",
+			map[string]ModuleVariable{
+				"services": services,
+				"count":    {Name: "count", Type: cty.Number},
+			},
+			hcltmpl.Variables{"count": cty.StringVal("three")},
+			nil,
+			[]string{`invalid value for variable "count": a number is required`},
+		}, {
+			"missing required",
+			map[string]ModuleVariable{
+				"services": services,
+				"b":        {Name: "b", Type: cty.String, Required: true},
+				"a":        {Name: "a", Type: cty.String, Required: true},
+			},
+			nil,
+			nil,
+			[]string{
+				`required variable "a" of the module is not assigned`,
+				`required variable "b" of the module is not assigned`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateModuleInputs(tc.inputs, tc.vars, tc.templateVars)
+			if len(tc.errs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			for _, e := range tc.errs {
+				assert.Contains(t, err.Error(), e)
+			}
+		})
+	}
+}
+
+func TestServicesType(t *testing.T) {
+	ty := servicesType()
+	require.True(t, ty.IsMapType())
+	assert.True(t, ty.ElementType().IsObjectType())
+	assert.True(t, ty.ElementType().HasAttribute("checks"))
+}
@@ -25,9 +25,16 @@ func LoadModuleVariables(filePath string) (hcltmpl.Variables, error) {
 }
 
 // ParseModuleVariables parses bytes representing Terraform input variables
-// for a module. It encodes the content into cty.Value types. Invalid HCL
-// syntax and unsupported Terraform variable types result in an error.
+// for a module. It encodes the content into cty.Value types. The
+// ${env:NAME} and ${file:PATH} sequences are interpolated before parsing.
+// Invalid HCL syntax and unsupported Terraform variable types result in an
+// error.
 func ParseModuleVariables(content []byte, filename string) (hcltmpl.Variables, error) {
+	content, err := hcltmpl.InterpolateHCL(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+
 	p := hclparse.NewParser()
 
 	hclFile, diag := p.ParseHCL(content, filename)
@@ -74,7 +81,7 @@ func NewModuleVariablesTF(w io.Writer, input *RootModuleInputData) error {
 	rootBody := hclFile.Body()
 	rootBody.AppendNewline()
 
-	lastIdx := len(input.Variables) - 1
+	lastIdx := len(input.Variables) + len(input.TemplateVariables) - 1
 	for i, name := range input.Variables.Keys() {
 		v := input.Variables[name]
 		vType := v.Type()
@@ -88,11 +95,30 @@ func NewModuleVariablesTF(w io.Writer, input *RootModuleInputData) error {
 			Bytes: []byte(rawTypeAttr),
 		}})
 		vBody.AppendNewline()
+		if input.isSensitive(name) {
+			vBody.SetAttributeValue("sensitive", cty.True)
+		}
 		if i != lastIdx {
 			rootBody.AppendNewline()
 		}
 	}
 
+	// Variables assigned by the tfvars template are not known until rendered
+	// and are declared without a type constraint.
+	offset := len(input.Variables)
+	for i, name := range input.TemplateVariables {
+		vBody := rootBody.AppendNewBlock("variable", []string{name}).Body()
+		vBody.SetAttributeValue("default", cty.NullVal(cty.DynamicPseudoType))
+		vBody.SetAttributeValue("description", cty.StringVal(
+			"Variable assigned by the tfvars template for the task"))
+		if input.isSensitive(name) {
+			vBody.SetAttributeValue("sensitive", cty.True)
+		}
+		if offset+i != lastIdx {
+			rootBody.AppendNewline()
+		}
+	}
+
 	// Format the file before writing
 	content := hclFile.Bytes()
 	content = hclwrite.Format(content)
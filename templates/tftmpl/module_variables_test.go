@@ -1,6 +1,7 @@
 package tftmpl
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,6 +9,9 @@ import (
 )
 
 func TestParseVariables(t *testing.T) {
+	os.Setenv("CTS_VARIABLES_TEST", `say "hi"`)
+	defer os.Unsetenv("CTS_VARIABLES_TEST")
+
 	testCases := []struct {
 		name    string
 		content []byte
@@ -28,6 +32,22 @@ obj = {
 l = [1, 2, 3]
 tup = ["abc", 123, true]`),
 			false,
+		}, {
+			"interpolation",
+			[]byte(`
+b = true
+key = "${env:CTS_VARIABLES_TEST}"
+num = 10
+obj = {
+  escaped = "$${env:CTS_VARIABLES_TEST}"
+}
+l = [1, 2, 3]
+tup = ["abc", 123, true]`),
+			false,
+		}, {
+			"interpolation unset env",
+			[]byte(`key = "${env:CTS_VARIABLES_DNE}"`),
+			true,
 		}, {
 			"unsupported type",
 			[]byte(`b = true + 1`),
@@ -50,4 +70,13 @@ tup = ["abc", 123, true]`),
 			assert.Len(t, vars, 6)
 		})
 	}
+
+	t.Run("interpolated values", func(t *testing.T) {
+		vars, err := ParseModuleVariables([]byte(`
+key = "${env:CTS_VARIABLES_TEST}"
+escaped = "$${env:CTS_VARIABLES_TEST}"`), "filename")
+		require.NoError(t, err)
+		assert.Equal(t, `say "hi"`, vars["key"].AsString())
+		assert.Equal(t, "${env:CTS_VARIABLES_TEST}", vars["escaped"].AsString())
+	})
 }
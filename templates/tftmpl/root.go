@@ -39,6 +39,18 @@ const (
 
 	// TFVarsTmplFilename is the template file for TFVarsFilename
 	TFVarsTmplFilename = "terraform.tfvars.tmpl"
+
+	// TFVarsJSONFilename is the file name for input variables using the JSON
+	// syntax, used in place of TFVarsFilename for the JSON tfvars format.
+	TFVarsJSONFilename = "terraform.tfvars.json"
+
+	// TFVarsJSONTmplFilename is the template file for TFVarsJSONFilename
+	TFVarsJSONTmplFilename = "terraform.tfvars.json.tmpl"
+
+	// TFVarsFormatHCL and TFVarsFormatJSON are the supported formats for the
+	// generated input variables file. HCL is the default format.
+	TFVarsFormatHCL  = "hcl"
+	TFVarsFormatJSON = "json"
 )
 
 var (
@@ -57,7 +69,6 @@ var (
 		RootFilename:       NewMainTF,
 		VarsFilename:       NewVariablesTF,
 		ModuleVarsFilename: NewModuleVariablesTF,
-		TFVarsTmplFilename: NewTFVarsTmpl,
 	}
 )
 
@@ -75,22 +86,62 @@ type Service struct {
 	Description string
 	Name        string
 	Namespace   string
+	Partition   string
 	Tag         string
+
+	// Tags and NodeMeta filter the service instances to those that have all
+	// of the tags and node metadata key/value pairs.
+	Tags     []string
+	NodeMeta map[string]string
+
+	// HealthStatus filters the service instances by their aggregated health
+	// status. Only passing instances are included when empty.
+	HealthStatus []string
+
+	// Filter is a Consul filter expression to filter the service instances.
+	Filter string
+
+	// Connect queries the Connect-capable instances of the service, like
+	// sidecar proxies, instead of the service instances.
+	Connect bool
 }
 
-// TODO incorporate namespace
+// TemplateServiceID returns the health service query of the service. The
+// namespace, admin partition, tags, node metadata, and filter are parameters
+// of the query sent to Consul, so each unique query is watched separately.
+//
+//	web?dc=dc1&ns=team-a&partition=infra&tag=canary&node-meta=rack:rack-1&status=passing
 func (s Service) TemplateServiceID() string {
-	id := s.Name
-
+	tags := s.Tags
 	if s.Tag != "" {
-		id = fmt.Sprintf("%s.%s", s.Tag, s.Name)
+		tags = []string{s.Tag}
+	}
+
+	status := []string{healthPassing}
+	if len(s.HealthStatus) > 0 {
+		status = make([]string, len(s.HealthStatus))
+		copy(status, s.HealthStatus)
+		sort.Strings(status)
 	}
 
-	if s.Datacenter != "" {
-		id = fmt.Sprintf("%s@%s", id, s.Datacenter)
+	q := healthServiceQuery{
+		name:      s.Name,
+		dc:        s.Datacenter,
+		ns:        s.Namespace,
+		partition: s.Partition,
+		tags:      tags,
+		nodeMeta:  s.NodeMeta,
+		filter:    s.Filter,
+		status:    status,
+		connect:   s.Connect,
 	}
+	return q.query()
+}
 
-	return id
+// templateQuery returns the template function call to query the instances of
+// the service.
+func (s Service) templateQuery() string {
+	return fmt.Sprintf("serviceInstances %q", s.TemplateServiceID())
 }
 
 // RootModuleInputData is the input data used to generate the root module
@@ -102,6 +153,21 @@ type RootModuleInputData struct {
 	Task         Task
 	Variables    hcltmpl.Variables
 
+	// TFVarsTemplate is the content of a user-supplied template that is
+	// rendered along with the generated tfvars template. TemplateVariables are
+	// the names of the variables assigned by the template.
+	TFVarsTemplate    []byte
+	TemplateVariables []string
+
+	// SensitiveVariables are the names of the module variables that are
+	// declared as sensitive so that Terraform redacts their values from plan
+	// and apply output. Sensitive variables require Terraform 0.14 or later.
+	SensitiveVariables []string
+
+	// TFVarsFormat is the format of the generated input variables file, either
+	// TFVarsFormatHCL or TFVarsFormatJSON. Defaults to HCL when empty.
+	TFVarsFormat string
+
 	backend *hcltmpl.NamedBlock
 }
 
@@ -121,15 +187,71 @@ func (d *RootModuleInputData) Init() {
 	})
 
 	sort.Slice(d.Services, func(i, j int) bool {
-		return d.Services[i].Name < d.Services[j].Name
+		a, b := d.Services[i], d.Services[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Partition < b.Partition
 	})
 }
 
+// moduleVariableNames returns the sorted names of the user input variables and
+// the variables assigned by the user-supplied tfvars template.
+func (d *RootModuleInputData) moduleVariableNames() []string {
+	names := d.Variables.Keys()
+	names = append(names, d.TemplateVariables...)
+	sort.Strings(names)
+	return names
+}
+
+// isSensitive reports whether the module variable is declared as sensitive.
+func (d *RootModuleInputData) isSensitive(name string) bool {
+	for _, s := range d.SensitiveVariables {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TFVarsFilenames returns the file names of the input variables template and
+// the rendered input variables file for the tfvars format.
+func TFVarsFilenames(format string) (tmplFilename, tfvarsFilename string) {
+	if format == TFVarsFormatJSON {
+		return TFVarsJSONTmplFilename, TFVarsJSONFilename
+	}
+	return TFVarsTmplFilename, TFVarsFilename
+}
+
 // InitRootModule generates the root module and writes the following files to
-// disk: main.tf, variables.tf
+// disk: main.tf, variables.tf, and the input variables template for the
+// tfvars format.
 func InitRootModule(input *RootModuleInputData, modulePath string, filePerms os.FileMode, force bool) error {
+	fileFuncs := make(map[string]func(io.Writer, *RootModuleInputData) error,
+		len(rootFileFuncs)+1)
 	for filename, newFileFunc := range rootFileFuncs {
-		if filename == ModuleVarsFilename && len(input.Variables) == 0 {
+		fileFuncs[filename] = newFileFunc
+	}
+
+	tmplFilename, _ := TFVarsFilenames(input.TFVarsFormat)
+	if input.TFVarsFormat == TFVarsFormatJSON {
+		fileFuncs[tmplFilename] = NewTFVarsJSONTmpl
+	} else {
+		fileFuncs[tmplFilename] = NewTFVarsTmpl
+	}
+
+	if err := removeStaleTFVars(modulePath, input.TFVarsFormat); err != nil {
+		log.Printf("[ERR] (templates.tftmpl) unable to remove input variables "+
+			"files of another format for %q: %s", input.Task.Name, err)
+		return err
+	}
+
+	for filename, newFileFunc := range fileFuncs {
+		if filename == ModuleVarsFilename && len(input.Variables) == 0 &&
+			len(input.TemplateVariables) == 0 {
 			// Skip variables.module.tf if there are no user input variables
 			continue
 		}
@@ -177,6 +299,26 @@ func InitRootModule(input *RootModuleInputData, modulePath string, filePerms os.
 	return nil
 }
 
+// removeStaleTFVars removes the generated input variables files of the other
+// tfvars format. Terraform automatically loads both terraform.tfvars and
+// terraform.tfvars.json, so stale values would otherwise be loaded after the
+// format is changed.
+func removeStaleTFVars(modulePath, format string) error {
+	staleFormat := TFVarsFormatJSON
+	if format == TFVarsFormatJSON {
+		staleFormat = TFVarsFormatHCL
+	}
+
+	tmplFilename, tfvarsFilename := TFVarsFilenames(staleFormat)
+	for _, filename := range []string{tmplFilename, tfvarsFilename} {
+		err := os.Remove(filepath.Join(modulePath, filename))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 // NewMainTF writes content used for main.tf of a Terraform root module.
 func NewMainTF(w io.Writer, input *RootModuleInputData) error {
 	_, err := w.Write(RootPreamble)
@@ -193,7 +335,7 @@ func NewMainTF(w io.Writer, input *RootModuleInputData) error {
 	rootBody.AppendNewline()
 	appendRootProviderBlocks(rootBody, input.Providers)
 	rootBody.AppendNewline()
-	appendRootModuleBlock(rootBody, input.Task, input.Variables.Keys())
+	appendRootModuleBlock(rootBody, input.Task, input.moduleVariableNames())
 
 	// Format the file before writing
 	content := hclFile.Bytes()
@@ -1,6 +1,9 @@
 package tftmpl
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/consul-terraform-sync/config"
@@ -111,3 +114,45 @@ func TestAppendRootTerraformBlock_backend(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveStaleTFVars(t *testing.T) {
+	testCases := []struct {
+		format    string
+		remaining []string
+	}{
+		{
+			TFVarsFormatHCL,
+			[]string{TFVarsFilename, TFVarsTmplFilename},
+		}, {
+			TFVarsFormatJSON,
+			[]string{TFVarsJSONFilename, TFVarsJSONTmplFilename},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tftmpl-")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			for _, f := range []string{TFVarsFilename, TFVarsTmplFilename,
+				TFVarsJSONFilename, TFVarsJSONTmplFilename} {
+				err := ioutil.WriteFile(filepath.Join(dir, f), []byte{}, 0644)
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, removeStaleTFVars(dir, tc.format))
+
+			files, err := ioutil.ReadDir(dir)
+			require.NoError(t, err)
+			var remaining []string
+			for _, f := range files {
+				remaining = append(remaining, f.Name())
+			}
+			assert.ElementsMatch(t, tc.remaining, remaining)
+
+			// Removing again is a no-op
+			assert.NoError(t, removeStaleTFVars(dir, tc.format))
+		})
+	}
+}
@@ -3,19 +3,26 @@ package tftmpl
 import (
 	"strings"
 
-	"github.com/hashicorp/hcat/dep"
 	"github.com/hashicorp/hcat/tfunc"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
 )
 
+// defaultPartition is the admin partition of Consul Enterprise resources when
+// a partition is not specified.
+const defaultPartition = "default"
+
 // HCLTmplFuncMap are template functions for rendering HCL
 var HCLTmplFuncMap = map[string]interface{}{
 	"indent":   tfunc.Helpers()["indent"],
 	"subtract": tfunc.Math()["subtract"],
 
-	"joinStrings": joinStringsFunc,
-	"HCLService":  hclServiceFunc,
+	"joinStrings":      joinStringsFunc,
+	"serviceInstances": serviceInstancesFunc,
+	"HCLService":       hclServiceFunc,
+	"HCLString":        hclStringFunc,
+	"JSONServices":     jsonServicesFunc,
 }
 
 // JoinStrings joins an optional number of strings with the separator while
@@ -36,7 +43,17 @@ func joinStringsFunc(sep string, values ...string) string {
 	return strings.Join(cleaned, sep)
 }
 
-func hclServiceFunc(sDep *dep.HealthService) string {
+// hclStringFunc encodes the string as a quoted HCL string. Quotes, control
+// characters, and template sequences like ${ and %{ are escaped so the string
+// is read by Terraform as is.
+func hclStringFunc(s string) string {
+	return string(hclwrite.TokensForValue(cty.StringVal(s)).Bytes())
+}
+
+// hclServiceFunc encodes the service instance as HCL attributes. String
+// values are escaped by hclwrite, so values from Consul that contain quotes or
+// template sequences are read by Terraform as is.
+func hclServiceFunc(sDep *serviceInstance) string {
 	if sDep == nil {
 		return ""
 	}
@@ -47,3 +64,17 @@ func hclServiceFunc(sDep *dep.HealthService) string {
 	gohcl.EncodeIntoBody(s, f.Body())
 	return strings.TrimSpace(string(f.Bytes()))
 }
+
+// Key returns the key of the service instance in the services variable. The
+// key includes the namespace and the admin partition of the instance so that
+// instances of services with the same name in different namespaces and
+// partitions are unique. The default partition is omitted to keep the keys of
+// instances from before partitions.
+func (s *serviceInstance) Key() string {
+	partition := s.Partition
+	if partition == defaultPartition {
+		partition = ""
+	}
+	return joinStringsFunc(".", s.ID, s.Node, s.Namespace, partition,
+		s.NodeDatacenter)
+}
@@ -1,10 +1,23 @@
 package tftmpl
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/quick"
 
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat"
 	"github.com/hashicorp/hcat/dep"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
 func TestJoinStringsFunc(t *testing.T) {
@@ -40,10 +53,56 @@ func TestJoinStringsFunc(t *testing.T) {
 	}
 }
 
+func TestServiceInstance_Key(t *testing.T) {
+	testCases := []struct {
+		name     string
+		instance *serviceInstance
+		expected string
+	}{
+		{
+			"oss",
+			&serviceInstance{HealthService: dep.HealthService{
+				ID: "api", Node: "node", NodeDatacenter: "dc1",
+			}},
+			"api.node.dc1",
+		}, {
+			"namespace",
+			&serviceInstance{HealthService: dep.HealthService{
+				ID: "api", Node: "node", NodeDatacenter: "dc1", Namespace: "ns",
+			}},
+			"api.node.ns.dc1",
+		}, {
+			"default partition omitted",
+			&serviceInstance{
+				HealthService: dep.HealthService{
+					ID: "api", Node: "node", NodeDatacenter: "dc1", Namespace: "ns",
+				},
+				Partition: "default",
+			},
+			"api.node.ns.dc1",
+		}, {
+			"partition",
+			&serviceInstance{
+				HealthService: dep.HealthService{
+					ID: "api", Node: "node", NodeDatacenter: "dc1", Namespace: "ns",
+				},
+				Partition: "infra",
+			},
+			"api.node.ns.infra.dc1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.instance.Key())
+		})
+	}
+}
+
 func TestHCLServiceFunc(t *testing.T) {
 	testCases := []struct {
 		name     string
-		content  *dep.HealthService
+		content  *serviceInstance
 		expected string
 	}{
 		{
@@ -52,15 +111,24 @@ func TestHCLServiceFunc(t *testing.T) {
 			"",
 		}, {
 			"empty",
-			&dep.HealthService{},
-			`id                    = ""
-name                  = ""
-address               = ""
-port                  = 0
-meta                  = {}
-tags                  = []
-namespace             = null
-status                = ""
+			&serviceInstance{},
+			`id               = ""
+name             = ""
+kind             = ""
+address          = ""
+port             = 0
+tagged_addresses = {}
+meta             = {}
+tags             = []
+namespace        = null
+partition        = null
+status           = ""
+checks           = []
+weights = {
+  passing = 0
+  warning = 0
+}
+proxy                 = null
 node                  = ""
 node_id               = ""
 node_address          = ""
@@ -69,14 +137,31 @@ node_tagged_addresses = {}
 node_meta             = {}`,
 		}, {
 			"basic",
-			&dep.HealthService{
-				ID:             "api",
-				Name:           "api",
-				Address:        "1.2.3.4",
-				Port:           8080,
-				ServiceMeta:    map[string]string{"key": "value"},
-				Tags:           []string{"tag"},
-				Status:         "passing",
+			&serviceInstance{HealthService: dep.HealthService{
+				ID:          "api",
+				Name:        "api",
+				Address:     "1.2.3.4",
+				Port:        8080,
+				ServiceMeta: map[string]string{"key": "value"},
+				Tags:        []string{"tag"},
+				Status:      "passing",
+				Checks: api.HealthChecks{
+					{
+						Node:   "worker-01",
+						Name:   "Serf Health Status",
+						Status: "passing",
+						Output: "Agent alive and reachable",
+					}, {
+						Node:      "worker-01",
+						Name:      "service:api",
+						Status:    "passing",
+						ServiceID: "api",
+					},
+				},
+				Weights: api.AgentWeights{
+					Passing: 1,
+					Warning: 1,
+				},
 				Node:           "worker-01",
 				NodeID:         "39e5a7f5-2834-e16d-6925-78167c9f50d8",
 				NodeAddress:    "127.0.0.1",
@@ -90,17 +175,36 @@ node_meta             = {}`,
 				NodeMeta: map[string]string{
 					"consul-network-segment": "",
 				},
-			},
-			`id      = "api"
-name    = "api"
-address = "1.2.3.4"
-port    = 8080
+			}},
+			`id               = "api"
+name             = "api"
+kind             = ""
+address          = "1.2.3.4"
+port             = 8080
+tagged_addresses = {}
 meta = {
   key = "value"
 }
-tags            = ["tag"]
-namespace       = null
-status          = "passing"
+tags      = ["tag"]
+namespace = null
+partition = null
+status    = "passing"
+checks = [{
+  name   = "Serf Health Status"
+  output = "Agent alive and reachable"
+  status = "passing"
+  type   = "node"
+  }, {
+  name   = "service:api"
+  output = ""
+  status = "passing"
+  type   = "service"
+}]
+weights = {
+  passing = 1
+  warning = 1
+}
+proxy           = null
 node            = "worker-01"
 node_id         = "39e5a7f5-2834-e16d-6925-78167c9f50d8"
 node_address    = "127.0.0.1"
@@ -115,18 +219,86 @@ node_meta = {
   consul-network-segment = ""
 }`,
 		}, {
-			"namespace",
-			&dep.HealthService{
-				Namespace: "namespace",
+			"namespace and partition",
+			&serviceInstance{
+				HealthService: dep.HealthService{Namespace: "namespace"},
+				Partition:     "partition",
 			},
-			`id                    = ""
-name                  = ""
-address               = ""
-port                  = 0
-meta                  = {}
-tags                  = []
-namespace             = "namespace"
-status                = ""
+			`id               = ""
+name             = ""
+kind             = ""
+address          = ""
+port             = 0
+tagged_addresses = {}
+meta             = {}
+tags             = []
+namespace        = "namespace"
+partition        = "partition"
+status           = ""
+checks           = []
+weights = {
+  passing = 0
+  warning = 0
+}
+proxy                 = null
+node                  = ""
+node_id               = ""
+node_address          = ""
+node_datacenter       = ""
+node_tagged_addresses = {}
+node_meta             = {}`,
+		}, {
+			"connect proxy",
+			&serviceInstance{
+				HealthService: dep.HealthService{
+					ID:      "api-sidecar-proxy",
+					Name:    "api-sidecar-proxy",
+					Address: "10.0.0.1",
+					Port:    21000,
+				},
+				Kind: "connect-proxy",
+				TaggedAddresses: map[string]api.ServiceAddress{
+					"lan": {Address: "10.0.0.1", Port: 21000},
+					"wan": {Address: "198.18.0.1", Port: 21001},
+				},
+				Proxy: &api.AgentServiceConnectProxyConfig{
+					DestinationServiceName: "api",
+					DestinationServiceID:   "api",
+					LocalServiceAddress:    "127.0.0.1",
+					LocalServicePort:       8080,
+				},
+			},
+			`id      = "api-sidecar-proxy"
+name    = "api-sidecar-proxy"
+kind    = "connect-proxy"
+address = "10.0.0.1"
+port    = 21000
+tagged_addresses = {
+  lan = {
+    address = "10.0.0.1"
+    port    = 21000
+  }
+  wan = {
+    address = "198.18.0.1"
+    port    = 21001
+  }
+}
+meta      = {}
+tags      = []
+namespace = null
+partition = null
+status    = ""
+checks    = []
+weights = {
+  passing = 0
+  warning = 0
+}
+proxy = {
+  destination_service_id   = "api"
+  destination_service_name = "api"
+  local_service_address    = "127.0.0.1"
+  local_service_port       = 8080
+}
 node                  = ""
 node_id               = ""
 node_address          = ""
@@ -143,3 +315,188 @@ node_meta             = {}`,
 		})
 	}
 }
+
+func TestHCLStringFunc(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			"empty",
+			"",
+			`""`,
+		}, {
+			"basic",
+			"api.worker-01.dc1",
+			`"api.worker-01.dc1"`,
+		}, {
+			"quotes",
+			`api" = {}, "injected`,
+			`"api\" = {}, \"injected"`,
+		}, {
+			"interpolation",
+			"${var.secret}",
+			`"$${var.secret}"`,
+		}, {
+			"directive",
+			"%{ if true }api%{ endif }",
+			`"%%{ if true }api%%{ endif }"`,
+		}, {
+			"control characters",
+			"api\n\t",
+			`"api\n\t"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hclStringFunc(tc.value))
+		})
+	}
+}
+
+// fuzzFragments are combined to generate strings with quotes, escape
+// sequences, and the template sequences of HCL and Go templates.
+var fuzzFragments = []string{
+	"a", "Z", "0", "-", "_", ".", " ", "=", ",", ":", "#", "//", "/*",
+	"$", "%", "{", "}", "[", "]", "${", "%{", "$${", "%%{", "{{", "}}", "~",
+	`"`, `\`, `\n`, "\n", "\r", "\t", "\x00", "\u2028", "é", "<<EOT",
+}
+
+func fuzzString(r *rand.Rand) string {
+	var sb strings.Builder
+	for n := r.Intn(8); n > 0; n-- {
+		sb.WriteString(fuzzFragments[r.Intn(len(fuzzFragments))])
+	}
+	return sb.String()
+}
+
+func fuzzStringMap(r *rand.Rand) map[string]string {
+	m := make(map[string]string)
+	for n := r.Intn(4); n > 0; n-- {
+		m[fuzzString(r)] = fuzzString(r)
+	}
+	return m
+}
+
+func fuzzServiceInstance(r *rand.Rand) *serviceInstance {
+	tags := make([]string, r.Intn(4))
+	for i := range tags {
+		tags[i] = fuzzString(r)
+	}
+
+	checks := make(api.HealthChecks, r.Intn(3))
+	for i := range checks {
+		checks[i] = &api.HealthCheck{
+			Name:   fuzzString(r),
+			Status: fuzzString(r),
+			Output: fuzzString(r),
+		}
+		if r.Intn(2) == 0 {
+			checks[i].ServiceID = fuzzString(r)
+		}
+	}
+
+	s := dep.HealthService{
+		ID:                  fuzzString(r),
+		Name:                fuzzString(r),
+		Address:             fuzzString(r),
+		Port:                r.Intn(65536),
+		ServiceMeta:         fuzzStringMap(r),
+		Tags:                tags,
+		Namespace:           fuzzString(r),
+		Status:              fuzzString(r),
+		Checks:              checks,
+		Node:                fuzzString(r),
+		NodeID:              fuzzString(r),
+		NodeAddress:         fuzzString(r),
+		NodeDatacenter:      fuzzString(r),
+		NodeTaggedAddresses: fuzzStringMap(r),
+		NodeMeta:            fuzzStringMap(r),
+		Weights: api.AgentWeights{
+			Passing: r.Intn(100),
+			Warning: r.Intn(100),
+		},
+	}
+
+	taggedAddresses := make(map[string]api.ServiceAddress)
+	for n := r.Intn(3); n > 0; n-- {
+		taggedAddresses[fuzzString(r)] = api.ServiceAddress{
+			Address: fuzzString(r),
+			Port:    r.Intn(65536),
+		}
+	}
+
+	var proxy *api.AgentServiceConnectProxyConfig
+	if r.Intn(2) == 0 {
+		proxy = &api.AgentServiceConnectProxyConfig{
+			DestinationServiceName: fuzzString(r),
+			DestinationServiceID:   fuzzString(r),
+			LocalServiceAddress:    fuzzString(r),
+			LocalServicePort:       r.Intn(65536),
+		}
+	}
+
+	return &serviceInstance{
+		HealthService:   s,
+		Partition:       fuzzString(r),
+		Kind:            fuzzString(r),
+		TaggedAddresses: taggedAddresses,
+		Proxy:           proxy,
+	}
+}
+
+// TestHCLServiceFunc_fuzz renders the services variable for service instances
+// with generated values, and checks that the rendered file parses back to the
+// original values of the instances.
+func TestHCLServiceFunc_fuzz(t *testing.T) {
+	contents := "services = {" + fmt.Sprintf(baseAddressStr, `serviceInstances "api?status=passing"`) + "\n}\n"
+
+	render := func(s *serviceInstance) bool {
+		tmpl := hcat.NewTemplate(hcat.TemplateInput{
+			Contents:     contents,
+			FuncMapMerge: HCLTmplFuncMap,
+		})
+		w := serviceWatcher{"health.service(api?status=passing)": {s}}
+		rendered, err := tmpl.Execute(w)
+		require.NoError(t, err)
+
+		file, diags := hclsyntax.ParseConfig(rendered, "terraform.tfvars", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), "%s\n%s", diags, rendered)
+		attrs, diags := file.Body.JustAttributes()
+		require.False(t, diags.HasErrors(), "%s\n%s", diags, rendered)
+		actual, diags := attrs["services"].Expr.Value(nil)
+		require.False(t, diags.HasErrors(), "%s\n%s", diags, rendered)
+
+		// Strings are expected as cty values, which are normalized to NFC
+		// like the values read by Terraform
+		expected := cty.ObjectVal(map[string]cty.Value{
+			s.Key(): newHealthService(s).objectVal(),
+		})
+
+		if !assert.Equal(t, jsonValue(t, expected), jsonValue(t, actual), string(rendered)) {
+			return false
+		}
+		return true
+	}
+
+	err := quick.Check(render, &quick.Config{
+		MaxCount: 500,
+		Values: func(args []reflect.Value, r *rand.Rand) {
+			args[0] = reflect.ValueOf(fuzzServiceInstance(r))
+		},
+	})
+	assert.NoError(t, err)
+}
+
+// jsonValue converts a cty value to a Go value to compare values of object
+// and map types, and of tuple and list types.
+func jsonValue(t *testing.T, v cty.Value) interface{} {
+	b, err := ctyjson.Marshal(v, v.Type())
+	require.NoError(t, err)
+
+	var i interface{}
+	require.NoError(t, json.Unmarshal(b, &i))
+	return i
+}
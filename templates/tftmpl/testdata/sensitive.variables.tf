@@ -0,0 +1,62 @@
+# This file is generated by Consul Terraform Sync.
+#
+# The HCL blocks, arguments, variables, and values are derived from the
+# operator configuration for Sync. Any manual changes to this file
+# may not be preserved and could be overwritten by a subsequent update.
+
+# Service definition protocol v1
+# Compatible with modules written for protocol v0
+variable "services" {
+  description = "Consul services monitored by Consul Terraform Sync"
+  type = map(
+    object({
+      id      = string
+      name    = string
+      kind    = string
+      address = string
+      port    = number
+      tagged_addresses = map(object({
+        address = string
+        port    = number
+      }))
+      meta      = map(string)
+      tags      = list(string)
+      namespace = string
+      partition = string
+      status    = string
+
+      checks = list(object({
+        name   = string
+        status = string
+        output = string
+        type   = string
+      }))
+      weights = object({
+        passing = number
+        warning = number
+      })
+      proxy = object({
+        destination_service_name = string
+        destination_service_id   = string
+        local_service_address    = string
+        local_service_port       = number
+      })
+
+      node                  = string
+      node_id               = string
+      node_address          = string
+      node_datacenter       = string
+      node_tagged_addresses = map(string)
+      node_meta             = map(string)
+    })
+  )
+}
+
+variable "testProvider" {
+  default     = null
+  description = "Configuration object for testProvider"
+  type = object({
+    token = string
+  })
+  sensitive = true
+}
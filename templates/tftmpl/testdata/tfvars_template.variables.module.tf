@@ -0,0 +1,21 @@
+# This file is generated by Consul Terraform Sync.
+#
+# The HCL blocks, arguments, variables, and values are derived from the
+# operator configuration for Sync. Any manual changes to this file
+# may not be preserved and could be overwritten by a subsequent update.
+
+
+variable "num" {
+  default = null
+  type    = number
+}
+
+variable "nodes" {
+  default     = null
+  description = "Variable assigned by the tfvars template for the task"
+}
+
+variable "ports" {
+  default     = null
+  description = "Variable assigned by the tfvars template for the task"
+}
@@ -4,20 +4,44 @@
 # operator configuration for Sync. Any manual changes to this file
 # may not be preserved and could be overwritten by a subsequent update.
 
-# Service definition protocol v0
+# Service definition protocol v1
+# Compatible with modules written for protocol v0
 variable "services" {
   description = "Consul services monitored by Consul Terraform Sync"
   type = map(
     object({
-      id        = string
-      name      = string
-      address   = string
-      port      = number
+      id      = string
+      name    = string
+      kind    = string
+      address = string
+      port    = number
+      tagged_addresses = map(object({
+        address = string
+        port    = number
+      }))
       meta      = map(string)
       tags      = list(string)
       namespace = string
+      partition = string
       status    = string
 
+      checks = list(object({
+        name   = string
+        status = string
+        output = string
+        type   = string
+      }))
+      weights = object({
+        passing = number
+        warning = number
+      })
+      proxy = object({
+        destination_service_name = string
+        destination_service_id   = string
+        local_service_address    = string
+        local_service_port       = number
+      })
+
       node                  = string
       node_id               = string
       node_address          = string
@@ -1,26 +1,73 @@
 package tftmpl
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat"
 	"github.com/hashicorp/hcat/dep"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/zclconf/go-cty/cty"
 )
 
+// checkType is the object type of a health check within the checks list of a
+// service
+var checkType = cty.Object(map[string]cty.Type{
+	"name":   cty.String,
+	"status": cty.String,
+	"output": cty.String,
+	"type":   cty.String,
+})
+
+// taggedAddressType is the object type of a tagged address within the
+// tagged_addresses map of a service
+var taggedAddressType = cty.Object(map[string]cty.Type{
+	"address": cty.String,
+	"port":    cty.Number,
+})
+
+// proxyType is the object type of the proxy configuration of a Connect proxy
+// service instance
+var proxyType = cty.Object(map[string]cty.Type{
+	"destination_service_name": cty.String,
+	"destination_service_id":   cty.String,
+	"local_service_address":    cty.String,
+	"local_service_port":       cty.Number,
+})
+
+const (
+	// checkTypeService is the type of a health check associated with the
+	// service instance
+	checkTypeService = "service"
+
+	// checkTypeNode is the type of a health check associated with the node of
+	// the service instance
+	checkTypeNode = "node"
+)
+
 type healthService struct {
-	ID        string            `hcl:"id"`
-	Name      string            `hcl:"name"`
-	Address   string            `hcl:"address"`
-	Port      int               `hcl:"port"`
-	Meta      map[string]string `hcl:"meta"`
-	Tags      []string          `hcl:"tags"`
-	Namespace cty.Value         `hcl:"namespace"`
-	Status    string            `hcl:"status"`
+	ID              string            `hcl:"id"`
+	Name            string            `hcl:"name"`
+	Kind            string            `hcl:"kind"`
+	Address         string            `hcl:"address"`
+	Port            int               `hcl:"port"`
+	TaggedAddresses cty.Value         `hcl:"tagged_addresses"`
+	Meta            map[string]string `hcl:"meta"`
+	Tags            []string          `hcl:"tags"`
+	Namespace       cty.Value         `hcl:"namespace"`
+	Partition       cty.Value         `hcl:"partition"`
+	Status          string            `hcl:"status"`
+	Checks          cty.Value         `hcl:"checks"`
+	Weights         cty.Value         `hcl:"weights"`
+	Proxy           cty.Value         `hcl:"proxy"`
 
 	Node                string            `hcl:"node"`
 	NodeID              string            `hcl:"node_id"`
@@ -30,19 +77,11 @@ type healthService struct {
 	NodeMeta            map[string]string `hcl:"node_meta"`
 }
 
-func newHealthService(s *dep.HealthService) healthService {
+func newHealthService(s *serviceInstance) healthService {
 	if s == nil {
 		return healthService{}
 	}
 
-	// Namespace is null-able
-	var namespace cty.Value
-	if s.Namespace != "" {
-		namespace = cty.StringVal(s.Namespace)
-	} else {
-		namespace = cty.NullVal(cty.String)
-	}
-
 	// Default to empty list instead of null
 	tags := []string{}
 	if s.Tags != nil {
@@ -50,14 +89,23 @@ func newHealthService(s *dep.HealthService) healthService {
 	}
 
 	return healthService{
-		ID:        s.ID,
-		Name:      s.Name,
-		Address:   s.Address,
-		Port:      s.Port,
-		Meta:      nonNullMap(s.ServiceMeta),
-		Tags:      tags,
-		Namespace: namespace,
-		Status:    s.Status,
+		ID:              s.ID,
+		Name:            s.Name,
+		Kind:            s.Kind,
+		Address:         s.Address,
+		Port:            s.Port,
+		TaggedAddresses: newTaggedAddresses(s.TaggedAddresses),
+		Meta:            nonNullMap(s.ServiceMeta),
+		Tags:            tags,
+		Namespace:       nullableString(s.Namespace),
+		Partition:       nullableString(s.Partition),
+		Status:          s.Status,
+		Checks:          newHealthChecks(s.Checks),
+		Weights: cty.ObjectVal(map[string]cty.Value{
+			"passing": cty.NumberIntVal(int64(s.Weights.Passing)),
+			"warning": cty.NumberIntVal(int64(s.Weights.Warning)),
+		}),
+		Proxy: newProxy(s.Proxy),
 
 		Node:                s.Node,
 		NodeID:              s.NodeID,
@@ -68,6 +116,91 @@ func newHealthService(s *dep.HealthService) healthService {
 	}
 }
 
+// objectVal converts the service instance to an object value with the same
+// attributes as the HCL encoding of the instance.
+func (s healthService) objectVal() cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		"id":               cty.StringVal(s.ID),
+		"name":             cty.StringVal(s.Name),
+		"kind":             cty.StringVal(s.Kind),
+		"address":          cty.StringVal(s.Address),
+		"port":             cty.NumberIntVal(int64(s.Port)),
+		"tagged_addresses": s.TaggedAddresses,
+		"meta":             stringMapVal(s.Meta),
+		"tags":             stringListVal(s.Tags),
+		"namespace":        s.Namespace,
+		"partition":        s.Partition,
+		"status":           cty.StringVal(s.Status),
+		"checks":           s.Checks,
+		"weights":          s.Weights,
+		"proxy":            s.Proxy,
+
+		"node":                  cty.StringVal(s.Node),
+		"node_id":               cty.StringVal(s.NodeID),
+		"node_address":          cty.StringVal(s.NodeAddress),
+		"node_datacenter":       cty.StringVal(s.NodeDatacenter),
+		"node_tagged_addresses": stringMapVal(s.NodeTaggedAddresses),
+		"node_meta":             stringMapVal(s.NodeMeta),
+	})
+}
+
+// newHealthChecks converts the health checks of a service instance to a list
+// of check objects. Checks without a service ID are node checks.
+func newHealthChecks(checks api.HealthChecks) cty.Value {
+	if len(checks) == 0 {
+		// Default to empty list instead of null
+		return cty.ListValEmpty(checkType)
+	}
+
+	vals := make([]cty.Value, len(checks))
+	for i, c := range checks {
+		t := checkTypeService
+		if c.ServiceID == "" {
+			t = checkTypeNode
+		}
+		vals[i] = cty.ObjectVal(map[string]cty.Value{
+			"name":   cty.StringVal(c.Name),
+			"status": cty.StringVal(c.Status),
+			"output": cty.StringVal(c.Output),
+			"type":   cty.StringVal(t),
+		})
+	}
+	return cty.ListVal(vals)
+}
+
+// newTaggedAddresses converts the tagged addresses of a service instance, such
+// as the lan, wan, and virtual addresses, to a map of address objects.
+func newTaggedAddresses(addrs map[string]api.ServiceAddress) cty.Value {
+	if len(addrs) == 0 {
+		// Default to empty map instead of null
+		return cty.MapValEmpty(taggedAddressType)
+	}
+
+	vals := make(map[string]cty.Value, len(addrs))
+	for k, a := range addrs {
+		vals[k] = cty.ObjectVal(map[string]cty.Value{
+			"address": cty.StringVal(a.Address),
+			"port":    cty.NumberIntVal(int64(a.Port)),
+		})
+	}
+	return cty.MapVal(vals)
+}
+
+// newProxy converts the proxy configuration of a Connect proxy instance to a
+// proxy object. The proxy is null for instances that are not proxies.
+func newProxy(p *api.AgentServiceConnectProxyConfig) cty.Value {
+	if p == nil {
+		return cty.NullVal(proxyType)
+	}
+
+	return cty.ObjectVal(map[string]cty.Value{
+		"destination_service_name": cty.StringVal(p.DestinationServiceName),
+		"destination_service_id":   cty.StringVal(p.DestinationServiceID),
+		"local_service_address":    cty.StringVal(p.LocalServiceAddress),
+		"local_service_port":       cty.NumberIntVal(int64(p.LocalServicePort)),
+	})
+}
+
 // NewTFVarsTmpl writes content to assign values to the root module's variables
 // that is commonly placed in a .tfvars file.
 func NewTFVarsTmpl(w io.Writer, input *RootModuleInputData) error {
@@ -83,35 +216,124 @@ func NewTFVarsTmpl(w io.Writer, input *RootModuleInputData) error {
 	body := hclFile.Body()
 	appendNamedBlockValues(body, input.Providers)
 	body.AppendNewline()
+	appendHealthStatusComment(body, input.Services)
 	appendRawServiceTemplateValues(body, input.Services)
 
 	_, err = hclFile.WriteTo(w)
+	if err != nil || len(input.TFVarsTemplate) == 0 {
+		return err
+	}
+
+	// Append the user-supplied template to be rendered along with the
+	// generated template
+	_, err = fmt.Fprintf(w, "\n%s\n", bytes.TrimSpace(input.TFVarsTemplate))
 	return err
 }
 
+// ParseTFVarsTemplate validates a user-supplied tfvars template and returns
+// the sorted names of the variables it assigns. The template is rendered
+// without any Consul or Vault values to check that the template syntax is
+// valid and that it renders into HCL attributes.
+func ParseTFVarsTemplate(content []byte, filename string) ([]string, error) {
+	tmpl := hcat.NewTemplate(hcat.TemplateInput{
+		Contents:     string(content),
+		FuncMapMerge: HCLTmplFuncMap,
+	})
+
+	rendered, err := tmpl.Execute(emptyWatcher{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid tfvars template %s: %s", filename, err)
+	}
+
+	hclFile, diags := hclsyntax.ParseConfig(rendered, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("tfvars template %s does not render valid HCL: %s",
+			filename, diags)
+	}
+
+	attrs, diags := hclFile.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("tfvars template %s must only render variable "+
+			"assignments: %s", filename, diags)
+	}
+
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// emptyWatcher is a watcher without any dependency values. It is used to
+// render a template without fetching dependencies.
+type emptyWatcher struct{}
+
+func (emptyWatcher) Buffer(string) bool { return false }
+
+func (emptyWatcher) Complete(hcat.Notifier) bool { return true }
+
+func (emptyWatcher) Recaller(hcat.Notifier) hcat.Recaller {
+	return func(dep.Dependency) (interface{}, bool) {
+		return nil, false
+	}
+}
+
 // appendNamedBlockValues appends blocks that assign value to the named
-// variable blocks genernated by `appendNamedBlockVariable`
+// variable blocks genernated by `appendNamedBlockVariable`. Template
+// delimiters within the values are escaped so that they are not evaluated
+// when the template is rendered.
 func appendNamedBlockValues(body *hclwrite.Body, blocks []hcltmpl.NamedBlock) {
 	lastIdx := len(blocks) - 1
 	for i, b := range blocks {
 		obj := b.ObjectVal()
-		body.SetAttributeValue(b.Name, *obj)
+		tokens := hclwrite.TokensForValue(*obj)
+		for _, t := range tokens {
+			t.Bytes = escapeTemplateDelims(t.Bytes)
+		}
+		body.SetAttributeRaw(b.Name, tokens)
 		if i != lastIdx {
 			body.AppendNewline()
 		}
 	}
 }
 
+// appendHealthStatusComment appends a comment documenting the health status
+// filter of services that include instances other than passing instances.
+//
+// # Service instances are filtered by health status:
+// #   <service>: <status>, <status>
+func appendHealthStatusComment(body *hclwrite.Body, services []Service) {
+	var lines []string
+	for _, s := range services {
+		if len(s.HealthStatus) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("#   %s: %s", s.Name,
+			strings.Join(s.HealthStatus, ", ")))
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	comment := "# Service instances are filtered by health status:\n" +
+		strings.Join(lines, "\n") + "\n"
+	body.AppendUnstructuredTokens(hclwrite.Tokens{{
+		Type:  hclsyntax.TokenComment,
+		Bytes: []byte(comment),
+	}})
+}
+
 // appendRawServiceTemplateValues appends raw lines representing blocks that
 // assign value to the services variable `VariableServices` with `hcat` template
 // syntax for dynamic rendering of Consul dependency values.
 //
-// services = {
-//   <service>: {
-//	   <attr> = <value>
-//     <attr> = {{ <template syntax> }}
-//   }
-// }
+//	services = {
+//	  <service>: {
+//		   <attr> = <value>
+//	    <attr> = {{ <template syntax> }}
+//	  }
+//	}
 func appendRawServiceTemplateValues(body *hclwrite.Body, services []Service) {
 	if len(services) == 0 {
 		return
@@ -124,14 +346,14 @@ func appendRawServiceTemplateValues(body *hclwrite.Body, services []Service) {
 	})
 	lastIdx := len(services) - 1
 	for i, s := range services {
-		rawService := fmt.Sprintf(baseAddressStr, s.TemplateServiceID())
+		rawService := fmt.Sprintf(baseAddressStr, s.templateQuery())
 
 		if i == lastIdx {
 			rawService += "\n}"
 		} else {
 			nextS := services[i+1]
-			rawComma := fmt.Sprintf(baseCommaStr, s.TemplateServiceID(),
-				nextS.TemplateServiceID())
+			rawComma := fmt.Sprintf(baseCommaStr, s.templateQuery(),
+				nextS.templateQuery())
 			rawService += rawComma
 		}
 
@@ -144,6 +366,45 @@ func appendRawServiceTemplateValues(body *hclwrite.Body, services []Service) {
 	body.SetAttributeRaw("services", tokens)
 }
 
+func stringMapVal(m map[string]string) cty.Value {
+	if len(m) == 0 {
+		return cty.MapValEmpty(cty.String)
+	}
+
+	vals := make(map[string]cty.Value, len(m))
+	for k, v := range m {
+		vals[k] = cty.StringVal(v)
+	}
+	return cty.MapVal(vals)
+}
+
+func stringListVal(l []string) cty.Value {
+	if len(l) == 0 {
+		return cty.ListValEmpty(cty.String)
+	}
+
+	vals := make([]cty.Value, len(l))
+	for i, v := range l {
+		vals[i] = cty.StringVal(v)
+	}
+	return cty.ListVal(vals)
+}
+
+// escapeTemplateDelims escapes the left template delimiters within literal
+// text of a template so that the text is rendered as is and not evaluated.
+func escapeTemplateDelims(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte("{{"), []byte(`{{"{{"}}`))
+}
+
+// nullableString converts the string to a string value that is null when the
+// string is empty.
+func nullableString(s string) cty.Value {
+	if s == "" {
+		return cty.NullVal(cty.String)
+	}
+	return cty.StringVal(s)
+}
+
 func nonNullMap(m map[string]string) map[string]string {
 	if m == nil {
 		return map[string]string{}
@@ -153,12 +414,14 @@ func nonNullMap(m map[string]string) map[string]string {
 }
 
 // baseAddressStr is the raw template following hcat syntax for addresses of
-// Consul services.
+// Consul services. The service instances are queried with the
+// `serviceInstances` template function. Values from Consul are encoded as
+// HCL with escaping by the HCLString and HCLService template functions.
 const baseAddressStr = `
-{{- with $srv := service "%s"}}
+{{- with $srv := %s}}
   {{- $last := len $srv | subtract 1}}
   {{- range $i, $s := $srv}}
-  "{{ joinStrings "." .ID .Node .Namespace .NodeDatacenter }}" : {
+  {{ $s.Key | HCLString }} : {
 {{ HCLService $s | indent 4 }}
   } {{- if (ne $i $last)}},{{end}}
   {{- end}}
@@ -167,6 +430,6 @@ const baseAddressStr = `
 // baseCommaStr is the raw template following hcat syntax for the comma between
 // different Consul services. Rendering a comma requires there to be an instance
 // of the service before and after the comma.
-const baseCommaStr = `{{- with $beforeSrv := service "%s"}}
-  {{- with $afterSrv := service "%s"}},{{end}}
+const baseCommaStr = `{{- with $beforeSrv := %s}}
+  {{- with $afterSrv := %s}},{{end}}
 {{- end}}`
@@ -0,0 +1,95 @@
+package tftmpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// NewTFVarsJSONTmpl writes content to assign values to the root module's
+// variables using the JSON syntax that is commonly placed in a .tfvars.json
+// file. Consul service values are JSON encoded when the template is rendered,
+// so values containing quotes or template sequences cannot change the
+// structure of the file. JSON does not support comments, so the preamble is
+// omitted.
+func NewTFVarsJSONTmpl(w io.Writer, input *RootModuleInputData) error {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+
+	for _, p := range input.Providers {
+		if err := appendNamedBlockJSON(&buf, p); err != nil {
+			return err
+		}
+	}
+
+	queries := make([]string, len(input.Services))
+	for i, s := range input.Services {
+		queries[i] = fmt.Sprintf("(%s)", s.templateQuery())
+	}
+	fmt.Fprintf(&buf, "  \"services\": {{ JSONServices %s }}\n}\n",
+		strings.Join(queries, " "))
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// appendNamedBlockJSON appends a JSON member that assigns the value of the
+// named variable block. Template delimiters within the values are escaped so
+// that they are not evaluated when the template is rendered.
+func appendNamedBlockJSON(buf *bytes.Buffer, block hcltmpl.NamedBlock) error {
+	name, err := json.Marshal(block.Name)
+	if err != nil {
+		return err
+	}
+
+	obj := block.ObjectVal()
+	value, err := marshalIndentJSON(*obj, "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode %q as JSON: %s", block.Name, err)
+	}
+
+	fmt.Fprintf(buf, "  %s: %s,\n", name, escapeTemplateDelims(value))
+	return nil
+}
+
+// jsonServicesFunc encodes the service instances of each Consul service query
+// as a JSON object keyed by the ID of the instance. The attributes of each
+// instance are the same as the HCL encoding of the instance by HCLService.
+func jsonServicesFunc(queries ...[]*serviceInstance) (string, error) {
+	instances := make(map[string]cty.Value)
+	for _, services := range queries {
+		for _, sDep := range services {
+			if sDep == nil {
+				continue
+			}
+			instances[sDep.Key()] = newHealthService(sDep).objectVal()
+		}
+	}
+
+	b, err := marshalIndentJSON(cty.ObjectVal(instances), "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// marshalIndentJSON encodes the value as indented JSON. The prefix is added to
+// every line except the first so the value can be nested within an object.
+func marshalIndentJSON(v cty.Value, prefix string) ([]byte, error) {
+	b, err := ctyjson.Marshal(v, v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, prefix, "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
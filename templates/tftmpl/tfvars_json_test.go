@@ -0,0 +1,161 @@
+package tftmpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/hcat"
+	"github.com/hashicorp/hcat/dep"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTFVarsJSONTmpl_render(t *testing.T) {
+	input := RootModuleInputData{
+		Providers: []hcltmpl.NamedBlock{hcltmpl.NewNamedBlock(
+			map[string]interface{}{
+				"testProvider": map[string]interface{}{
+					"attr": `{{ "not evaluated" }}`,
+				},
+			})},
+		Services: []Service{{Name: "api"}, {Name: "web"}, {Name: "db"}},
+	}
+	input.Init()
+
+	var b bytes.Buffer
+	require.NoError(t, NewTFVarsJSONTmpl(&b, &input))
+
+	w := serviceWatcher{
+		"health.service(api?status=passing)": {{HealthService: dep.HealthService{
+			ID:      "api",
+			Name:    "api",
+			Node:    "node",
+			Address: "10.0.0.1",
+			Port:    8080,
+			ServiceMeta: map[string]string{
+				"quotes": `"}, "injected": {"`,
+				"interp": "${var.secret}",
+				"delims": "{{ env \"HOME\" }}",
+			},
+			Tags: []string{"tag\nnewline"},
+			Checks: api.HealthChecks{
+				{Name: "check", Status: "passing", ServiceID: "api"},
+			},
+		}}},
+		"health.service(web?status=passing)": {{HealthService: dep.HealthService{
+			ID:   "web",
+			Name: "web",
+			Node: "node",
+		}}},
+	}
+	tmpl := hcat.NewTemplate(hcat.TemplateInput{
+		Contents:     b.String(),
+		FuncMapMerge: HCLTmplFuncMap,
+	})
+	rendered, err := tmpl.Execute(w)
+	require.NoError(t, err)
+	require.True(t, json.Valid(rendered), string(rendered))
+
+	var tfvars struct {
+		TestProvider map[string]string                 `json:"testProvider"`
+		Services     map[string]map[string]interface{} `json:"services"`
+	}
+	require.NoError(t, json.Unmarshal(rendered, &tfvars))
+
+	assert.Equal(t, `{{ "not evaluated" }}`, tfvars.TestProvider["attr"])
+	require.Len(t, tfvars.Services, 2)
+
+	api := tfvars.Services["api.node"]
+	assert.Equal(t, "10.0.0.1", api["address"])
+	assert.Equal(t, float64(8080), api["port"])
+	assert.Nil(t, api["namespace"])
+	assert.Nil(t, api["partition"])
+	assert.Equal(t, map[string]interface{}{
+		"quotes": `"}, "injected": {"`,
+		"interp": "${var.secret}",
+		"delims": "{{ env \"HOME\" }}",
+	}, api["meta"])
+	assert.Equal(t, []interface{}{"tag\nnewline"}, api["tags"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"name":   "check",
+		"status": "passing",
+		"output": "",
+		"type":   "service",
+	}}, api["checks"])
+
+	web := tfvars.Services["web.node"]
+	assert.Equal(t, map[string]interface{}{}, web["meta"])
+	assert.Equal(t, []interface{}{}, web["tags"])
+}
+
+func TestJSONServicesFunc(t *testing.T) {
+	t.Run("no instances", func(t *testing.T) {
+		actual, err := jsonServicesFunc()
+		require.NoError(t, err)
+		assert.Equal(t, "{}", actual)
+
+		actual, err = jsonServicesFunc([]*serviceInstance{}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "{}", actual)
+	})
+
+	t.Run("indented for nesting", func(t *testing.T) {
+		actual, err := jsonServicesFunc([]*serviceInstance{{
+			HealthService: dep.HealthService{
+				ID:        "api",
+				Node:      "node",
+				Namespace: "ns",
+			},
+			Partition: "infra",
+		}})
+		require.NoError(t, err)
+		lines := strings.Split(actual, "\n")
+		assert.Equal(t, "{", lines[0])
+		assert.Equal(t, `    "api.node.ns.infra": {`, lines[1])
+		assert.Equal(t, "  }", lines[len(lines)-1])
+	})
+}
+
+func TestHealthService_objectVal(t *testing.T) {
+	// The JSON encoding of a service instance has the same attributes as
+	// the HCL encoding
+	var expected []string
+	typ := reflect.TypeOf(healthService{})
+	for i := 0; i < typ.NumField(); i++ {
+		expected = append(expected, typ.Field(i).Tag.Get("hcl"))
+	}
+	sort.Strings(expected)
+
+	obj := newHealthService(&serviceInstance{}).objectVal()
+	var actual []string
+	for name := range obj.Type().AttributeTypes() {
+		actual = append(actual, name)
+	}
+	sort.Strings(actual)
+
+	assert.Equal(t, expected, actual)
+}
+
+// serviceWatcher is a watcher with service instances for the Consul service
+// queries keyed by the string of the dependency.
+type serviceWatcher map[string][]*serviceInstance
+
+func (serviceWatcher) Buffer(string) bool { return false }
+
+func (serviceWatcher) Complete(hcat.Notifier) bool { return true }
+
+func (w serviceWatcher) Recaller(hcat.Notifier) hcat.Recaller {
+	return func(d dep.Dependency) (interface{}, bool) {
+		services, ok := w[d.String()]
+		if !ok {
+			return []*serviceInstance{}, true
+		}
+		return services, true
+	}
+}
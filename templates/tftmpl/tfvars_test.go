@@ -0,0 +1,119 @@
+package tftmpl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/consul-terraform-sync/templates/hcltmpl"
+	"github.com/hashicorp/hcat"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParseTFVarsTemplate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    []string
+		expectError bool
+	}{
+		{
+			"empty",
+			"",
+			[]string{},
+			false,
+		}, {
+			"variables",
+			`ports = [
+{{- range $i, $s := service "api" }}{{ if $i }},{{ end }}{{ $s.Port }}{{ end -}}
+]
+nodes = {
+{{- range nodes }}
+  "{{ .Node }}" = "{{ .Address }}"
+{{- end }}
+}
+prefix = "{{ key "config/prefix" }}"`,
+			[]string{"nodes", "ports", "prefix"},
+			false,
+		}, {
+			"template functions",
+			`joined = "{{ joinStrings "." "a" "b" }}"`,
+			[]string{"joined"},
+			false,
+		}, {
+			"invalid template syntax",
+			`ports = {{ range service "api" }}`,
+			nil,
+			true,
+		}, {
+			"unknown function",
+			`ports = {{ unknownFunc "api" }}`,
+			nil,
+			true,
+		}, {
+			"invalid HCL",
+			`ports = [`,
+			nil,
+			true,
+		}, {
+			"blocks",
+			`block {
+  attr = "value"
+}`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParseTFVarsTemplate([]byte(tc.content), "test.tmpl")
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestNewTFVarsTmpl_escape(t *testing.T) {
+	// Literal text of the tfvars template is rendered as is
+	value := `{{ "secret" }} ${var.secret} %{ if true }"{{`
+	input := RootModuleInputData{
+		Providers: []hcltmpl.NamedBlock{hcltmpl.NewNamedBlock(
+			map[string]interface{}{
+				"testProvider": map[string]interface{}{
+					"attr": value,
+					"list": []interface{}{value},
+				},
+			})},
+		Services: []Service{{Name: "web"}},
+	}
+	input.Init()
+
+	var b bytes.Buffer
+	require.NoError(t, NewTFVarsTmpl(&b, &input))
+
+	tmpl := hcat.NewTemplate(hcat.TemplateInput{
+		Contents:     b.String(),
+		FuncMapMerge: HCLTmplFuncMap,
+	})
+	rendered, err := tmpl.Execute(serviceWatcher{})
+	require.NoError(t, err)
+
+	file, diags := hclsyntax.ParseConfig(rendered, "terraform.tfvars", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	attrs, diags := file.Body.JustAttributes()
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Len(t, attrs, 2)
+
+	provider, diags := attrs["testProvider"].Expr.Value(nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, value, provider.GetAttr("attr").AsString())
+	assert.Equal(t, value, provider.GetAttr("list").Index(cty.NumberIntVal(0)).AsString())
+}
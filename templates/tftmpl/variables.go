@@ -15,21 +15,51 @@ import (
 
 // VariableServices is versioned to track compatibility with the generated
 // root module with modules.
+//
+// Protocol v1 adds the kind, tagged addresses, admin partition, list of health
+// checks, DNS weights, and Connect proxy configuration of each service
+// instance. Modules written for protocol v0 remain compatible with v1,
+// since Terraform drops object attributes that are not declared by the module
+// variable type.
 var VariableServices = []byte(
-	`# Service definition protocol v0
+	`# Service definition protocol v1
+# Compatible with modules written for protocol v0
 variable "services" {
   description = "Consul services monitored by Consul Terraform Sync"
   type = map(
     object({
-      id        = string
-      name      = string
-      address   = string
-      port      = number
+      id      = string
+      name    = string
+      kind    = string
+      address = string
+      port    = number
+      tagged_addresses = map(object({
+        address = string
+        port    = number
+      }))
       meta      = map(string)
       tags      = list(string)
       namespace = string
+      partition = string
       status    = string
 
+      checks = list(object({
+        name   = string
+        status = string
+        output = string
+        type   = string
+      }))
+      weights = object({
+        passing = number
+        warning = number
+      })
+      proxy = object({
+        destination_service_name = string
+        destination_service_id   = string
+        local_service_address    = string
+        local_service_port       = number
+      })
+
       node                  = string
       node_id               = string
       node_address          = string
@@ -76,7 +106,8 @@ func NewVariablesTF(w io.Writer, input *RootModuleInputData) error {
 }
 
 // appendNamedBlockVariable creates an HCL file object that contains the variable
-// blocks used by the root module.
+// blocks used by the root module. Variables for sensitive blocks are declared
+// as sensitive.
 func appendNamedBlockVariable(body *hclwrite.Body, block hcltmpl.NamedBlock) {
 	pBody := body.AppendNewBlock("variable", []string{block.Name}).Body()
 	pBody.SetAttributeValue("default", cty.NullVal(*block.ObjectType()))
@@ -90,6 +121,9 @@ func appendNamedBlockVariable(body *hclwrite.Body, block hcltmpl.NamedBlock) {
 		Bytes: []byte(rawTypeAttr),
 	}})
 	pBody.AppendNewline()
+	if block.Sensitive {
+		pBody.SetAttributeValue("sensitive", cty.True)
+	}
 }
 
 // variableTypeString generates the raw Terraform type strings for supported